@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"time"
+	_ "time/tzdata" // zone names for the timezone setting on systems without a zone database
 
+	"github.com/Joseda-hg/lazytask/internal/cli"
 	"github.com/Joseda-hg/lazytask/internal/config"
 	"github.com/Joseda-hg/lazytask/internal/db"
 	"github.com/Joseda-hg/lazytask/internal/tui"
@@ -23,6 +30,7 @@ func main() {
 	webOnlyFlag := flag.Bool("web-only", false, "run web server only")
 	portFlag := flag.Int("port", 0, "web server port")
 	versionFlag := flag.Bool("version", false, "print version and exit")
+	flag.Usage = usage
 	flag.Parse()
 
 	if *versionFlag {
@@ -30,6 +38,12 @@ func main() {
 		return
 	}
 
+	if flag.NArg() > 0 && !cli.IsCommand(flag.Arg(0)) {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
 	cfgPath, err := resolveConfigPath(*configPathFlag)
 	if err != nil {
 		log.Fatal(err)
@@ -55,26 +69,60 @@ func main() {
 	if cfg.WebPort == 0 {
 		cfg.WebPort = 8080
 	}
+	if cfg.BindAddress == "" {
+		cfg.BindAddress = "localhost"
+	}
 
 	if err := config.Save(cfgPath, cfg); err != nil {
 		log.Fatal(err)
 	}
 
-	store, err := openStore(cfg.DBPath)
+	location := time.Local
+	if cfg.Timezone != "" {
+		if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			log.Fatalf("timezone: %v", err)
+		}
+	}
+
+	migrate := flag.NArg() == 0 || cli.MigratesOnOpen(flag.Arg(0))
+	store, err := openStore(cfg.DBPath, migrate)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// The CLI, the TUI and the web server read and show times in the
+	// store's location.
+	store = store.WithLocation(location)
+
+	if migrate && cfg.TrashRetentionDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -cfg.TrashRetentionDays)
+		if _, err := store.PurgeTrash(context.Background(), cutoff); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() > 0 {
+		cliStore := store.WithOrigin(db.Origin{Actor: currentUser(), Source: db.SourceCLI})
+		if err := cli.Run(context.Background(), cliStore, flag.Args(), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	if cfg.WebEnabled {
-		addr := fmt.Sprintf(":%d", cfg.WebPort)
-		handler := web.NewServer(store).Handler()
+		addr := net.JoinHostPort(cfg.BindAddress, strconv.Itoa(cfg.WebPort))
+		webStore := store.WithOrigin(db.Origin{Actor: currentUser(), Source: db.SourceWeb})
+		handler := web.NewServer(webStore, web.Options{BasicAuth: cfg.WebBasicAuth}).Handler()
+		if !cfg.WebBasicAuth && !isLoopback(cfg.BindAddress) {
+			log.Printf("warning: the web pages on %s are open to anyone who can reach them; set web_basic_auth to require a login", addr)
+		}
 		if *webOnlyFlag {
-			log.Printf("Web server running at http://localhost%s", addr)
+			log.Printf("Web server running at http://%s", addr)
 			log.Fatal(http.ListenAndServe(addr, handler))
 		}
 
 		go func() {
-			log.Printf("Web server running at http://localhost%s", addr)
+			log.Printf("Web server running at http://%s", addr)
 			if err := http.ListenAndServe(addr, handler); err != nil {
 				log.Printf("web server error: %v", err)
 			}
@@ -85,12 +133,28 @@ func main() {
 		return
 	}
 
-	if err := tui.Run(store); err != nil {
+	options := tui.Options{
+		PomodoroWork:  time.Duration(cfg.PomodoroWorkMinutes) * time.Minute,
+		PomodoroBreak: time.Duration(cfg.PomodoroBreakMinutes) * time.Minute,
+	}
+	if err := tui.Run(store.WithOrigin(db.Origin{Actor: currentUser(), Source: db.SourceTUI}), options); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "usage: lazytask [flags] [command [args]]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Without a command the TUI is started.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out)
+	cli.Usage(out)
+}
+
 func resolveConfigPath(flagValue string) (string, error) {
 	if flagValue != "" {
 		return flagValue, nil
@@ -98,12 +162,34 @@ func resolveConfigPath(flagValue string) (string, error) {
 	return config.DefaultConfigPath()
 }
 
-func openStore(dbPath string) (*db.Store, error) {
+// isLoopback reports whether host only accepts connections from this
+// machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// currentUser names the person recorded in task history.
+func currentUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return os.Getenv("USER")
+}
+
+func openStore(dbPath string, migrate bool) (*db.Store, error) {
 	if err := config.EnsureDir(dbPath); err != nil {
 		return nil, err
 	}
 
-	sqlDB, err := db.Open(dbPath)
+	open := db.Open
+	if !migrate {
+		open = db.Connect
+	}
+	sqlDB, err := open(dbPath)
 	if err != nil {
 		return nil, err
 	}
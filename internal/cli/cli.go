@@ -0,0 +1,1328 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, store *db.Store, args []string, out io.Writer) error
+	// manualMigrate commands receive a store whose pending migrations have not been applied.
+	manualMigrate bool
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "add", usage: "add [flags] TITLE", summary: "create a task", run: runAdd},
+		{name: "list", usage: "list [flags] [FILTER...]", summary: "list tasks matching a filter expression", run: runList},
+		{name: "search", usage: "search [flags] QUERY...", summary: "full-text search with ranked, highlighted results", run: runSearch},
+		{name: "show", usage: "show [flags] ID", summary: "show a task and its history", run: runShow},
+		{name: "log", usage: "log [flags]", summary: "show recent changes across all tasks", run: runLog},
+		{name: "edit", usage: "edit [flags] ID", summary: "update fields of a task", run: runEdit},
+		{name: "done", usage: "done [flags] ID...", summary: "mark tasks as done", run: runDone},
+		{name: "rm", usage: "rm ID...", summary: "move tasks to the trash", run: runRemove},
+		{name: "trash", usage: "trash [list|restore ID...|purge ID...|empty] [flags]", summary: "list, restore or permanently delete trashed tasks", run: runTrash},
+		{name: "tag", usage: "tag [flags] ID [TAG...]", summary: "list, add or remove tags of a task", run: runTag},
+		{name: "block", usage: "block [flags] ID [BLOCKER...]", summary: "list, add or remove the tasks a task waits on", run: runBlock},
+		{name: "time", usage: "time [[list] ID|start ID...|stop [ID...]|adjust ENTRY] [flags]", summary: "list, start, stop or adjust time entries", run: runTime},
+		{name: "report", usage: "report time [flags]", summary: "summarize tracked time by task or tag", run: runReport},
+		{name: "token", usage: "token [list|create NAME|revoke ID...] [flags]", summary: "manage API tokens for the web server", run: runToken},
+		{name: "db", usage: "db migrate [--status]", summary: "apply or inspect schema migrations", run: runDB, manualMigrate: true},
+	}
+}
+
+// IsCommand reports whether name is a known subcommand.
+func IsCommand(name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+// MigratesOnOpen reports whether the store passed to the named command should
+// have pending migrations applied when it is opened.
+func MigratesOnOpen(name string) bool {
+	cmd, ok := lookup(name)
+	return !ok || !cmd.manualMigrate
+}
+
+// Usage writes the list of subcommands to out.
+func Usage(out io.Writer) {
+	fmt.Fprintln(out, "Commands:")
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.usage, cmd.summary)
+	}
+	_ = tw.Flush()
+}
+
+// Run executes the subcommand named by args[0] against store.
+func Run(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command")
+	}
+	cmd, ok := lookup(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return cmd.run(ctx, store, args[1:], out)
+}
+
+func lookup(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		if cmd, ok := lookup(name); ok {
+			fmt.Fprintf(fs.Output(), "usage: lazytask %s\n", cmd.usage)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before or after positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+type taskFlags struct {
+	title       string
+	description string
+	status      string
+	priority    int64
+	due         string
+	tags        string
+	parent      int64
+	repeat      string
+}
+
+func (f *taskFlags) register(fs *flag.FlagSet, withTitle bool) {
+	if withTitle {
+		fs.StringVar(&f.title, "title", "", "task title")
+	}
+	fs.StringVar(&f.description, "desc", "", "task description")
+	fs.StringVar(&f.status, "status", "", "status (todo, doing, eventually, done)")
+	fs.Int64Var(&f.priority, "priority", 0, "priority")
+	fs.StringVar(&f.due, "due", "", "due date (YYYY-MM-DD, tomorrow, fri, +3d, \"tomorrow 14:00\" ..., or none)")
+	fs.StringVar(&f.tags, "tags", "", "comma separated tags")
+	fs.Int64Var(&f.parent, "parent", 0, "parent task ID (0 for none)")
+	fs.StringVar(&f.repeat, "repeat", "", "recurrence: daily, weekly, monthly, yearly or an RRULE such as FREQ=WEEKLY;BYDAY=MO (or none)")
+}
+
+func runAdd(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("add")
+	var fields taskFlags
+	fields.register(fs, false)
+	asJSON := fs.Bool("json", false, "print the created task as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	title := strings.TrimSpace(strings.Join(positional, " "))
+	if title == "" {
+		return fmt.Errorf("title is required")
+	}
+
+	due, err := parseDue(fields.due, store.Location())
+	if err != nil {
+		return err
+	}
+
+	input := db.TaskInput{
+		Title:       title,
+		Description: strings.TrimSpace(fields.description),
+		Status:      fields.status,
+		Priority:    fields.priority,
+		Recurrence:  parseRepeat(fields.repeat),
+		Tags:        parseTags(fields.tags),
+	}
+	input.SetDue(due)
+	if fields.parent != 0 {
+		parentID := fields.parent
+		input.ParentTaskID = &parentID
+	}
+
+	task, err := store.CreateTask(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(out, task)
+	}
+	fmt.Fprintf(out, "created task %d\n", task.ID)
+	return nil
+}
+
+func runList(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("list")
+	query := fs.String("q", "", "filter expression, e.g. 'status:doing tag:work due<2026-11-01'")
+	status := fs.String("status", "", "only tasks with this status")
+	tags := fs.String("tags", "", "comma separated tags")
+	tagMatch := fs.String("tag-match", model.TagMatchAny, "match tasks with any or all of --tags")
+	dueBefore := fs.String("due-before", "", "only tasks due on or before a date (YYYY-MM-DD, today, fri, +3d, ...)")
+	dueAfter := fs.String("due-after", "", "only tasks due on or after a date (YYYY-MM-DD, today, fri, +3d, ...)")
+	ready := fs.Bool("ready", false, "only unfinished tasks whose blockers are all done")
+	asJSON := fs.Bool("json", false, "print tasks as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *ready {
+		positional = append(positional, "is:ready")
+	}
+
+	filter := model.Filter{
+		Query:    strings.TrimSpace(strings.Join(append([]string{*query}, positional...), " ")),
+		Status:   strings.TrimSpace(strings.ToLower(*status)),
+		Tags:     parseTags(*tags),
+		TagMatch: strings.TrimSpace(strings.ToLower(*tagMatch)),
+	}
+	if filter.DueBefore, err = parseDueDay(*dueBefore, store.Location()); err != nil {
+		return err
+	}
+	if filter.DueAfter, err = parseDueDay(*dueAfter, store.Location()); err != nil {
+		return err
+	}
+
+	tasks, err := store.ListTasks(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if tasks == nil {
+			tasks = []model.Task{}
+		}
+		return writeJSON(out, tasks)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tPRI\tDUE\tTITLE\tTAGS")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\n", task.ID, task.Status, task.Priority, formatDue(task, store.Location()), task.Title, formatTags(task.Tags))
+	}
+	return tw.Flush()
+}
+
+func runSearch(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("search")
+	status := fs.String("status", "", "only tasks with this status")
+	tags := fs.String("tags", "", "comma separated tags")
+	tagMatch := fs.String("tag-match", model.TagMatchAny, "match tasks with any or all of --tags")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	query := strings.TrimSpace(strings.Join(positional, " "))
+	if query == "" {
+		return fmt.Errorf("query is required")
+	}
+
+	results, err := store.SearchTasks(ctx, model.Filter{
+		Query:    query,
+		Status:   strings.TrimSpace(strings.ToLower(*status)),
+		Tags:     parseTags(*tags),
+		TagMatch: strings.TrimSpace(strings.ToLower(*tagMatch)),
+	})
+	if err != nil {
+		return err
+	}
+
+	markers := strings.NewReplacer(db.SnippetMatchStart, "[", db.SnippetMatchEnd, "]", "\n", " ")
+	for i := range results {
+		results[i].Snippet = markers.Replace(results[i].Snippet)
+	}
+
+	if *asJSON {
+		if results == nil {
+			results = []model.SearchResult{}
+		}
+		return writeJSON(out, results)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tTITLE\tMATCH")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", result.Task.ID, result.Task.Status, result.Task.Title, result.Snippet)
+	}
+	return tw.Flush()
+}
+
+func runShow(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("show")
+	asJSON := fs.Bool("json", false, "print the task as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: lazytask show [flags] ID")
+	}
+
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	task, err := store.GetTaskWithTags(ctx, id)
+	if err != nil {
+		return taskError(id, err)
+	}
+
+	history, err := store.ListHistory(ctx, id)
+	if err != nil {
+		return err
+	}
+	blockedBy, err := store.ListBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+	blocks, err := store.ListDependents(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		payload := struct {
+			Task      model.Task           `json:"task"`
+			BlockedBy []model.Task         `json:"blocked_by"`
+			Blocks    []model.Task         `json:"blocks"`
+			History   []model.HistoryEntry `json:"history"`
+		}{Task: task, BlockedBy: blockedBy, Blocks: blocks, History: history}
+		return writeJSON(out, payload)
+	}
+
+	parent := "none"
+	if task.ParentTaskID != nil {
+		parent = strconv.FormatInt(*task.ParentTaskID, 10)
+	}
+
+	fmt.Fprintf(out, "#%d %s\n", task.ID, task.Title)
+	fmt.Fprintf(out, "Status: %s\n", task.Status)
+	fmt.Fprintf(out, "Priority: %d\n", task.Priority)
+	fmt.Fprintf(out, "Due: %s\n", formatDue(task, store.Location()))
+	fmt.Fprintf(out, "Parent: %s\n", parent)
+	fmt.Fprintf(out, "Tags: %s\n", formatTags(task.Tags))
+	if rule, err := model.ParseRecurrence(task.Recurrence); err == nil {
+		fmt.Fprintf(out, "Repeats: %s (%s)\n", rule.Describe(), rule)
+	}
+	if len(blockedBy) > 0 {
+		fmt.Fprintf(out, "Blocked by: %s\n", formatTaskRefs(blockedBy))
+	}
+	if len(blocks) > 0 {
+		fmt.Fprintf(out, "Blocks: %s\n", formatTaskRefs(blocks))
+	}
+	if task.Description != "" {
+		fmt.Fprintf(out, "\n%s\n", task.Description)
+	}
+	if len(history) > 0 {
+		fmt.Fprintln(out, "\nHistory:")
+		for _, entry := range history {
+			fmt.Fprintf(out, "  %s | %s | %s\n", entry.CreatedAt.In(store.Location()).Format("2006-01-02 15:04"), entry.EventType, entry.Details(store.Location()))
+		}
+	}
+	return nil
+}
+
+// activityPageSize is how many history entries log reads per query.
+const activityPageSize = 500
+
+func runLog(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("log")
+	sinceFlag := fs.String("since", "today", "show changes since today, yesterday, week, a number of days (3d), a duration (12h) or a date (YYYY-MM-DD)")
+	limit := fs.Int("limit", 0, "show at most this many entries (0 for no limit)")
+	asJSON := fs.Bool("json", false, "print the entries as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: lazytask log [flags]")
+	}
+
+	since, err := model.ParseSince(*sinceFlag, time.Now().In(store.Location()))
+	if err != nil {
+		return err
+	}
+
+	activity := []model.ActivityEntry{}
+	for {
+		pageSize := activityPageSize
+		if *limit > 0 {
+			pageSize = min(pageSize, *limit-len(activity))
+		}
+		page, err := store.ListActivity(ctx, since, pageSize, len(activity))
+		if err != nil {
+			return err
+		}
+		activity = append(activity, page...)
+		if len(page) < pageSize || (*limit > 0 && len(activity) >= *limit) {
+			break
+		}
+	}
+
+	if *asJSON {
+		return writeJSON(out, activity)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tID\tTASK\tBY\tCHANGE")
+	for _, entry := range activity {
+		by := entry.Actor
+		if by == "" {
+			by = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", entry.CreatedAt.In(store.Location()).Format("2006-01-02 15:04"), entry.TaskID, entry.TaskTitle, by, entry.Details(store.Location()))
+	}
+	return tw.Flush()
+}
+
+func runEdit(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("edit")
+	var fields taskFlags
+	fields.register(fs, true)
+	asJSON := fs.Bool("json", false, "print the updated task as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: lazytask edit [flags] ID")
+	}
+
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	task, err := store.GetTaskWithTags(ctx, id)
+	if err != nil {
+		return taskError(id, err)
+	}
+
+	input := db.InputFromTask(task)
+	var visitErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			input.Title = strings.TrimSpace(fields.title)
+		case "desc":
+			input.Description = strings.TrimSpace(fields.description)
+		case "status":
+			input.Status = fields.status
+		case "priority":
+			input.Priority = fields.priority
+		case "due":
+			due, err := parseDue(fields.due, store.Location())
+			if err != nil {
+				visitErr = err
+				return
+			}
+			input.SetDue(due)
+		case "tags":
+			input.Tags = parseTags(fields.tags)
+		case "repeat":
+			input.Recurrence = parseRepeat(fields.repeat)
+		case "parent":
+			if fields.parent == 0 {
+				input.ParentTaskID = nil
+			} else {
+				parentID := fields.parent
+				input.ParentTaskID = &parentID
+			}
+		}
+	})
+	if visitErr != nil {
+		return visitErr
+	}
+	if input.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+
+	updated, err := store.UpdateTask(ctx, id, input)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(out, updated)
+	}
+	fmt.Fprintf(out, "updated task %d\n", updated.ID)
+	return nil
+}
+
+func runDone(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("done")
+	asJSON := fs.Bool("json", false, "print the updated tasks as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(positional)
+	if err != nil {
+		return err
+	}
+
+	updated := make([]model.Task, 0, len(ids))
+	err = store.WithTx(ctx, func(tx *db.Store) error {
+		for _, id := range ids {
+			task, err := tx.GetTaskWithTags(ctx, id)
+			if err != nil {
+				return taskError(id, err)
+			}
+			input := db.InputFromTask(task)
+			input.Status = "done"
+			task, err = tx.UpdateTask(ctx, id, input)
+			if err != nil {
+				return err
+			}
+			updated = append(updated, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return writeJSON(out, updated)
+	}
+	for _, task := range updated {
+		nextID, ok, err := store.NextOccurrenceID(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fmt.Fprintf(out, "completed task %d, next occurrence is task %d\n", task.ID, nextID)
+			continue
+		}
+		fmt.Fprintf(out, "completed task %d\n", task.ID)
+	}
+	return nil
+}
+
+func runRemove(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("rm")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	ids, err := parseIDs(positional)
+	if err != nil {
+		return err
+	}
+
+	err = store.WithTx(ctx, func(tx *db.Store) error {
+		for _, id := range ids {
+			if err := tx.DeleteTask(ctx, id); err != nil {
+				return taskError(id, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		fmt.Fprintf(out, "moved task %d to the trash\n", id)
+	}
+	return nil
+}
+
+func runTrash(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	fs := newFlagSet("trash")
+	olderThan := fs.Int("older-than", 0, "with empty, only purge tasks deleted at least this many days ago")
+	asJSON := fs.Bool("json", false, "print the trashed or restored tasks as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		tasks, err := store.ListTrash(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			if tasks == nil {
+				tasks = []model.Task{}
+			}
+			return writeJSON(out, tasks)
+		}
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tDELETED\tSTATUS\tTITLE\tTAGS")
+		for _, task := range tasks {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", task.ID, task.DeletedAt.In(store.Location()).Format("2006-01-02 15:04"), task.Status, task.Title, formatTags(task.Tags))
+		}
+		return tw.Flush()
+	case "restore":
+		ids, err := parseIDs(positional)
+		if err != nil {
+			return err
+		}
+		restored := make([]model.Task, 0, len(ids))
+		err = store.WithTx(ctx, func(tx *db.Store) error {
+			for _, id := range ids {
+				task, err := tx.RestoreFromTrash(ctx, id)
+				if err != nil {
+					return taskError(id, err)
+				}
+				restored = append(restored, task)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(out, restored)
+		}
+		for _, task := range restored {
+			fmt.Fprintf(out, "restored task %d\n", task.ID)
+		}
+		return nil
+	case "purge":
+		ids, err := parseIDs(positional)
+		if err != nil {
+			return err
+		}
+		err = store.WithTx(ctx, func(tx *db.Store) error {
+			for _, id := range ids {
+				if err := tx.PurgeTask(ctx, id); err != nil {
+					return taskError(id, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Fprintf(out, "purged task %d\n", id)
+		}
+		return nil
+	case "empty":
+		if *olderThan < 0 {
+			return fmt.Errorf("--older-than must not be negative")
+		}
+		purged, err := store.PurgeTrash(ctx, time.Now().AddDate(0, 0, -*olderThan))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "purged %d tasks\n", purged)
+		return nil
+	default:
+		return fmt.Errorf("unknown trash action %q (want list, restore, purge or empty)", action)
+	}
+}
+
+func runTag(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("tag")
+	remove := fs.Bool("rm", false, "remove the given tags instead of adding them")
+	set := fs.Bool("set", false, "replace all tags with the given tags")
+	asJSON := fs.Bool("json", false, "print the task tags as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("usage: lazytask tag [flags] ID [TAG...]")
+	}
+	if *remove && *set {
+		return fmt.Errorf("--rm and --set cannot be combined")
+	}
+
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	task, err := store.GetTaskWithTags(ctx, id)
+	if err != nil {
+		return taskError(id, err)
+	}
+
+	names := parseTags(strings.Join(positional[1:], ","))
+	if len(names) > 0 || *set {
+		input := db.InputFromTask(task)
+		switch {
+		case *set:
+			input.Tags = names
+		case *remove:
+			input.Tags = removeTags(input.Tags, names)
+		default:
+			input.Tags = append(input.Tags, names...)
+		}
+		task, err = store.UpdateTask(ctx, id, input)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *asJSON {
+		tags := task.Tags
+		if tags == nil {
+			tags = []model.Tag{}
+		}
+		return writeJSON(out, tags)
+	}
+	fmt.Fprintln(out, formatTags(task.Tags))
+	return nil
+}
+
+func runBlock(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	fs := newFlagSet("block")
+	remove := fs.Bool("rm", false, "remove the given blockers instead of adding them")
+	asJSON := fs.Bool("json", false, "print the blocking tasks as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("usage: lazytask block [flags] ID [BLOCKER...]")
+	}
+
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	var blockerIDs []int64
+	if len(positional) > 1 {
+		if blockerIDs, err = parseIDs(positional[1:]); err != nil {
+			return err
+		}
+	}
+
+	err = store.WithTx(ctx, func(tx *db.Store) error {
+		for _, blockerID := range blockerIDs {
+			if *remove {
+				if err := tx.RemoveBlocker(ctx, id, blockerID); err != nil {
+					if errors.Is(err, sql.ErrNoRows) {
+						return fmt.Errorf("task %d is not blocked by task %d", id, blockerID)
+					}
+					return err
+				}
+				continue
+			}
+			if err := tx.AddBlocker(ctx, id, blockerID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("task %d or %d not found", id, blockerID)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := store.GetTaskWithTags(ctx, id); err != nil {
+		return taskError(id, err)
+	}
+	blockers, err := store.ListBlockers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if blockers == nil {
+			blockers = []model.Task{}
+		}
+		return writeJSON(out, blockers)
+	}
+	if len(blockers) == 0 {
+		fmt.Fprintf(out, "task %d is not blocked\n", id)
+		return nil
+	}
+	fmt.Fprintf(out, "task %d is blocked by %s\n", id, formatTaskRefs(blockers))
+	return nil
+}
+
+func runTime(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if _, err := parseID(args[0]); err != nil {
+			action, args = args[0], args[1:]
+		}
+	}
+
+	fs := newFlagSet("time")
+	start := fs.String("start", "", "with adjust, when the entry started (15:04, 2006-01-02 15:04 or RFC 3339)")
+	end := fs.String("end", "", "with adjust, when the entry ended, or 'running'")
+	duration := fs.Duration("duration", 0, "with adjust, end the entry this long after it started")
+	asJSON := fs.Bool("json", false, "print the time entries as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: lazytask time list ID")
+		}
+		id, err := parseID(positional[0])
+		if err != nil {
+			return err
+		}
+		if _, err := store.GetTaskWithTags(ctx, id); err != nil {
+			return taskError(id, err)
+		}
+		entries, err := store.ListTimeEntries(ctx, id)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(out, entries)
+		}
+		now := time.Now()
+		var total time.Duration
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ENTRY	START	END	TIME")
+		for _, entry := range entries {
+			ended := "running"
+			if entry.EndedAt != nil {
+				ended = entry.EndedAt.In(store.Location()).Format("2006-01-02 15:04")
+			}
+			total += entry.Duration(now)
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", entry.ID, entry.StartedAt.In(store.Location()).Format("2006-01-02 15:04"), ended, model.FormatDuration(entry.Duration(now)))
+		}
+		fmt.Fprintf(tw, "TOTAL\t\t\t%s\n", model.FormatDuration(total))
+		return tw.Flush()
+	case "start":
+		ids, err := parseIDs(positional)
+		if err != nil {
+			return err
+		}
+		started := make([]model.TimeEntry, 0, len(ids))
+		err = store.WithTx(ctx, func(tx *db.Store) error {
+			for _, id := range ids {
+				entry, err := tx.StartTimer(ctx, id)
+				if err != nil {
+					return taskError(id, err)
+				}
+				started = append(started, entry)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(out, started)
+		}
+		for _, entry := range started {
+			fmt.Fprintf(out, "timer for task %d running since %s\n", entry.TaskID, entry.StartedAt.In(store.Location()).Format("15:04"))
+		}
+		return nil
+	case "stop":
+		var ids []int64
+		if len(positional) == 0 {
+			running, err := store.ListRunningTimers(ctx)
+			if err != nil {
+				return err
+			}
+			for _, entry := range running {
+				ids = append(ids, entry.TaskID)
+			}
+		} else if ids, err = parseIDs(positional); err != nil {
+			return err
+		}
+		stopped := make([]model.TimeEntry, 0, len(ids))
+		err = store.WithTx(ctx, func(tx *db.Store) error {
+			for _, id := range ids {
+				entry, err := tx.StopTimer(ctx, id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("task %d has no running timer", id)
+				}
+				if err != nil {
+					return err
+				}
+				stopped = append(stopped, entry)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(out, stopped)
+		}
+		if len(stopped) == 0 {
+			fmt.Fprintln(out, "no timers running")
+		}
+		for _, entry := range stopped {
+			fmt.Fprintf(out, "stopped timer for task %d after %s\n", entry.TaskID, model.FormatDuration(entry.Duration(time.Now())))
+		}
+		return nil
+	case "adjust":
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: lazytask time adjust ENTRY [--start TIME] [--end TIME|running] [--duration 1h30m]")
+		}
+		entryID, err := strconv.ParseInt(positional[0], 10, 64)
+		if err != nil || entryID <= 0 {
+			return fmt.Errorf("invalid time entry %q", positional[0])
+		}
+		entry, err := store.GetTimeEntry(ctx, entryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("time entry %d not found", entryID)
+		}
+		if err != nil {
+			return err
+		}
+
+		startedAt, endedAt := entry.StartedAt, entry.EndedAt
+		if *start != "" {
+			if startedAt, err = parseTime(*start, time.Now().In(store.Location())); err != nil {
+				return err
+			}
+		}
+		switch {
+		case *duration != 0 && *end != "":
+			return fmt.Errorf("--end and --duration cannot be combined")
+		case *duration < 0:
+			return fmt.Errorf("--duration must not be negative")
+		case *duration > 0:
+			ended := startedAt.Add(*duration)
+			endedAt = &ended
+		case strings.EqualFold(strings.TrimSpace(*end), "running"):
+			endedAt = nil
+		case *end != "":
+			ended, err := parseTime(*end, time.Now().In(store.Location()))
+			if err != nil {
+				return err
+			}
+			endedAt = &ended
+		}
+
+		entry, err = store.AdjustTimeEntry(ctx, entryID, startedAt, endedAt)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(out, entry)
+		}
+		fmt.Fprintf(out, "time entry %d of task %d now lasts %s\n", entry.ID, entry.TaskID, model.FormatDuration(entry.Duration(time.Now())))
+		return nil
+	default:
+		return fmt.Errorf("unknown time action %q (want list, start, stop or adjust)", action)
+	}
+}
+
+// tokenJSON is an API token as `token --json` prints it. Secret is only
+// set when the token is created.
+type tokenJSON struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+	Secret    string    `json:"secret,omitempty"`
+}
+
+func newTokenJSON(token model.APIToken, secret string) tokenJSON {
+	return tokenJSON{ID: token.ID, Name: token.Name, Scope: token.Scope, CreatedAt: token.CreatedAt, Secret: secret}
+}
+
+// reportRow is the time tracked for one task or tag in a report.
+type reportRow struct {
+	Name    string `json:"name"`
+	Seconds int64  `json:"seconds"`
+}
+
+func runReport(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "time" {
+		return fmt.Errorf("usage: lazytask report time [flags]")
+	}
+
+	fs := newFlagSet("report")
+	sinceFlag := fs.String("since", "week", "count time since today, yesterday, week, a number of days (3d), a duration (12h) or a date (YYYY-MM-DD)")
+	by := fs.String("by", "task", "group by task or tag; tasks with several tags count towards each")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: lazytask report time [flags]")
+	}
+	if *by != "task" && *by != "tag" {
+		return fmt.Errorf("invalid --by %q (want task or tag)", *by)
+	}
+
+	now := time.Now().In(store.Location())
+	since, err := model.ParseSince(*sinceFlag, now)
+	if err != nil {
+		return err
+	}
+	entries, err := store.ListTimeEntriesSince(ctx, since)
+	if err != nil {
+		return err
+	}
+
+	tasks := make(map[int64]model.Task)
+	totals := make(map[string]time.Duration)
+	var total time.Duration
+	for _, entry := range entries {
+		task, ok := tasks[entry.TaskID]
+		if !ok {
+			if task, err = store.GetTaskWithTags(ctx, entry.TaskID); err != nil {
+				return err
+			}
+			tasks[entry.TaskID] = task
+		}
+
+		spent := entry.Within(since, now)
+		total += spent
+		if *by == "task" {
+			totals[fmt.Sprintf("#%d %s", task.ID, task.Title)] += spent
+			continue
+		}
+		if len(task.Tags) == 0 {
+			totals["-"] += spent
+		}
+		for _, tag := range task.Tags {
+			totals[tag.Name] += spent
+		}
+	}
+
+	rows := make([]reportRow, 0, len(totals))
+	for name, spent := range totals {
+		rows = append(rows, reportRow{Name: name, Seconds: int64(spent / time.Second)})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Seconds == rows[j].Seconds {
+			return rows[i].Name < rows[j].Name
+		}
+		return rows[i].Seconds > rows[j].Seconds
+	})
+
+	if *asJSON {
+		payload := struct {
+			Since        time.Time   `json:"since"`
+			By           string      `json:"by"`
+			Rows         []reportRow `json:"rows"`
+			TotalSeconds int64       `json:"total_seconds"`
+		}{Since: since, By: *by, Rows: rows, TotalSeconds: int64(total / time.Second)}
+		return writeJSON(out, payload)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tTIME\n", strings.ToUpper(*by))
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", row.Name, model.FormatDuration(time.Duration(row.Seconds)*time.Second))
+	}
+	fmt.Fprintf(tw, "TOTAL\t%s\n", model.FormatDuration(total))
+	return tw.Flush()
+}
+
+func runToken(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	action := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	fs := newFlagSet("token")
+	scope := fs.String("scope", model.ScopeRead, "with create, what the token may do: read or read-write")
+	asJSON := fs.Bool("json", false, "print the tokens as JSON")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		tokens, err := store.ListAPITokens(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			rows := make([]tokenJSON, 0, len(tokens))
+			for _, token := range tokens {
+				rows = append(rows, newTokenJSON(token, ""))
+			}
+			return writeJSON(out, rows)
+		}
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tSCOPE\tCREATED\tNAME")
+		for _, token := range tokens {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", token.ID, token.Scope, token.CreatedAt.In(store.Location()).Format("2006-01-02 15:04"), token.Name)
+		}
+		return tw.Flush()
+	case "create":
+		if len(positional) == 0 {
+			return fmt.Errorf("usage: lazytask token create [--scope read|read-write] NAME")
+		}
+		token, secret, err := store.CreateAPIToken(ctx, strings.Join(positional, " "), *scope)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(out, newTokenJSON(token, secret))
+		}
+		fmt.Fprintf(out, "created %s token %d %q; it is shown only once:\n%s\n", token.Scope, token.ID, token.Name, secret)
+		return nil
+	case "revoke":
+		if len(positional) == 0 {
+			return fmt.Errorf("usage: lazytask token revoke ID...")
+		}
+		ids := make([]int64, 0, len(positional))
+		for _, value := range positional {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid token ID %q", value)
+			}
+			ids = append(ids, id)
+		}
+		err = store.WithTx(ctx, func(tx *db.Store) error {
+			for _, id := range ids {
+				if err := tx.RevokeAPIToken(ctx, id); errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("token %d not found", id)
+				} else if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Fprintf(out, "revoked token %d\n", id)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown token action %q (want list, create or revoke)", action)
+	}
+}
+
+func runDB(ctx context.Context, store *db.Store, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "migrate" {
+		return fmt.Errorf("usage: lazytask db migrate [--status]")
+	}
+
+	fs := newFlagSet("db")
+	statusOnly := fs.Bool("status", false, "list migrations and whether they are applied, without applying them")
+	if _, err := parseArgs(fs, args[1:]); err != nil {
+		return err
+	}
+
+	if *statusOnly {
+		statuses, err := db.Status(ctx, store.DB)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return tw.Flush()
+	}
+
+	applied, err := db.Migrate(ctx, store.DB)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Fprintln(out, "database is up to date")
+		return nil
+	}
+	for _, migration := range applied {
+		fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+	}
+	return nil
+}
+
+func removeTags(current, remove []string) []string {
+	drop := make(map[string]struct{}, len(remove))
+	for _, name := range remove {
+		drop[strings.ToLower(name)] = struct{}{}
+	}
+	result := make([]string, 0, len(current))
+	for _, name := range current {
+		if _, ok := drop[strings.ToLower(name)]; ok {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+func parseID(value string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(value), "#"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid task ID %q", value)
+	}
+	return id, nil
+}
+
+func parseIDs(values []string) ([]int64, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("at least one task ID is required")
+	}
+	ids := make([]int64, 0, len(values))
+	for _, value := range values {
+		id, err := parseID(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseDue parses a due date given in loc; "none" means no due date.
+func parseDue(value string, loc *time.Location) (*duedate.Due, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || strings.EqualFold(trimmed, "none") {
+		return nil, nil
+	}
+	due, err := duedate.Parse(trimmed, time.Now().In(loc))
+	if err != nil {
+		return nil, fmt.Errorf("invalid due date %q", value)
+	}
+	return &due, nil
+}
+
+// parseDueDay parses a due date into the day the list filters take.
+func parseDueDay(value string, loc *time.Location) (*time.Time, error) {
+	due, err := parseDue(value, loc)
+	if due == nil || err != nil {
+		return nil, err
+	}
+	day := due.Day()
+	return &day, nil
+}
+
+// parseTime reads a point in time as a clock time today ("15:04") or a date
+// and time ("2006-01-02 15:04"), both in now's location, or RFC 3339.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if clock, err := time.ParseInLocation("15:04", value, now.Location()); err == nil {
+		year, month, day := now.Date()
+		return time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, now.Location()), nil
+	}
+	if parsed, err := time.ParseInLocation("2006-01-02 15:04", value, now.Location()); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (want 15:04, 2006-01-02 15:04 or RFC 3339)", value)
+}
+
+// parseRepeat reads a --repeat value, where "none" clears the recurrence.
+func parseRepeat(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "none") {
+		return ""
+	}
+	return value
+}
+
+func parseTags(value string) []string {
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if trimmed == "" {
+			continue
+		}
+		result = append(result, trimmed)
+	}
+	return result
+}
+
+func formatDue(task model.Task, loc *time.Location) string {
+	if task.DueAt == nil {
+		return "-"
+	}
+	return duedate.Format(*task.DueAt, task.DueAllDay, loc)
+}
+
+func formatTags(tags []model.Tag) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// formatTaskRefs lists tasks as "#1 Title (status)", comma separated.
+func formatTaskRefs(tasks []model.Task) string {
+	refs := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		refs = append(refs, fmt.Sprintf("#%d %s (%s)", task.ID, task.Title, task.Status))
+	}
+	return strings.Join(refs, ", ")
+}
+
+func taskError(id int64, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("task %d not found", id)
+	}
+	return err
+}
+
+func writeJSON(out io.Writer, payload any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(payload)
+}
@@ -0,0 +1,476 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestAddEditDoneAndRemove(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"add", "--tags", "work,ops", "Rotate", "keys", "--priority", "2", "--json"}, &out); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	var created model.Task
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("decode add output: %v", err)
+	}
+	if created.Title != "Rotate keys" {
+		t.Fatalf("expected title 'Rotate keys', got %q", created.Title)
+	}
+	if created.Priority != 2 {
+		t.Fatalf("expected priority 2, got %d", created.Priority)
+	}
+	if len(created.Tags) != 2 {
+		t.Fatalf("expected 2 tags, got %d", len(created.Tags))
+	}
+
+	id := strconv.FormatInt(created.ID, 10)
+	if err := Run(context.Background(), store, []string{"edit", id, "--desc", "Quarterly", "--due", "2026-11-01"}, &out); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"tag", "--rm", id, "ops"}, &out); err != nil {
+		t.Fatalf("tag: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"done", id}, &out); err != nil {
+		t.Fatalf("done: %v", err)
+	}
+
+	task, err := store.GetTaskWithTags(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Description != "Quarterly" {
+		t.Fatalf("expected description 'Quarterly', got %q", task.Description)
+	}
+	if task.DueAt == nil || task.DueAt.Format("2006-01-02") != "2026-11-01" {
+		t.Fatalf("expected due 2026-11-01, got %v", task.DueAt)
+	}
+	if task.Status != "done" {
+		t.Fatalf("expected status 'done', got %q", task.Status)
+	}
+	if len(task.Tags) != 1 || task.Tags[0].Name != "work" {
+		t.Fatalf("expected only tag 'work', got %v", task.Tags)
+	}
+	if task.Priority != 2 {
+		t.Fatalf("expected edit to keep priority 2, got %d", task.Priority)
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"rm", id}, &out); err != nil {
+		t.Fatalf("rm: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"show", id}, &out); err == nil {
+		t.Fatalf("expected show of deleted task to fail")
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"trash", "--json"}, &out); err != nil {
+		t.Fatalf("trash list: %v", err)
+	}
+	var trashed []model.Task
+	if err := json.Unmarshal(out.Bytes(), &trashed); err != nil {
+		t.Fatalf("decode trash output: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0].ID != created.ID || trashed[0].DeletedAt == nil {
+		t.Fatalf("expected task %d in the trash, got %+v", created.ID, trashed)
+	}
+	if err := Run(context.Background(), store, []string{"trash", "restore", id}, &out); err != nil {
+		t.Fatalf("trash restore: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"show", id}, &out); err != nil {
+		t.Fatalf("show restored task: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"trash", "purge", id}, &out); err == nil {
+		t.Fatalf("expected purge of a task outside the trash to fail")
+	}
+	if err := Run(context.Background(), store, []string{"rm", id}, &out); err != nil {
+		t.Fatalf("rm again: %v", err)
+	}
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"trash", "empty"}, &out); err != nil {
+		t.Fatalf("trash empty: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "purged 1 tasks" {
+		t.Fatalf("expected one purged task, got %q", out.String())
+	}
+}
+
+func TestListFiltersAndFormats(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, title := range []string{"Buy milk", "Write report"} {
+		if _, err := store.CreateTask(context.Background(), db.TaskInput{Title: title}); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"list", "--q", "report", "--json"}, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var tasks []model.Task
+	if err := json.Unmarshal(out.Bytes(), &tasks); err != nil {
+		t.Fatalf("decode list output: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Write report" {
+		t.Fatalf("expected only 'Write report', got %v", tasks)
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"list", "--json", "prio<1", "--", "-milk"}, &out); err != nil {
+		t.Fatalf("list with expression: %v", err)
+	}
+	tasks = nil
+	if err := json.Unmarshal(out.Bytes(), &tasks); err != nil {
+		t.Fatalf("decode list output: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Write report" {
+		t.Fatalf("expected expression to leave 'Write report', got %v", tasks)
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"list"}, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("expected header row, got %q", lines[0])
+	}
+}
+
+func TestSearchHighlightsMatches(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if _, err := store.CreateTask(context.Background(), db.TaskInput{Title: "Renew certificates", Description: "Before they expire"}); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"search", "--json", "certificate"}, &out); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	var results []model.SearchResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("decode search output: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if !strings.Contains(results[0].Snippet, "[certificates]") {
+		t.Fatalf("expected highlighted snippet, got %q", results[0].Snippet)
+	}
+}
+
+func TestLogShowsActivityAcrossTasks(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, title := range []string{"Buy milk", "Write report"} {
+		if _, err := store.CreateTask(context.Background(), db.TaskInput{Title: title}); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"log", "--since", "week", "--json"}, &out); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	var activity []model.ActivityEntry
+	if err := json.Unmarshal(out.Bytes(), &activity); err != nil {
+		t.Fatalf("decode log output: %v", err)
+	}
+	if len(activity) != 2 || activity[0].TaskTitle != "Write report" || activity[1].TaskTitle != "Buy milk" {
+		t.Fatalf("expected both creations newest first, got %+v", activity)
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"log", "--limit", "1"}, &out); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "Write report") {
+		t.Fatalf("expected header and the newest entry, got %q", out.String())
+	}
+}
+
+func TestAddAcceptsNaturalDueDates(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"add", "--due", "tomorrow", "Renew passport"}, &out); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"add", "--due", "+2w", "File taxes"}, &out); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	now := time.Now()
+	for id, want := range map[int64]time.Time{1: now.AddDate(0, 0, 1), 2: now.AddDate(0, 0, 14)} {
+		task, err := store.GetTaskWithTags(context.Background(), id)
+		if err != nil {
+			t.Fatalf("get task: %v", err)
+		}
+		if task.DueAt == nil || task.DueAt.Format("2006-01-02") != want.Format("2006-01-02") {
+			t.Fatalf("expected task %d due %s, got %v", id, want.Format("2006-01-02"), task.DueAt)
+		}
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"list", "--due-before", "+1w"}, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(out.String(), "Renew passport") || strings.Contains(out.String(), "File taxes") {
+		t.Fatalf("expected only the task due tomorrow, got %q", out.String())
+	}
+}
+
+func TestDoneCreatesNextOccurrence(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"add", "--repeat", "FREQ=MONTHLY;BYMONTHDAY=1", "--due", "2026-11-01", "Pay rent"}, &out); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"done", "1"}, &out); err != nil {
+		t.Fatalf("done: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "completed task 1, next occurrence is task 2" {
+		t.Fatalf("expected the next occurrence to be reported, got %q", out.String())
+	}
+
+	next, err := store.GetTaskWithTags(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("get next occurrence: %v", err)
+	}
+	if next.DueAt == nil || next.DueAt.Format("2006-01-02") != "2026-12-01" {
+		t.Fatalf("expected next occurrence due 2026-12-01, got %v", next.DueAt)
+	}
+
+	if err := Run(context.Background(), store, []string{"edit", "2", "--repeat", "none"}, &out); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	if next, err = store.GetTaskWithTags(context.Background(), 2); err != nil || next.Recurrence != "" {
+		t.Fatalf("expected --repeat none to clear the recurrence, got %q (%v)", next.Recurrence, err)
+	}
+}
+
+func TestBlockAndListReadyTasks(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	for _, title := range []string{"Design", "Build"} {
+		if err := Run(context.Background(), store, []string{"add", title}, &out); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"block", "2", "1"}, &out); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "task 2 is blocked by #1 Design (todo)" {
+		t.Fatalf("unexpected block output %q", out.String())
+	}
+	if err := Run(context.Background(), store, []string{"block", "1", "2"}, &out); err == nil {
+		t.Fatalf("expected a cycle to be rejected")
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"list", "--ready"}, &out); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if !strings.Contains(out.String(), "Design") || strings.Contains(out.String(), "Build") {
+		t.Fatalf("expected only Design to be ready, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"show", "1"}, &out); err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	if !strings.Contains(out.String(), "Blocks: #2 Build (todo)") {
+		t.Fatalf("expected show to list the blocked task, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"block", "--rm", "2", "1"}, &out); err != nil {
+		t.Fatalf("unblock: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "task 2 is not blocked" {
+		t.Fatalf("unexpected unblock output %q", out.String())
+	}
+	if err := Run(context.Background(), store, []string{"block", "--rm", "2", "1"}, &out); err == nil {
+		t.Fatalf("expected removing a missing blocker to fail")
+	}
+}
+
+func TestTimeTrackingReport(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), store, []string{"add", "--status", "doing", "--tags", "work", "Write report"}, &out); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"add", "Water plants"}, &out); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := Run(context.Background(), store, []string{"time", "start", "2"}, &out); err != nil {
+		t.Fatalf("time start: %v", err)
+	}
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"time", "stop"}, &out); err != nil {
+		t.Fatalf("time stop: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 {
+		t.Fatalf("expected both timers to stop, got %q", out.String())
+	}
+	if err := Run(context.Background(), store, []string{"time", "stop", "1"}, &out); err == nil {
+		t.Fatalf("expected stopping a stopped timer to fail")
+	}
+
+	start := time.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+	for _, args := range [][]string{
+		{"time", "adjust", "1", "--start", start, "--duration", "90m"},
+		{"time", "adjust", "2", "--start", start, "--duration", "15m"},
+	} {
+		if err := Run(context.Background(), store, args, &out); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	if err := Run(context.Background(), store, []string{"time", "adjust", "1", "--end", "2000-01-01 10:00"}, &out); err == nil {
+		t.Fatalf("expected an entry ending before it starts to be rejected")
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"report", "time", "--since", "1d", "--by", "tag"}, &out); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || strings.Fields(lines[1])[0] != "work" || strings.Fields(lines[1])[1] != "1h30m" || strings.Fields(lines[2])[0] != "-" || strings.Fields(lines[3])[1] != "1h45m" {
+		t.Fatalf("unexpected report %q", out.String())
+	}
+
+	out.Reset()
+	if err := Run(context.Background(), store, []string{"time", "1"}, &out); err != nil {
+		t.Fatalf("time list: %v", err)
+	}
+	if !strings.Contains(out.String(), "TOTAL") || !strings.Contains(out.String(), "1h30m") {
+		t.Fatalf("expected the entries of task 1, got %q", out.String())
+	}
+}
+
+func TestTokenCreateListAndRevoke(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := Run(ctx, store, []string{"token", "create", "--scope", "read-write", "phone"}, &out); err != nil {
+		t.Fatalf("token create: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	secret := lines[len(lines)-1]
+	token, err := store.AuthenticateToken(ctx, secret)
+	if err != nil || token.Name != "phone" || !token.CanWrite() {
+		t.Fatalf("expected the printed secret to authenticate a read-write token, got %+v (%v)", token, err)
+	}
+
+	out.Reset()
+	if err := Run(ctx, store, []string{"token", "list"}, &out); err != nil {
+		t.Fatalf("token list: %v", err)
+	}
+	if !strings.Contains(out.String(), "phone") || strings.Contains(out.String(), secret) {
+		t.Fatalf("expected the token to be listed without its secret, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := Run(ctx, store, []string{"token", "create", "--json", "ci"}, &out); err != nil {
+		t.Fatalf("token create --json: %v", err)
+	}
+	var created map[string]any
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	for _, key := range []string{"id", "name", "scope", "created_at", "secret"} {
+		if _, ok := created[key]; !ok {
+			t.Fatalf("expected key %q in %s", key, out.String())
+		}
+	}
+	out.Reset()
+	if err := Run(ctx, store, []string{"token", "list", "--json"}, &out); err != nil {
+		t.Fatalf("token list --json: %v", err)
+	}
+	if !strings.Contains(out.String(), `"name": "phone"`) || strings.Contains(out.String(), "secret") {
+		t.Fatalf("expected snake_case tokens without secrets, got %s", out.String())
+	}
+
+	if err := Run(ctx, store, []string{"token", "revoke", "1"}, &out); err != nil {
+		t.Fatalf("token revoke: %v", err)
+	}
+	if _, err := store.AuthenticateToken(ctx, secret); err == nil {
+		t.Fatalf("expected a revoked token to stop working")
+	}
+	if err := Run(ctx, store, []string{"token", "revoke", "1"}, &out); err == nil {
+		t.Fatalf("expected revoking a missing token to fail")
+	}
+	if err := Run(ctx, store, []string{"token", "create", "--scope", "admin", "ci"}, &out); err == nil {
+		t.Fatalf("expected an unknown scope to be rejected")
+	}
+}
+
+func TestRunRejectsInvalidInput(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	cases := [][]string{
+		{"bogus"},
+		{"add"},
+		{"add", "--due", "soon", "Task"},
+		{"show", "abc"},
+		{"done"},
+		{"edit", "999", "--title", "x"},
+		{"log", "--since", "soon"},
+		{"add", "--repeat", "FREQ=HOURLY", "Task"},
+		{"block", "1", "1"},
+		{"block", "999"},
+		{"time", "pause", "1"},
+		{"time", "adjust", "1", "--start", "soon"},
+		{"report", "time", "--by", "project"},
+	}
+	for _, args := range cases {
+		var out bytes.Buffer
+		if err := Run(context.Background(), store, args, &out); err == nil {
+			t.Fatalf("expected %v to fail", args)
+		}
+	}
+}
+
+func newTestStore(t *testing.T) (*db.Store, func()) {
+	t.Helper()
+	dbConn, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	return db.NewStore(dbConn), func() {
+		_ = dbConn.Close()
+	}
+}
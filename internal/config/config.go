@@ -11,10 +11,26 @@ type Config struct {
 	DBPath     string `json:"db_path"`
 	WebEnabled bool   `json:"web_enabled"`
 	WebPort    int    `json:"web_port"`
+	// BindAddress is the interface the web server listens on; set it to
+	// "0.0.0.0" or a LAN address to reach it from other machines.
+	BindAddress string `json:"bind_address"`
+	// WebBasicAuth makes the HTML pages of the web server ask for a login
+	// whose password is an API token. The API always requires a token.
+	WebBasicAuth bool `json:"web_basic_auth"`
+	// TrashRetentionDays is how long deleted tasks stay in the trash before
+	// they are purged; 0 keeps them until purged by hand.
+	TrashRetentionDays int `json:"trash_retention_days"`
+	// PomodoroWorkMinutes and PomodoroBreakMinutes are the lengths of a
+	// focus session in the TUI and of the break after it.
+	PomodoroWorkMinutes  int `json:"pomodoro_work_minutes"`
+	PomodoroBreakMinutes int `json:"pomodoro_break_minutes"`
+	// Timezone is the IANA name of the zone due dates are entered and shown
+	// in, such as "Europe/Madrid"; empty uses the system's.
+	Timezone string `json:"timezone"`
 }
 
 func Default() Config {
-	return Config{WebPort: 8080}
+	return Config{WebPort: 8080, BindAddress: "localhost", TrashRetentionDays: 30, PomodoroWorkMinutes: 25, PomodoroBreakMinutes: 5}
 }
 
 func DefaultConfigPath() (string, error) {
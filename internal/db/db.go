@@ -3,26 +3,18 @@ package db
 import (
 	"context"
 	"database/sql"
-	"embed"
 	"fmt"
 
 	_ "modernc.org/sqlite"
 )
 
-//go:embed schema.sql
-var schemaFS embed.FS
-
 func Open(path string) (*sql.DB, error) {
-	if path == "" {
-		return nil, fmt.Errorf("db path is required")
-	}
-
-	db, err := sql.Open("sqlite", path)
+	db, err := Connect(path)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := applySchema(context.Background(), db); err != nil {
+	if _, err := Migrate(context.Background(), db); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
@@ -30,43 +22,25 @@ func Open(path string) (*sql.DB, error) {
 	return db, nil
 }
 
-func applySchema(ctx context.Context, db *sql.DB) error {
-	schemaSQL, err := schemaFS.ReadFile("schema.sql")
-	if err != nil {
-		return fmt.Errorf("read schema: %w", err)
-	}
-
-	if _, err := db.ExecContext(ctx, string(schemaSQL)); err != nil {
-		return fmt.Errorf("apply schema: %w", err)
-	}
-
-	if err := ensureParentTaskIDColumn(ctx, db); err != nil {
-		return err
+// Connect opens the database without applying pending migrations.
+func Connect(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("db path is required")
 	}
 
-	return nil
-}
-
-func ensureParentTaskIDColumn(ctx context.Context, db *sql.DB) error {
-	var exists int
-	err := db.QueryRowContext(ctx, "SELECT 1 FROM pragma_table_info('tasks') WHERE name = 'parent_task_id' LIMIT 1").Scan(&exists)
-	if err == nil {
-		if _, err := db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id)"); err != nil {
-			return fmt.Errorf("create idx_tasks_parent_task_id: %w", err)
-		}
-		return nil
-	}
-	if err != sql.ErrNoRows {
-		return fmt.Errorf("check tasks.parent_task_id column: %w", err)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
 	}
 
-	if _, err := db.ExecContext(ctx, "ALTER TABLE tasks ADD COLUMN parent_task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL"); err != nil {
-		return fmt.Errorf("add tasks.parent_task_id column: %w", err)
-	}
+	// SQLite serializes writers anyway; a single connection keeps per-connection
+	// pragmas and in-memory databases consistent across queries and transactions.
+	db.SetMaxOpenConns(1)
 
-	if _, err := db.ExecContext(ctx, "CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id)"); err != nil {
-		return fmt.Errorf("create idx_tasks_parent_task_id: %w", err)
+	if _, err := db.ExecContext(context.Background(), "PRAGMA foreign_keys = ON"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
 
-	return nil
+	return db, nil
 }
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// DependencyError reports a dependency that cannot be added, such as one that
+// would make a task wait on itself.
+type DependencyError struct {
+	Msg string
+}
+
+func (e *DependencyError) Error() string {
+	return e.Msg
+}
+
+// AddBlocker records that taskID is blocked by blockerID. Both tasks must
+// exist outside the trash, and the new edge must not close a cycle. Adding
+// an existing blocker is a no-op.
+func (s *Store) AddBlocker(ctx context.Context, taskID, blockerID int64) error {
+	if taskID == blockerID {
+		return &DependencyError{Msg: fmt.Sprintf("task %d cannot block itself", taskID)}
+	}
+	return s.WithTx(ctx, func(tx *Store) error {
+		for _, id := range []int64{taskID, blockerID} {
+			if _, err := tx.GetTaskWithTags(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		cycle, err := tx.Queries.TaskDependsOn(ctx, sqlc.TaskDependsOnParams{TaskID: blockerID, BlockerID: taskID})
+		if err != nil {
+			return err
+		}
+		if cycle != 0 {
+			return &DependencyError{Msg: fmt.Sprintf("task %d already waits on task %d", blockerID, taskID)}
+		}
+
+		return tx.changeBlockers(ctx, taskID, func() error {
+			return tx.Queries.AddDependency(ctx, sqlc.AddDependencyParams{TaskID: taskID, BlockerID: blockerID})
+		})
+	})
+}
+
+// RemoveBlocker removes the edge from blockerID to taskID, or returns
+// sql.ErrNoRows if there is none.
+func (s *Store) RemoveBlocker(ctx context.Context, taskID, blockerID int64) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		return tx.changeBlockers(ctx, taskID, func() error {
+			removed, err := tx.Queries.RemoveDependency(ctx, sqlc.RemoveDependencyParams{TaskID: taskID, BlockerID: blockerID})
+			if err == nil && removed == 0 {
+				err = sql.ErrNoRows
+			}
+			return err
+		})
+	})
+}
+
+// changeBlockers runs change and records the resulting blockers of taskID
+// in its history, if they changed.
+func (s *Store) changeBlockers(ctx context.Context, taskID int64, change func() error) error {
+	before, err := s.blockerValue(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	after, err := s.blockerValue(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if before == after {
+		return nil
+	}
+	return s.addHistory(ctx, taskID, model.EventUpdated, []model.HistoryChange{{Field: model.FieldBlockedBy, Old: before, New: after}})
+}
+
+func (s *Store) blockerValue(ctx context.Context, taskID int64) (string, error) {
+	ids, err := s.Queries.ListBlockerIDs(ctx, taskID)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(values, ","), nil
+}
+
+// ListBlockers returns the tasks outside the trash that block taskID.
+func (s *Store) ListBlockers(ctx context.Context, taskID int64) ([]model.Task, error) {
+	rows, err := s.Queries.ListBlockers(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapTasks(ctx, rows)
+}
+
+// ListDependents returns the tasks outside the trash that taskID blocks.
+func (s *Store) ListDependents(ctx context.Context, taskID int64) ([]model.Task, error) {
+	rows, err := s.Queries.ListDependents(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapTasks(ctx, rows)
+}
+
+// ListDependencies returns every edge between tasks outside the trash.
+func (s *Store) ListDependencies(ctx context.Context) ([]model.Dependency, error) {
+	rows, err := s.Queries.ListDependencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dependencies := make([]model.Dependency, 0, len(rows))
+	for _, row := range rows {
+		dependencies = append(dependencies, model.Dependency{TaskID: row.TaskID, BlockerID: row.BlockerID, CreatedAt: row.CreatedAt})
+	}
+	return dependencies, nil
+}
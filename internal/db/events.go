@@ -0,0 +1,110 @@
+package db
+
+import (
+	"sync"
+	"time"
+)
+
+// Kinds of Event.
+const (
+	// EventTask is a task being created, changed, deleted, restored or
+	// purged; Action is the history event type, or "purged".
+	EventTask = "task"
+	// EventHistory is an entry added to the history of a task.
+	EventHistory = "history"
+	// EventTag is a tag being deleted from every task.
+	EventTag = "tag"
+)
+
+// Event describes a change made through a Store. Events are published to
+// subscribers once the transaction making the change commits.
+type Event struct {
+	Kind      string    `json:"kind"`
+	Action    string    `json:"action"`
+	TaskID    int64     `json:"task_id,omitempty"`
+	HistoryID int64     `json:"history_id,omitempty"`
+	TagID     int64     `json:"tag_id,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// eventBufferSize is how many events a subscriber may fall behind by before
+// it is dropped.
+const eventBufferSize = 64
+
+// eventBus fans events out to the subscribers of every store sharing a
+// connection. It only sees changes made in this process.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: map[chan Event]struct{}{}}
+}
+
+// Subscribe returns a channel receiving the changes made through s or any
+// store sharing its connection, and a function that ends the subscription.
+// The channel is closed when the subscription ends, including when the
+// subscriber falls too far behind; subscribers should then reload what they
+// show.
+func (s *Store) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+	if s.events == nil {
+		close(ch)
+		return ch, func() {}
+	}
+
+	bus := s.events
+	bus.mu.Lock()
+	bus.subscribers[ch] = struct{}{}
+	bus.mu.Unlock()
+	return ch, func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		if _, ok := bus.subscribers[ch]; ok {
+			delete(bus.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends events to the subscribers, or holds them until the
+// transaction s is bound to commits.
+func (s *Store) publish(events ...Event) {
+	for i := range events {
+		events[i].Actor = s.origin.Actor
+		events[i].Source = s.origin.Source
+		if events[i].At.IsZero() {
+			events[i].At = time.Now().UTC()
+		}
+	}
+	if s.tx != nil {
+		*s.pending = append(*s.pending, events...)
+		return
+	}
+	s.events.publish(events...)
+}
+
+func (b *eventBus) publish(events ...Event) {
+	if b == nil || len(events) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		for _, event := range events {
+			select {
+			case ch <- event:
+				continue
+			default:
+			}
+			// Drop a subscriber that is too far behind rather than block
+			// the change.
+			delete(b.subscribers, ch)
+			close(ch)
+			break
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// Origin identifies who made a change and through which interface, and is
+// recorded with every history entry.
+type Origin struct {
+	Actor  string
+	Source string
+}
+
+// Sources recorded in Origin.Source.
+const (
+	SourceTUI = "tui"
+	SourceCLI = "cli"
+	SourceWeb = "web"
+)
+
+// WithOrigin returns a store sharing s's connection whose changes are
+// recorded as made by origin.
+func (s *Store) WithOrigin(origin Origin) *Store {
+	clone := *s
+	clone.origin = origin
+	return &clone
+}
+
+func (s *Store) ListHistory(ctx context.Context, taskID int64) ([]model.HistoryEntry, error) {
+	rows, err := s.Queries.ListHistoryByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]model.HistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := mapHistory(row)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+// ListActivity returns the history of every task recorded at or after
+// since, newest first, skipping offset entries and returning at most limit.
+// Trashed tasks are included so their deletion shows up in the log.
+func (s *Store) ListActivity(ctx context.Context, since time.Time, limit, offset int) ([]model.ActivityEntry, error) {
+	rows, err := s.Queries.ListActivity(ctx, sqlc.ListActivityParams{
+		CreatedAt: since.UTC(),
+		Limit:     int64(limit),
+		Offset:    int64(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make([]model.ActivityEntry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := mapHistory(sqlc.TaskHistory{
+			ID:        row.ID,
+			TaskID:    row.TaskID,
+			EventType: row.EventType,
+			Details:   row.Details,
+			CreatedAt: row.CreatedAt,
+			Changes:   row.Changes,
+			Actor:     row.Actor,
+			Source:    row.Source,
+		})
+		if err != nil {
+			return nil, err
+		}
+		activity = append(activity, model.ActivityEntry{HistoryEntry: entry, TaskTitle: row.Title})
+	}
+	return activity, nil
+}
+
+func (s *Store) addHistory(ctx context.Context, taskID int64, eventType string, changes []model.HistoryChange) error {
+	if changes == nil {
+		changes = []model.HistoryChange{}
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	row, err := s.Queries.AddHistory(ctx, sqlc.AddHistoryParams{
+		TaskID:    taskID,
+		EventType: eventType,
+		Changes:   string(data),
+		Actor:     s.origin.Actor,
+		Source:    s.origin.Source,
+	})
+	if err != nil {
+		return err
+	}
+	// Every change to a task is recorded here, so this is where tasks are
+	// published too.
+	s.publish(
+		Event{Kind: EventTask, Action: eventType, TaskID: taskID},
+		Event{Kind: EventHistory, Action: eventType, TaskID: taskID, HistoryID: row.ID},
+	)
+	return nil
+}
+
+func mapHistory(row sqlc.TaskHistory) (model.HistoryEntry, error) {
+	var changes []model.HistoryChange
+	if err := json.Unmarshal([]byte(row.Changes), &changes); err != nil {
+		return model.HistoryEntry{}, err
+	}
+	return model.HistoryEntry{
+		ID:        row.ID,
+		TaskID:    row.TaskID,
+		EventType: row.EventType,
+		Changes:   changes,
+		Actor:     row.Actor,
+		Source:    row.Source,
+		Text:      row.Details,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+// snapshotChanges records every field of a task that was created, restored
+// (fields go from unset to their value) or deleted (from their value to unset).
+func snapshotChanges(eventType string, task model.Task) []model.HistoryChange {
+	changes := make([]model.HistoryChange, 0, len(model.HistoryFields))
+	for _, field := range model.HistoryFields {
+		change := model.HistoryChange{Field: field, New: model.FieldValue(task, field)}
+		if eventType == model.EventDeleted {
+			change.Old, change.New = change.New, ""
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// taskChanges returns the fields that differ between before and after.
+func taskChanges(before, after model.Task) []model.HistoryChange {
+	var changes []model.HistoryChange
+	for _, field := range model.HistoryFields {
+		oldValue := model.FieldValue(before, field)
+		newValue := model.FieldValue(after, field)
+		if oldValue != newValue {
+			changes = append(changes, model.HistoryChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+var (
+	legacySnapshotPattern = regexp.MustCompile(`^(created|deleted): title='(.*)' status=(\S*) priority=(-?\d+) due=(\S+) tags=(.*)$`)
+	legacyChangePattern   = regexp.MustCompile(`(?:^|; )(title|description|status|priority|parent|due|tags): '`)
+)
+
+// backfillHistoryChanges converts the text details written by earlier
+// versions into structured changes. Entries whose text cannot be parsed keep
+// it as their only description.
+func backfillHistoryChanges(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, event_type, details FROM task_history WHERE details <> ''")
+	if err != nil {
+		return err
+	}
+	type legacyEntry struct {
+		id        int64
+		eventType string
+		details   string
+	}
+	var entries []legacyEntry
+	for rows.Next() {
+		var entry legacyEntry
+		if err := rows.Scan(&entry.id, &entry.eventType, &entry.details); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		changes, ok := parseLegacyDetails(entry.eventType, entry.details)
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE task_history SET changes = ?, details = '' WHERE id = ?", string(data), entry.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseLegacyDetails parses the text written by formatCreatedDetails,
+// formatDeletedDetails and formatTaskDiff before history was structured.
+func parseLegacyDetails(eventType, details string) ([]model.HistoryChange, bool) {
+	if match := legacySnapshotPattern.FindStringSubmatch(details); match != nil {
+		if match[1] != eventType {
+			return nil, false
+		}
+		values := map[string]string{
+			model.FieldTitle:    match[2],
+			model.FieldStatus:   match[3],
+			model.FieldPriority: match[4],
+			model.FieldDue:      legacyValue(model.FieldDue, match[5]),
+			model.FieldTags:     legacyValue(model.FieldTags, match[6]),
+		}
+		changes := make([]model.HistoryChange, 0, len(values))
+		for _, field := range model.HistoryFields {
+			value, ok := values[field]
+			if !ok {
+				continue
+			}
+			change := model.HistoryChange{Field: field, New: value}
+			if eventType == model.EventDeleted {
+				change.Old, change.New = value, ""
+			}
+			changes = append(changes, change)
+		}
+		return changes, true
+	}
+
+	body, ok := strings.CutPrefix(details, eventType+": ")
+	if !ok || eventType != model.EventUpdated {
+		return nil, false
+	}
+	if body == "no changes" {
+		return []model.HistoryChange{}, true
+	}
+
+	// Each change reads "field: 'old' -> 'new'", joined by "; ". Values are
+	// free text, so changes are split where the next known field starts.
+	starts := legacyChangePattern.FindAllStringSubmatchIndex(body, -1)
+	if len(starts) == 0 || starts[0][0] != 0 {
+		return nil, false
+	}
+	changes := make([]model.HistoryChange, 0, len(starts))
+	for index, start := range starts {
+		end := len(body)
+		if index+1 < len(starts) {
+			end = starts[index+1][0]
+		}
+		field := body[start[2]:start[3]]
+		values := body[start[1]:end]
+		if !strings.HasSuffix(values, "'") {
+			return nil, false
+		}
+		oldValue, newValue, found := strings.Cut(strings.TrimSuffix(values, "'"), "' -> '")
+		if !found {
+			return nil, false
+		}
+		changes = append(changes, model.HistoryChange{
+			Field: field,
+			Old:   legacyValue(field, oldValue),
+			New:   legacyValue(field, newValue),
+		})
+	}
+	return changes, true
+}
+
+// legacyValue converts a value from the old text form, where unset values
+// read "none", to its canonical form. Its due dates were days, which is
+// already the canonical form of all-day ones.
+func legacyValue(field, value string) string {
+	if value == "none" && field != model.FieldTitle && field != model.FieldStatus {
+		return ""
+	}
+	return value
+}
+
+// backfillDueAllDay marks due dates stored as midnight UTC as all-day, which
+// is how they were read before due_all_day, and rewrites them in history to
+// the canonical form of all-day dates.
+func backfillDueAllDay(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, due_at FROM tasks WHERE due_at IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	var taskIDs []int64
+	for rows.Next() {
+		var id int64
+		var dueAt time.Time
+		if err := rows.Scan(&id, &dueAt); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if isMidnightUTC(dueAt) {
+			taskIDs = append(taskIDs, id)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range taskIDs {
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET due_all_day = 1 WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT id, changes FROM task_history")
+	if err != nil {
+		return err
+	}
+	type historyChanges struct {
+		id      int64
+		changes []model.HistoryChange
+	}
+	var entries []historyChanges
+	for rows.Next() {
+		var id int64
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		var changes []model.HistoryChange
+		if json.Unmarshal([]byte(data), &changes) != nil {
+			continue
+		}
+		rewritten := false
+		for index, change := range changes {
+			if change.Field != model.FieldDue {
+				continue
+			}
+			for _, value := range []*string{&changes[index].Old, &changes[index].New} {
+				if due, err := time.Parse(time.RFC3339Nano, *value); err == nil && isMidnightUTC(due) {
+					*value = due.UTC().Format("2006-01-02")
+					rewritten = true
+				}
+			}
+		}
+		if rewritten {
+			entries = append(entries, historyChanges{id: id, changes: changes})
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, err := json.Marshal(entry.changes)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE task_history SET changes = ? WHERE id = ?", string(data), entry.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isMidnightUTC(t time.Time) bool {
+	t = t.UTC()
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
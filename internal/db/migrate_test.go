@@ -0,0 +1,304 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestMigrateRecordsAppliedMigrations(t *testing.T) {
+	db, err := Connect(":memory:")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Close()
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("list migrations: %v", err)
+	}
+
+	statuses, err := Status(context.Background(), db)
+	if err != nil {
+		t.Fatalf("status before migrate: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Fatalf("expected migration %d to be pending", status.Version)
+		}
+	}
+	var tables int
+	if err := db.QueryRow("SELECT count(*) FROM sqlite_master").Scan(&tables); err != nil {
+		t.Fatalf("count tables: %v", err)
+	}
+	if tables != 0 {
+		t.Fatalf("expected status to leave the database untouched, found %d objects", tables)
+	}
+
+	applied, err := Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("expected %d applied migrations, got %d", len(migrations), len(applied))
+	}
+
+	applied, err = Migrate(context.Background(), db)
+	if err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("expected second migrate to be a no-op, applied %d", len(applied))
+	}
+
+	statuses, err = Status(context.Background(), db)
+	if err != nil {
+		t.Fatalf("status after migrate: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Fatalf("expected migration %d to be applied", status.Version)
+		}
+	}
+}
+
+func TestMigrateUpgradesLegacySchema(t *testing.T) {
+	db, err := Connect(":memory:")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Close()
+
+	legacy := `CREATE TABLE tasks (
+  id INTEGER PRIMARY KEY,
+  title TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'todo',
+  priority INTEGER NOT NULL DEFAULT 0,
+  due_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO tasks (title) VALUES ('Legacy task');`
+	if _, err := db.Exec(legacy); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+
+	if _, err := Migrate(context.Background(), db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	store := NewStore(db)
+	tasks, err := store.ListTasks(context.Background(), model.Filter{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Legacy task" {
+		t.Fatalf("expected legacy task to survive migration, got %v", tasks)
+	}
+	if _, err := store.CreateTask(context.Background(), TaskInput{Title: "Child", ParentTaskID: &tasks[0].ID}); err != nil {
+		t.Fatalf("create child after migration: %v", err)
+	}
+}
+
+func TestMigrateRejectsUnknownVersions(t *testing.T) {
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (9999, 'future')"); err != nil {
+		t.Fatalf("insert future migration: %v", err)
+	}
+
+	_, err = Migrate(context.Background(), db)
+	if err == nil || !strings.Contains(err.Error(), "9999") {
+		t.Fatalf("expected unknown migration error, got %v", err)
+	}
+}
+
+func TestMigrateBackfillsStructuredHistory(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, TaskInput{Title: "Legacy"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	legacy := []struct {
+		eventType string
+		details   string
+	}{
+		{"created", "created: title='It's legacy' status=todo priority=1 due=2026-11-01 tags=home,work"},
+		{"updated", "updated: description: 'none' -> 'a; b'; due: '2026-11-01' -> 'none'; tags: 'work' -> 'home,work'"},
+		{"updated", "updated: no changes"},
+		{"updated", "something else entirely"},
+	}
+	for _, entry := range legacy {
+		if _, err := store.DB.ExecContext(ctx, "INSERT INTO task_history (task_id, event_type, details) VALUES (?, ?, ?)", task.ID, entry.eventType, entry.details); err != nil {
+			t.Fatalf("insert legacy history: %v", err)
+		}
+	}
+
+	err = store.WithTx(ctx, func(tx *Store) error {
+		return backfillHistoryChanges(ctx, tx.tx)
+	})
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+
+	history, err := store.ListHistory(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	details := make([]string, 0, len(history))
+	for _, entry := range history {
+		details = append(details, entry.Details(time.UTC))
+	}
+	want := []string{
+		"something else entirely",
+		"updated: no changes",
+		"updated: description: 'none' -> 'a; b'; due: '2026-11-01' -> 'none'; tags: 'work' -> 'home,work'",
+		"created: title='It's legacy' status=todo priority=1 due=2026-11-01 tags=home,work",
+	}
+	if strings.Join(details[:len(want)], "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected details\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(details, "\n"))
+	}
+
+	change, ok := history[2].Change(model.FieldDue)
+	if !ok || change.Old != "2026-11-01" || change.New != "" {
+		t.Fatalf("expected structured due change, got %+v", change)
+	}
+	if history[0].Text != "something else entirely" || len(history[0].Changes) != 0 {
+		t.Fatalf("expected unparsable details to be kept as text, got %+v", history[0])
+	}
+}
+
+func TestMigrateMarksMidnightUTCDueDatesAllDay(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Before due_all_day, midnight UTC meant an all-day date.
+	day := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	timed := time.Date(2026, time.November, 1, 14, 0, 0, 0, time.UTC)
+	allDayTask, err := store.CreateTask(ctx, TaskInput{Title: "All day", DueAt: &day})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	timedTask, err := store.CreateTask(ctx, TaskInput{Title: "Timed", DueAt: &timed})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	changes := `[{"field":"due","old":"2026-11-01T00:00:00Z","new":"2026-11-01T14:00:00Z"}]`
+	if _, err := store.DB.ExecContext(ctx, "INSERT INTO task_history (task_id, event_type, details, changes) VALUES (?, 'updated', '', ?)", timedTask.ID, changes); err != nil {
+		t.Fatalf("insert history: %v", err)
+	}
+
+	err = store.WithTx(ctx, func(tx *Store) error {
+		return backfillDueAllDay(ctx, tx.tx)
+	})
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+
+	if task, err := store.GetTaskWithTags(ctx, allDayTask.ID); err != nil || !task.DueAllDay {
+		t.Fatalf("expected midnight UTC to become all-day, got %+v (%v)", task, err)
+	}
+	if task, err := store.GetTaskWithTags(ctx, timedTask.ID); err != nil || task.DueAllDay {
+		t.Fatalf("expected a timed due date to stay timed, got %+v (%v)", task, err)
+	}
+	history, err := store.ListHistory(ctx, timedTask.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	change, ok := history[0].Change(model.FieldDue)
+	if !ok || change.Old != "2026-11-01" || change.New != "2026-11-01T14:00:00Z" {
+		t.Fatalf("expected the all-day value in history to become a date, got %+v", change)
+	}
+}
+
+func TestConnectEnforcesForeignKeys(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if stats := store.DB.Stats(); stats.MaxOpenConnections != 1 {
+		t.Fatalf("expected a single connection, so pragmas and :memory: databases hold for every query, got %d", stats.MaxOpenConnections)
+	}
+
+	task, err := store.CreateTask(ctx, TaskInput{Title: "Tagged", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if _, err := store.DB.ExecContext(ctx, "INSERT INTO task_tags (task_id, tag_id) VALUES (999, ?)", task.Tags[0].ID); err == nil {
+		t.Fatalf("expected a tag link to a missing task to be rejected")
+	}
+
+	if _, err := store.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?", task.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	for _, table := range []string{"task_tags", "task_history"} {
+		var count int
+		if err := store.DB.QueryRowContext(ctx, "SELECT count(*) FROM "+table+" WHERE task_id = ?", task.ID).Scan(&count); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		if count != 0 {
+			t.Fatalf("expected deleting the task to cascade to %s, %d rows left", table, count)
+		}
+	}
+}
+
+func TestMigrateClearsRowsOrphanedWithoutForeignKeys(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	parent, err := store.CreateTask(ctx, TaskInput{Title: "Parent", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	child, err := store.CreateTask(ctx, TaskInput{Title: "Child", ParentTaskID: &parent.ID})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	// Delete the parent the way versions without foreign keys did, then run
+	// the cleanup again.
+	statements := []string{
+		"PRAGMA foreign_keys = OFF",
+		fmt.Sprintf("DELETE FROM tasks WHERE id = %d", parent.ID),
+		"PRAGMA foreign_keys = ON",
+		"DELETE FROM schema_migrations WHERE version = 11",
+	}
+	for _, statement := range statements {
+		if _, err := store.DB.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	if _, err := Migrate(ctx, store.DB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var orphans int
+	err = store.DB.QueryRowContext(ctx, "SELECT (SELECT count(*) FROM task_tags WHERE task_id = ?) + (SELECT count(*) FROM task_history WHERE task_id = ?)", parent.ID, parent.ID).Scan(&orphans)
+	if err != nil {
+		t.Fatalf("count orphans: %v", err)
+	}
+	if orphans != 0 {
+		t.Fatalf("expected orphaned tag links and history to be removed, %d left", orphans)
+	}
+	got, err := store.GetTaskWithTags(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("get child: %v", err)
+	}
+	if got.ParentTaskID != nil {
+		t.Fatalf("expected the child to become a top-level task, got parent %d", *got.ParentTaskID)
+	}
+}
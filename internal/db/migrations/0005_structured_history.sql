@@ -0,0 +1,3 @@
+ALTER TABLE task_history ADD COLUMN changes TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE task_history ADD COLUMN actor TEXT NOT NULL DEFAULT '';
+ALTER TABLE task_history ADD COLUMN source TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,6 @@
+-- Foreign keys were not enforced before migrations were tracked, so deleting
+-- a task left its tag links and history behind and its subtasks pointing at
+-- it. Apply the ON DELETE actions the schema declares to what is left over.
+DELETE FROM task_tags WHERE task_id NOT IN (SELECT id FROM tasks) OR tag_id NOT IN (SELECT id FROM tags);
+DELETE FROM task_history WHERE task_id NOT IN (SELECT id FROM tasks);
+UPDATE tasks SET parent_task_id = NULL WHERE parent_task_id NOT IN (SELECT id FROM tasks);
@@ -0,0 +1,5 @@
+-- Due dates without a time of day are stored as midnight UTC of their day,
+-- which a deadline at exactly midnight UTC is too. Record which ones are
+-- days instead of telling them apart by their time; backfillDueAllDay marks
+-- the existing ones.
+ALTER TABLE tasks ADD COLUMN due_all_day BOOLEAN NOT NULL DEFAULT 0;
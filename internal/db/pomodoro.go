@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// LogPomodoro records a completed focus session on a task.
+func (s *Store) LogPomodoro(ctx context.Context, taskID int64, start, end time.Time) (model.PomodoroSession, error) {
+	row, err := s.Queries.CreatePomodoroSession(ctx, sqlc.CreatePomodoroSessionParams{
+		TaskID:    taskID,
+		StartedAt: start.UTC(),
+		EndedAt:   end.UTC(),
+	})
+	if err != nil {
+		return model.PomodoroSession{}, err
+	}
+	return model.PomodoroSession{ID: row.ID, TaskID: row.TaskID, StartedAt: row.StartedAt, EndedAt: row.EndedAt}, nil
+}
+
+// PomodoroCounts returns how many focus sessions were completed on each task
+// that has any.
+func (s *Store) PomodoroCounts(ctx context.Context) (map[int64]int64, error) {
+	rows, err := s.Queries.CountPomodoroSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		counts[row.TaskID] = row.Sessions
+	}
+	return counts, nil
+}
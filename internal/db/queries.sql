@@ -1,7 +1,7 @@
 -- name: CreateTask :one
-INSERT INTO tasks (title, description, status, priority, due_at, parent_task_id)
-VALUES (?, ?, ?, ?, ?, ?)
-RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at;
+INSERT INTO tasks (title, description, status, priority, due_at, due_all_day, parent_task_id, recurrence)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day;
 
 -- name: UpdateTask :one
 UPDATE tasks
@@ -10,36 +10,35 @@ SET title = ?,
     status = ?,
     priority = ?,
     due_at = ?,
+    due_all_day = ?,
     parent_task_id = ?,
+    recurrence = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at;
+RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day;
 
 -- name: DeleteTask :exec
 DELETE FROM tasks WHERE id = ?;
 
--- name: GetTask :one
-SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at
-FROM tasks
-WHERE id = ?;
+-- name: SoftDeleteTask :exec
+UPDATE tasks SET deleted_at = ? WHERE id = ?;
+
+-- name: UndeleteTask :exec
+UPDATE tasks SET deleted_at = NULL WHERE id = ?;
 
--- name: ListTasks :many
-SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at
+-- name: ListDeletedTasks :many
+SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
 FROM tasks
-WHERE (sqlc.arg(query) = '' OR title LIKE '%' || sqlc.arg(query) || '%' OR description LIKE '%' || sqlc.arg(query) || '%')
-  AND (sqlc.arg(status) = '' OR status = sqlc.arg(status))
-  AND (sqlc.arg(due_before) IS NULL OR due_at <= sqlc.arg(due_before))
-  AND (sqlc.arg(due_after) IS NULL OR due_at >= sqlc.arg(due_after))
-ORDER BY created_at DESC;
-
--- name: ListTasksByTags :many
-SELECT DISTINCT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: PurgeDeletedTasks :execrows
+DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at <= ?;
+
+-- name: GetTask :one
+SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
 FROM tasks
-JOIN task_tags ON task_tags.task_id = tasks.id
-JOIN tags ON tags.id = task_tags.tag_id
-WHERE tags.name IN (sqlc.slice('tags'))
-  AND (sqlc.arg(query) = '' OR tasks.title LIKE '%' || sqlc.arg(query) || '%' OR tasks.description LIKE '%' || sqlc.arg(query) || '%')
-ORDER BY tasks.created_at DESC;
+WHERE id = ?;
 
 -- name: CreateTag :one
 INSERT INTO tags (name)
@@ -73,16 +72,30 @@ JOIN task_tags ON task_tags.tag_id = tags.id
 WHERE task_tags.task_id = ?
 ORDER BY tags.name ASC;
 
+-- name: ListTaskTagsForTasks :many
+SELECT task_id, tag_id FROM task_tags WHERE task_id IN (sqlc.slice('task_ids'));
+
+-- name: ListTaskTags :many
+SELECT task_id, tag_id FROM task_tags;
+
 -- name: AddHistory :one
-INSERT INTO task_history (task_id, event_type, details)
-VALUES (?, ?, ?)
-RETURNING id, task_id, event_type, details, created_at;
+INSERT INTO task_history (task_id, event_type, details, changes, actor, source)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, task_id, event_type, details, created_at, changes, actor, source;
 
 -- name: ListHistoryByTask :many
-SELECT id, task_id, event_type, details, created_at
+SELECT id, task_id, event_type, details, created_at, changes, actor, source
 FROM task_history
 WHERE task_id = ?
-ORDER BY created_at DESC;
+ORDER BY created_at DESC, id DESC;
+
+-- name: ListActivity :many
+SELECT task_history.id, task_history.task_id, task_history.event_type, task_history.details, task_history.created_at, task_history.changes, task_history.actor, task_history.source, tasks.title
+FROM task_history
+JOIN tasks ON tasks.id = task_history.task_id
+WHERE task_history.created_at >= ?
+ORDER BY task_history.created_at DESC, task_history.id DESC
+LIMIT ? OFFSET ?;
 
 -- name: CreateView :one
 INSERT INTO views (name, filter_json)
@@ -109,3 +122,121 @@ ORDER BY name ASC;
 SELECT id, name, filter_json, created_at, updated_at
 FROM views
 WHERE name = ?;
+
+-- name: GetView :one
+SELECT id, name, filter_json, created_at, updated_at
+FROM views
+WHERE id = ?;
+
+-- name: GetSetting :one
+SELECT value FROM settings WHERE key = ?;
+
+-- name: SetSetting :exec
+INSERT INTO settings (key, value)
+VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteSetting :exec
+DELETE FROM settings WHERE key = ?;
+
+-- name: InsertTaskWithID :one
+INSERT INTO tasks (id, title, description, status, priority, due_at, due_all_day, parent_task_id, recurrence, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day;
+
+-- name: ListChildTaskIDs :many
+SELECT id FROM tasks WHERE parent_task_id = ? AND deleted_at IS NULL ORDER BY id ASC;
+
+-- name: SetTaskParent :exec
+UPDATE tasks SET parent_task_id = ? WHERE id = ?;
+
+-- name: AddDependency :exec
+INSERT INTO task_dependencies (task_id, blocker_id) VALUES (?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveDependency :execrows
+DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?;
+
+-- name: TaskDependsOn :one
+WITH RECURSIVE blockers(id) AS (
+  SELECT task_dependencies.blocker_id FROM task_dependencies WHERE task_dependencies.task_id = sqlc.arg(task_id)
+  UNION
+  SELECT task_dependencies.blocker_id FROM task_dependencies JOIN blockers ON task_dependencies.task_id = blockers.id
+)
+SELECT EXISTS (SELECT 1 FROM blockers WHERE blockers.id = sqlc.arg(blocker_id));
+
+-- name: ListBlockerIDs :many
+SELECT blocker_id FROM task_dependencies WHERE task_id = ? ORDER BY blocker_id ASC;
+
+-- name: ListDependencies :many
+SELECT task_dependencies.task_id, task_dependencies.blocker_id, task_dependencies.created_at
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.task_id
+JOIN tasks AS blockers ON blockers.id = task_dependencies.blocker_id
+WHERE tasks.deleted_at IS NULL AND blockers.deleted_at IS NULL
+ORDER BY task_dependencies.task_id ASC, task_dependencies.blocker_id ASC;
+
+-- name: ListBlockers :many
+SELECT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at, tasks.deleted_at, tasks.recurrence, tasks.due_all_day
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.blocker_id
+WHERE task_dependencies.task_id = ? AND tasks.deleted_at IS NULL
+ORDER BY tasks.id ASC;
+
+-- name: ListDependents :many
+SELECT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at, tasks.deleted_at, tasks.recurrence, tasks.due_all_day
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.task_id
+WHERE task_dependencies.blocker_id = ? AND tasks.deleted_at IS NULL
+ORDER BY tasks.id ASC;
+
+-- name: CreateTimeEntry :one
+INSERT INTO time_entries (task_id, started_at) VALUES (?, ?)
+RETURNING id, task_id, started_at, ended_at;
+
+-- name: GetTimeEntry :one
+SELECT id, task_id, started_at, ended_at FROM time_entries WHERE id = ?;
+
+-- name: GetRunningTimeEntry :one
+SELECT id, task_id, started_at, ended_at FROM time_entries WHERE task_id = ? AND ended_at IS NULL;
+
+-- name: UpdateTimeEntry :one
+UPDATE time_entries SET started_at = ?, ended_at = ? WHERE id = ?
+RETURNING id, task_id, started_at, ended_at;
+
+-- name: ListTimeEntriesByTask :many
+SELECT id, task_id, started_at, ended_at FROM time_entries WHERE task_id = ? ORDER BY started_at ASC, id ASC;
+
+-- name: ListRunningTimeEntries :many
+SELECT time_entries.id, time_entries.task_id, time_entries.started_at, time_entries.ended_at
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+WHERE time_entries.ended_at IS NULL AND tasks.deleted_at IS NULL
+ORDER BY time_entries.started_at ASC, time_entries.id ASC;
+
+-- name: ListTimeEntriesSince :many
+SELECT time_entries.id, time_entries.task_id, time_entries.started_at, time_entries.ended_at
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+WHERE (time_entries.ended_at IS NULL OR time_entries.ended_at > ?) AND tasks.deleted_at IS NULL
+ORDER BY time_entries.started_at ASC, time_entries.id ASC;
+
+-- name: CreatePomodoroSession :one
+INSERT INTO pomodoro_sessions (task_id, started_at, ended_at) VALUES (?, ?, ?)
+RETURNING id, task_id, started_at, ended_at;
+
+-- name: CountPomodoroSessions :many
+SELECT task_id, COUNT(*) AS sessions FROM pomodoro_sessions GROUP BY task_id ORDER BY task_id ASC;
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (name, token_hash, scope) VALUES (?, ?, ?)
+RETURNING id, name, token_hash, scope, created_at;
+
+-- name: GetAPITokenByHash :one
+SELECT id, name, token_hash, scope, created_at FROM api_tokens WHERE token_hash = ?;
+
+-- name: ListAPITokens :many
+SELECT id, name, token_hash, scope, created_at FROM api_tokens ORDER BY id ASC;
+
+-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens WHERE id = ?;
@@ -0,0 +1,583 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// QueryError reports a filter expression that parses but cannot be applied,
+// such as a malformed date or priority.
+type QueryError struct {
+	Term string
+	Msg  string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid filter %q: %s", e.Term, e.Msg)
+}
+
+// Query is a parsed filter expression. The language combines full-text terms
+// with field predicates:
+//
+//	status:doing tag:work -tag:blocked due<2026-11-01 prio>=2 "exact phrase" parent:42
+//
+// Bare words match as prefixes and "quoted text" as a phrase in titles and
+// descriptions. Predicates are field:value, or field<value, <=, >, >= for due
+// and prio; status and tag accept comma separated alternatives, and due and
+// parent accept "none". is:blocked matches tasks waiting on an unfinished
+// blocker and is:ready unfinished tasks that are not blocked. Terms are
+// combined with AND (implicit), OR, NOT or a leading -, and grouped with
+// parentheses. Unknown fields are searched as text, and unbalanced
+// parentheses or dangling operators are ignored.
+type Query struct {
+	root queryNode
+}
+
+// Empty reports whether the query has no terms and so matches every task.
+func (q Query) Empty() bool {
+	return q.root == nil
+}
+
+// String returns the query in normalized form; parsing it again yields the
+// same query.
+func (q Query) String() string {
+	if q.root == nil {
+		return ""
+	}
+	return q.root.String()
+}
+
+// ParseQuery parses a filter expression. Syntax errors are recovered from; only
+// invalid predicate values are reported, as a *QueryError.
+func ParseQuery(input string) (Query, error) {
+	p := &queryParser{tokens: lexQuery(input)}
+	var root queryNode
+	for {
+		node, err := p.parseOr()
+		if err != nil {
+			return Query{}, err
+		}
+		root = joinAnd(root, node)
+		if p.peek().kind == queryEOF {
+			break
+		}
+		// Only a stray closing parenthesis stops parseOr before the end.
+		p.next()
+	}
+	return Query{root: root}, nil
+}
+
+type queryTokenKind int
+
+const (
+	queryEOF queryTokenKind = iota
+	queryWord
+	queryPhrase
+	queryAnd
+	queryOr
+	queryNot
+	queryOpen
+	queryClose
+)
+
+type queryToken struct {
+	kind   queryTokenKind
+	value  string
+	prefix bool
+}
+
+func lexQuery(input string) []queryToken {
+	// Control characters never belong in a filter, and a NUL would end the
+	// MATCH string early, so treat them all as spaces.
+	runes := []rune(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, input))
+	var tokens []queryToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryOpen})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryClose})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			tokens = append(tokens, queryToken{kind: queryNot})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			phrase := string(runes[i+1 : min(end, len(runes))])
+			i = end + 1
+			prefix := false
+			if i < len(runes) && runes[i] == '*' {
+				prefix = true
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: queryPhrase, value: phrase, prefix: prefix})
+		default:
+			var word strings.Builder
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+				if runes[i] == '"' {
+					// A quote right after a predicate operator quotes the value.
+					prev := runes[max(i-1, 0)]
+					if word.Len() == 0 || !strings.ContainsRune(":=<>", prev) {
+						break
+					}
+					end := i + 1
+					for end < len(runes) && runes[end] != '"' {
+						end++
+					}
+					word.WriteString(string(runes[i:min(end+1, len(runes))]))
+					i = end + 1
+					break
+				}
+				word.WriteRune(runes[i])
+				i++
+			}
+			switch value := word.String(); value {
+			case "AND":
+				tokens = append(tokens, queryToken{kind: queryAnd})
+			case "OR":
+				tokens = append(tokens, queryToken{kind: queryOr})
+			case "NOT":
+				tokens = append(tokens, queryToken{kind: queryNot})
+			default:
+				tokens = append(tokens, queryToken{kind: queryWord, value: value})
+			}
+		}
+	}
+	return tokens
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.pos >= len(p.tokens) {
+		return queryToken{kind: queryEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	token := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return token
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == queryOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case right == nil:
+		case left == nil:
+			left = right
+		default:
+			left = orNode{left: left, right: right}
+		}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var result queryNode
+	for {
+		switch p.peek().kind {
+		case queryEOF, queryOr, queryClose:
+			return result, nil
+		case queryAnd:
+			p.next()
+			continue
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		result = joinAnd(result, node)
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	token := p.next()
+	switch token.kind {
+	case queryNot:
+		switch p.peek().kind {
+		case queryEOF, queryOr, queryAnd, queryClose:
+			return nil, nil
+		}
+		node, err := p.parseUnary()
+		if err != nil || node == nil {
+			return nil, err
+		}
+		if inner, ok := node.(notNode); ok {
+			return inner.node, nil
+		}
+		return notNode{node: node}, nil
+	case queryOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind == queryClose {
+			p.next()
+		}
+		return node, nil
+	case queryPhrase:
+		return newTextNode(token.value, true, token.prefix), nil
+	case queryWord:
+		return parseWord(token.value)
+	}
+	return nil, nil
+}
+
+func joinAnd(left, right queryNode) queryNode {
+	switch {
+	case right == nil:
+		return left
+	case left == nil:
+		return right
+	}
+	return andNode{left: left, right: right}
+}
+
+func parseWord(word string) (queryNode, error) {
+	if field, op, value, ok := splitPredicate(word); ok {
+		return newFieldNode(word, field, op, value)
+	}
+	return newTextNode(strings.TrimRight(word, "*"), false, true), nil
+}
+
+var queryFields = map[string]string{
+	"status":   "status",
+	"tag":      "tag",
+	"due":      "due",
+	"prio":     "prio",
+	"priority": "prio",
+	"parent":   "parent",
+	"is":       "is",
+}
+
+func splitPredicate(word string) (string, string, string, bool) {
+	index := strings.IndexAny(word, ":=<>")
+	if index <= 0 {
+		return "", "", "", false
+	}
+	field, ok := queryFields[strings.ToLower(word[:index])]
+	if !ok {
+		return "", "", "", false
+	}
+
+	rest := word[index:]
+	op := rest[:1]
+	if (op == "<" || op == ">") && strings.HasPrefix(rest[1:], "=") {
+		op = rest[:2]
+	}
+	value := rest[len(op):]
+	if op == "=" {
+		op = ":"
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	} else {
+		value = strings.TrimPrefix(value, `"`)
+	}
+	return field, op, strings.TrimSpace(value), true
+}
+
+type queryNode interface {
+	String() string
+	compile(c *queryCompiler, negated bool) string
+}
+
+type andNode struct {
+	left, right queryNode
+}
+
+func (n andNode) String() string {
+	return groupOr(n.left) + " " + groupOr(n.right)
+}
+
+type orNode struct {
+	left, right queryNode
+}
+
+func (n orNode) String() string {
+	return n.left.String() + " OR " + n.right.String()
+}
+
+type notNode struct {
+	node queryNode
+}
+
+func (n notNode) String() string {
+	switch n.node.(type) {
+	case andNode, orNode:
+		return "-(" + n.node.String() + ")"
+	}
+	return "-" + n.node.String()
+}
+
+func groupOr(node queryNode) string {
+	if _, ok := node.(orNode); ok {
+		return "(" + node.String() + ")"
+	}
+	return node.String()
+}
+
+type textNode struct {
+	text   string
+	phrase bool
+	prefix bool
+}
+
+func newTextNode(text string, phrase, prefix bool) queryNode {
+	if _, ok := quoteMatchTerm(text, prefix); !ok {
+		return nil
+	}
+	return textNode{text: text, phrase: phrase, prefix: prefix}
+}
+
+func (n textNode) String() string {
+	if !n.phrase {
+		switch n.text {
+		case "AND", "OR", "NOT":
+			// Written with the prefix marker so it is not read as an operator.
+			return n.text + "*"
+		}
+		return n.text
+	}
+	result := `"` + n.text + `"`
+	if n.prefix {
+		result += "*"
+	}
+	return result
+}
+
+// match returns the node as an FTS5 MATCH expression.
+func (n textNode) match() string {
+	term, _ := quoteMatchTerm(n.text, n.prefix)
+	return term
+}
+
+type fieldNode struct {
+	field  string
+	op     string
+	values []string
+	date   time.Time
+	number int64
+	none   bool
+}
+
+func newFieldNode(term, field, op, value string) (queryNode, error) {
+	node := fieldNode{field: field, op: op}
+	if value == "" {
+		return nil, &QueryError{Term: term, Msg: "missing value"}
+	}
+
+	ordered := op != ":"
+	switch field {
+	case "status", "tag":
+		if ordered {
+			return nil, &QueryError{Term: term, Msg: field + " only supports ':'"}
+		}
+		if strings.Contains(value, `"`) {
+			return nil, &QueryError{Term: term, Msg: "unbalanced quotes"}
+		}
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if field == "status" {
+				part = strings.ToLower(part)
+			}
+			if part != "" {
+				node.values = append(node.values, part)
+			}
+		}
+		if len(node.values) == 0 {
+			return nil, &QueryError{Term: term, Msg: "missing value"}
+		}
+	case "due":
+		if strings.EqualFold(value, "none") {
+			if ordered {
+				return nil, &QueryError{Term: term, Msg: "'none' only supports ':'"}
+			}
+			node.none = true
+			break
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, &QueryError{Term: term, Msg: "expected a YYYY-MM-DD date or none"}
+		}
+		node.date = date
+	case "prio":
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, &QueryError{Term: term, Msg: "expected a whole number"}
+		}
+		node.number = number
+	case "parent":
+		if ordered {
+			return nil, &QueryError{Term: term, Msg: "parent only supports ':'"}
+		}
+		if strings.EqualFold(value, "none") {
+			node.none = true
+			break
+		}
+		number, err := strconv.ParseInt(strings.TrimPrefix(value, "#"), 10, 64)
+		if err != nil || number <= 0 {
+			return nil, &QueryError{Term: term, Msg: "expected a task ID or none"}
+		}
+		node.number = number
+	case "is":
+		if ordered {
+			return nil, &QueryError{Term: term, Msg: "is only supports ':'"}
+		}
+		value = strings.ToLower(value)
+		if value != "blocked" && value != "ready" {
+			return nil, &QueryError{Term: term, Msg: "expected blocked or ready"}
+		}
+		node.values = []string{value}
+	}
+	return node, nil
+}
+
+func (n fieldNode) String() string {
+	var value string
+	switch {
+	case n.none:
+		value = "none"
+	case n.field == "due":
+		value = n.date.Format("2006-01-02")
+	case n.field == "prio" || n.field == "parent":
+		value = strconv.FormatInt(n.number, 10)
+	default:
+		value = strings.Join(n.values, ",")
+		if strings.ContainsAny(value, " \t\n()\"") || value != strings.TrimSpace(value) {
+			value = `"` + value + `"`
+		}
+	}
+	return n.field + n.op + value
+}
+
+// blockedCondition matches tasks with a blocker that is neither done nor in
+// the trash.
+const blockedCondition = "EXISTS (SELECT 1 FROM task_dependencies JOIN tasks AS blockers ON blockers.id = task_dependencies.blocker_id WHERE task_dependencies.task_id = tasks.id AND blockers.status <> 'done' AND blockers.deleted_at IS NULL)"
+
+// queryCompiler turns a query into a SQL condition over the tasks table,
+// collecting bound arguments and the full-text terms used for ranking.
+type queryCompiler struct {
+	args  []any
+	terms []string
+	loc   *time.Location
+}
+
+func (c *queryCompiler) arg(value any) string {
+	c.args = append(c.args, value)
+	return "?"
+}
+
+// dayStart binds the start of day, given by its date in UTC, for comparison
+// with tasks.due_at: all-day due dates name calendar days and compare with
+// the day as stored, while timed ones are instants and compare with the
+// start of the day in c.loc.
+func (c *queryCompiler) dayStart(day time.Time) string {
+	year, month, date := day.Date()
+	allDay := time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+	local := time.Date(year, month, date, 0, 0, 0, 0, c.loc).UTC()
+	return "CASE WHEN tasks.due_all_day THEN " + c.arg(allDay) + " ELSE " + c.arg(local) + " END"
+}
+
+func (c *queryCompiler) argList(values []string) string {
+	placeholders := make([]string, 0, len(values))
+	for _, value := range values {
+		placeholders = append(placeholders, c.arg(value))
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+func (n andNode) compile(c *queryCompiler, negated bool) string {
+	return "(" + n.left.compile(c, negated) + " AND " + n.right.compile(c, negated) + ")"
+}
+
+func (n orNode) compile(c *queryCompiler, negated bool) string {
+	return "(" + n.left.compile(c, negated) + " OR " + n.right.compile(c, negated) + ")"
+}
+
+func (n notNode) compile(c *queryCompiler, negated bool) string {
+	return "NOT " + n.node.compile(c, !negated)
+}
+
+func (n textNode) compile(c *queryCompiler, negated bool) string {
+	match := n.match()
+	if !negated {
+		c.terms = append(c.terms, match)
+	}
+	return "tasks.id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH " + c.arg(match) + ")"
+}
+
+// compile emits conditions that are never NULL, so that NOT selects exactly
+// the tasks the predicate does not.
+func (n fieldNode) compile(c *queryCompiler, _ bool) string {
+	switch n.field {
+	case "status":
+		return "tasks.status IN (" + c.argList(n.values) + ")"
+	case "tag":
+		return "EXISTS (SELECT 1 FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE task_tags.task_id = tasks.id AND tags.name IN (" + c.argList(n.values) + "))"
+	case "due":
+		if n.none {
+			return "tasks.due_at IS NULL"
+		}
+		next := n.date.AddDate(0, 0, 1)
+		switch n.op {
+		case "<":
+			return "(tasks.due_at IS NOT NULL AND tasks.due_at < " + c.dayStart(n.date) + ")"
+		case "<=":
+			return "(tasks.due_at IS NOT NULL AND tasks.due_at < " + c.dayStart(next) + ")"
+		case ">":
+			return "(tasks.due_at IS NOT NULL AND tasks.due_at >= " + c.dayStart(next) + ")"
+		case ">=":
+			return "(tasks.due_at IS NOT NULL AND tasks.due_at >= " + c.dayStart(n.date) + ")"
+		}
+		return "(tasks.due_at IS NOT NULL AND tasks.due_at >= " + c.dayStart(n.date) + " AND tasks.due_at < " + c.dayStart(next) + ")"
+	case "prio":
+		op := n.op
+		if op == ":" {
+			op = "="
+		}
+		return "tasks.priority " + op + " " + c.arg(n.number)
+	case "parent":
+		if n.none {
+			return "tasks.parent_task_id IS NULL"
+		}
+		return "tasks.parent_task_id IS " + c.arg(n.number)
+	case "is":
+		if n.values[0] == "ready" {
+			return "(tasks.status <> 'done' AND NOT " + blockedCondition + ")"
+		}
+		return blockedCondition
+	}
+	return "1"
+}
@@ -0,0 +1,247 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: ""},
+		{input: "   ", want: ""},
+		{input: "report", want: "report"},
+		{input: "deploy report*", want: "deploy report"},
+		{input: `"exact phrase"`, want: `"exact phrase"`},
+		{input: `"exact phrase"*`, want: `"exact phrase"*`},
+		{input: "deploy OR release", want: "deploy OR release"},
+		{input: "deploy AND release", want: "deploy release"},
+		{input: "deploy -staging", want: "deploy -staging"},
+		{input: "NOT staging", want: "-staging"},
+		{input: "-(-staging)", want: "staging"},
+		{input: "(deploy OR release) NOT staging", want: "(deploy OR release) -staging"},
+		{input: "-(deploy OR release)", want: "-(deploy OR release)"},
+		{input: "OR deploy AND", want: "deploy"},
+		{input: "(deploy", want: "deploy"},
+		{input: "deploy)", want: "deploy"},
+		{input: "()", want: ""},
+		{input: "c++ * -", want: "c++"},
+		{input: "Status:Doing,TODO", want: "status:doing,todo"},
+		{input: "tag:work -tag:blocked", want: "tag:work -tag:blocked"},
+		{input: `tag:"deep work"`, want: `tag:"deep work"`},
+		{input: "due<2026-11-01 due>=2026-10-01 due:none", want: "due<2026-11-01 due>=2026-10-01 due:none"},
+		{input: "priority>=2 prio=1", want: "prio>=2 prio:1"},
+		{input: "parent:#42 OR parent:none", want: "parent:42 OR parent:none"},
+		{input: "IS:Ready OR -is:blocked", want: "is:ready OR -is:blocked"},
+		{input: "a:b http://example.com", want: "a:b http://example.com"},
+		{
+			input: `status:doing tag:work -tag:blocked due<2026-11-01 prio>=2 "exact phrase" parent:42`,
+			want:  `status:doing tag:work -tag:blocked due<2026-11-01 prio>=2 "exact phrase" parent:42`,
+		},
+	}
+	for _, tc := range cases {
+		query, err := ParseQuery(tc.input)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tc.input, err)
+			continue
+		}
+		if got := query.String(); got != tc.want {
+			t.Errorf("ParseQuery(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestParseQueryRejectsInvalidValues(t *testing.T) {
+	inputs := []string{"status:", "status<doing", "due<tomorrow", "due>none", "prio>=high", "parent:abc", "parent:0", "parent>3", `tag:""`, "is:done", "is>ready"}
+	for _, input := range inputs {
+		_, err := ParseQuery(input)
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) {
+			t.Errorf("ParseQuery(%q): expected a QueryError, got %v", input, err)
+		}
+	}
+}
+
+func TestListTasksWithFilterExpressions(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	due := func(value string) *time.Time {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			t.Fatalf("parse due: %v", err)
+		}
+		return &parsed
+	}
+
+	release, err := store.CreateTask(ctx, TaskInput{Title: "Release notes", Description: "Write the exact phrase here", Status: "doing", Priority: 3, DueAt: due("2026-10-20"), DueAllDay: true, Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	inputs := []TaskInput{
+		{Title: "Blocked deploy", Status: "doing", Priority: 2, DueAt: due("2026-10-31"), DueAllDay: true, Tags: []string{"work", "blocked"}},
+		{Title: "Water plants", Status: "todo", Priority: 1, Tags: []string{"home"}},
+		{Title: "Changelog", Status: "doing", Priority: 2, DueAt: due("2026-12-01"), DueAllDay: true, ParentTaskID: &release.ID, Tags: []string{"work"}},
+	}
+	for _, input := range inputs {
+		if _, err := store.CreateTask(ctx, input); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{query: "status:doing tag:work -tag:blocked", want: []string{"Changelog", "Release notes"}},
+		{query: "due<2026-11-01", want: []string{"Blocked deploy", "Release notes"}},
+		{query: "due<=2026-10-31 -due:2026-10-20", want: []string{"Blocked deploy"}},
+		{query: "due>2026-10-31", want: []string{"Changelog"}},
+		{query: "due:none", want: []string{"Water plants"}},
+		{query: "-due<2026-11-01", want: []string{"Changelog", "Water plants"}},
+		{query: "prio>=2 -tag:blocked", want: []string{"Changelog", "Release notes"}},
+		{query: `"exact phrase"`, want: []string{"Release notes"}},
+		{query: "parent:" + strconv.FormatInt(release.ID, 10), want: []string{"Changelog"}},
+		{query: "parent:none tag:work", want: []string{"Blocked deploy", "Release notes"}},
+		{query: "tag:home OR prio:3", want: []string{"Release notes", "Water plants"}},
+		{query: "-plants", want: []string{"Blocked deploy", "Changelog", "Release notes"}},
+		{query: "(deploy OR water) status:todo,doing", want: []string{"Blocked deploy", "Water plants"}},
+		{query: `status:doing tag:work -tag:blocked due<2026-11-01 prio>=2 "exact phrase" parent:none`, want: []string{"Release notes"}},
+	}
+	for _, tc := range cases {
+		tasks, err := store.ListTasks(ctx, model.Filter{Query: tc.query})
+		if err != nil {
+			t.Errorf("%q: %v", tc.query, err)
+			continue
+		}
+		got := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			got = append(got, task.Title)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%q: expected %v, got %v", tc.query, tc.want, got)
+		}
+	}
+
+	results, err := store.SearchTasks(ctx, model.Filter{Query: "notes OR tag:home"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 || results[0].Task.Title != "Release notes" || results[0].Snippet == "" || results[1].Snippet != "" {
+		t.Fatalf("expected the text match to rank first with a snippet, got %+v", results)
+	}
+
+	if _, err := store.ListTasks(ctx, model.Filter{Query: "prio>=high"}); err == nil {
+		t.Fatalf("expected an invalid expression to fail")
+	}
+}
+
+func TestDueFiltersCompareTimedTasksInLocalDays(t *testing.T) {
+	base, cleanup := newTestStore(t)
+	defer cleanup()
+	pdt := time.FixedZone("PDT", -7*60*60)
+	store := base.WithLocation(pdt)
+	ctx := context.Background()
+
+	dues := []struct {
+		title  string
+		due    time.Time
+		allDay bool
+	}{
+		{"All day", time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), true},
+		{"Late evening", time.Date(2026, time.November, 1, 23, 0, 0, 0, pdt), false},
+		{"Next morning", time.Date(2026, time.November, 2, 8, 0, 0, 0, pdt), false},
+		{"Day after all", time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC), true},
+		// A deadline at midnight UTC, 17:00 on November 1 in PDT.
+		{"Midnight UTC", time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC), false},
+	}
+	for _, due := range dues {
+		if _, err := store.CreateTask(ctx, TaskInput{Title: due.title, Status: "todo", DueAt: &due.due, DueAllDay: due.allDay}); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	day := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		filter model.Filter
+		want   []string
+	}{
+		{name: "due before", filter: model.Filter{DueBefore: &day}, want: []string{"All day", "Late evening", "Midnight UTC"}},
+		{name: "due after", filter: model.Filter{DueAfter: &day}, want: []string{"All day", "Day after all", "Late evening", "Midnight UTC", "Next morning"}},
+		{name: "due on", filter: model.Filter{Query: "due:2026-11-02"}, want: []string{"Day after all", "Next morning"}},
+		{name: "due earlier", filter: model.Filter{Query: "due<2026-11-02"}, want: []string{"All day", "Late evening", "Midnight UTC"}},
+	}
+	for _, tc := range cases {
+		tasks, err := store.ListTasks(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			got = append(got, task.Title)
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func FuzzParseQuery(f *testing.F) {
+	seeds := []string{
+		"",
+		"report",
+		`status:doing tag:work -tag:blocked due<2026-11-01 prio>=2 "exact phrase" parent:42`,
+		"(deploy OR release) NOT staging",
+		`tag:"deep work" OR -(a b)`,
+		`"unterminated`,
+		"-(-a)) OR (",
+		"due:none parent:#3 priority=1",
+		"a:b NEAR(x y) * ^c",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	store, cleanup := newTestStore(f)
+	defer cleanup()
+	if _, err := store.CreateTask(context.Background(), TaskInput{Title: "Fuzz target", Tags: []string{"work"}}); err != nil {
+		f.Fatalf("create task: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		query, err := ParseQuery(input)
+		if err != nil {
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("ParseQuery(%q): unexpected error type %T", input, err)
+			}
+			return
+		}
+
+		normalized := query.String()
+		again, err := ParseQuery(normalized)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) of normalized %q: %v", input, normalized, err)
+		}
+		if again.String() != normalized {
+			t.Fatalf("normalized form of %q is not stable: %q then %q", input, normalized, again.String())
+		}
+
+		if _, err := store.SearchTasks(context.Background(), model.Filter{Query: input}); err != nil {
+			t.Fatalf("SearchTasks(%q): %v", input, err)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// createNextOccurrence creates the task following a completed recurring task:
+// a copy due at the next occurrence of recurrence that is not in the past,
+// carrying its tags, recurrence and a fresh copy of its subtasks. The history
+// of both tasks links them.
+func (s *Store) createNextOccurrence(ctx context.Context, done model.Task, recurrence string) (model.Task, error) {
+	rule, err := model.ParseRecurrence(recurrence)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	// Tasks without a due date repeat from today. All-day due dates are
+	// stored as days at midnight UTC, and timed ones repeat at the same local
+	// time of day, so compare them with today by calendar day.
+	loc := s.Location()
+	year, month, day := time.Now().In(loc).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	base := today
+	if done.DueAt != nil {
+		base = *done.DueAt
+		if !done.DueAllDay {
+			base = base.In(loc)
+		}
+	}
+	due := rule.Next(base)
+	for {
+		year, month, day := due.Date()
+		if !time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Before(today) {
+			break
+		}
+		due = rule.Next(due)
+	}
+	days := calendarDays(base, due)
+	due = due.UTC()
+
+	input := InputFromTask(done)
+	input.Status = "todo"
+	input.DueAt = &due
+	input.DueAllDay = done.DueAt == nil || done.DueAllDay
+	input.Recurrence = recurrence
+	next, err := s.createTask(ctx, input, occurrenceLink(model.FieldPrevious, done.ID))
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	if err := s.copySubtasks(ctx, done.ID, next.ID, days); err != nil {
+		return model.Task{}, err
+	}
+
+	if err := s.addHistory(ctx, done.ID, model.EventRecurred, []model.HistoryChange{occurrenceLink(model.FieldNext, next.ID)}); err != nil {
+		return model.Task{}, err
+	}
+	return next, nil
+}
+
+// NextOccurrenceID returns the occurrence created by the latest change to a
+// task, if that change completed a recurring task.
+func (s *Store) NextOccurrenceID(ctx context.Context, taskID int64) (int64, bool, error) {
+	history, err := s.ListHistory(ctx, taskID)
+	if err != nil || len(history) == 0 || history[0].EventType != model.EventRecurred {
+		return 0, false, err
+	}
+	link, _ := history[0].Change(model.FieldNext)
+	nextID, err := strconv.ParseInt(link.New, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return nextID, true, nil
+}
+
+// copySubtasks copies the subtasks of fromID, recursively, under toID as
+// todo tasks with due dates moved by days calendar days.
+func (s *Store) copySubtasks(ctx context.Context, fromID, toID int64, days int) error {
+	childIDs, err := s.Queries.ListChildTaskIDs(ctx, sql.NullInt64{Int64: fromID, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, childID := range childIDs {
+		child, err := s.GetTaskWithTags(ctx, childID)
+		if err != nil {
+			return err
+		}
+		input := InputFromTask(child)
+		input.Status = "todo"
+		input.ParentTaskID = &toID
+		if child.DueAt != nil {
+			due := shiftDays(*child.DueAt, child.DueAllDay, days, s.Location())
+			input.DueAt = &due
+		}
+		copied, err := s.createTask(ctx, input, occurrenceLink(model.FieldPrevious, child.ID))
+		if err != nil {
+			return err
+		}
+		if err := s.copySubtasks(ctx, child.ID, copied.ID, days); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// calendarDays returns the number of calendar days from the date of from to
+// the date of to, each in its own location.
+func calendarDays(from, to time.Time) int {
+	fromYear, fromMonth, fromDay := from.Date()
+	toYear, toMonth, toDay := to.Date()
+	start := time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)
+	end := time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// shiftDays moves a due date by calendar days: all-day dates stay all-day,
+// and timed ones keep their time of day in loc across DST changes.
+func shiftDays(due time.Time, allDay bool, days int, loc *time.Location) time.Time {
+	if allDay {
+		return due.AddDate(0, 0, days)
+	}
+	return due.In(loc).AddDate(0, 0, days).UTC()
+}
+
+func occurrenceLink(field string, taskID int64) model.HistoryChange {
+	return model.HistoryChange{Field: field, New: strconv.FormatInt(taskID, 10)}
+}
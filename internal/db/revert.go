@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// RevertError reports a revert that cannot be carried out as asked.
+type RevertError struct {
+	Msg string
+}
+
+func (e *RevertError) Error() string {
+	return e.Msg
+}
+
+// TaskAt returns the task as it was right after the given history entry. It
+// starts from the current task and undoes every newer entry, so it fails
+// with a *RevertError when one of them predates structured history.
+func (s *Store) TaskAt(ctx context.Context, taskID, historyID int64) (model.Task, error) {
+	current, err := s.GetTaskWithTags(ctx, taskID)
+	if err != nil {
+		return model.Task{}, err
+	}
+	history, err := s.ListHistory(ctx, taskID)
+	if err != nil {
+		return model.Task{}, err
+	}
+
+	// History is newest first.
+	index := slices.IndexFunc(history, func(entry model.HistoryEntry) bool { return entry.ID == historyID })
+	if index < 0 {
+		return model.Task{}, sql.ErrNoRows
+	}
+
+	// A task is gone right after a delete, so reverting to one means
+	// reverting to the task as it was deleted.
+	if history[index].EventType == model.EventDeleted {
+		index++
+	}
+
+	task := current
+	for _, entry := range history[:index] {
+		if len(entry.Changes) == 0 && entry.Text != "" {
+			return model.Task{}, &RevertError{Msg: fmt.Sprintf("history entry %d predates structured history and cannot be undone", entry.ID)}
+		}
+		for _, change := range entry.Changes {
+			if !slices.Contains(model.HistoryFields, change.Field) {
+				continue
+			}
+			if err := model.SetFieldValue(&task, change.Field, change.Old); err != nil {
+				return model.Task{}, &RevertError{Msg: fmt.Sprintf("history entry %d: %v", entry.ID, err)}
+			}
+		}
+	}
+	return task, nil
+}
+
+// RevertTask puts the given fields of a task, or all of them when none are
+// given, back to their values right after the history entry, and records a
+// "reverted" event.
+func (s *Store) RevertTask(ctx context.Context, taskID, historyID int64, fields ...string) (model.Task, error) {
+	for _, field := range fields {
+		if !slices.Contains(model.HistoryFields, field) {
+			return model.Task{}, &RevertError{Msg: fmt.Sprintf("unknown field %q", field)}
+		}
+	}
+	if len(fields) == 0 {
+		fields = model.HistoryFields
+	}
+
+	var reverted model.Task
+	err := s.WithTx(ctx, func(tx *Store) error {
+		current, err := tx.GetTaskWithTags(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		past, err := tx.TaskAt(ctx, taskID, historyID)
+		if err != nil {
+			return err
+		}
+
+		target := current
+		for _, field := range fields {
+			if err := model.SetFieldValue(&target, field, model.FieldValue(past, field)); err != nil {
+				return err
+			}
+		}
+		if target.ParentTaskID != nil {
+			if _, err := tx.Queries.GetTask(ctx, *target.ParentTaskID); err == sql.ErrNoRows {
+				return &RevertError{Msg: fmt.Sprintf("parent task %d no longer exists", *target.ParentTaskID)}
+			} else if err != nil {
+				return err
+			}
+		}
+
+		reverted, err = tx.updateTaskAs(ctx, taskID, InputFromTask(target), model.EventReverted)
+		return err
+	})
+	if err != nil {
+		return model.Task{}, err
+	}
+	return reverted, nil
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// Snippet markers surround the matched terms in model.SearchResult.Snippet.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// SearchTasks returns the tasks matching every filter field, with filter.Query
+// parsed as a filter expression (see Query). When the expression has full-text
+// terms, results are ranked by relevance and carry a snippet of the best match;
+// otherwise they are ordered newest first.
+func (s *Store) SearchTasks(ctx context.Context, filter model.Filter) ([]model.SearchResult, error) {
+	query, err := ParseQuery(filter.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	statement, args, err := compileFilter(filter, query, s.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn().QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var taskRows []sqlc.Task
+	var results []model.SearchResult
+	for rows.Next() {
+		var row sqlc.Task
+		var result model.SearchResult
+		if err := rows.Scan(
+			&row.ID,
+			&row.ParentTaskID,
+			&row.Title,
+			&row.Description,
+			&row.Status,
+			&row.Priority,
+			&row.DueAt,
+			&row.CreatedAt,
+			&row.UpdatedAt,
+			&row.DeletedAt,
+			&row.Recurrence,
+			&row.DueAllDay,
+			&result.Snippet,
+			&result.Rank,
+		); err != nil {
+			return nil, err
+		}
+		taskRows = append(taskRows, row)
+		results = append(results, result)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.mapTasks(ctx, taskRows)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Task = tasks[i]
+	}
+	if results == nil {
+		results = []model.SearchResult{}
+	}
+	return results, nil
+}
+
+// compileFilter builds the statement behind SearchTasks. Full-text terms that
+// are not negated are also matched in a joined subquery that supplies the
+// snippet and bm25 rank (title weighted over description). Timed due dates
+// are compared with days in loc.
+func compileFilter(filter model.Filter, query Query, loc *time.Location) (string, []any, error) {
+	tags, minTagMatches, err := tagFilter(filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	compiler := &queryCompiler{loc: loc}
+	conditions := []string{"tasks.deleted_at IS NULL"}
+	if query.root != nil {
+		conditions = append(conditions, query.root.compile(compiler, false))
+	}
+	if status := strings.TrimSpace(filter.Status); status != "" {
+		conditions = append(conditions, "tasks.status = "+compiler.arg(status))
+	}
+	// The due filters name days and include them.
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "tasks.due_at < "+compiler.dayStart(filter.DueBefore.UTC().AddDate(0, 0, 1)))
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "tasks.due_at >= "+compiler.dayStart(filter.DueAfter.UTC()))
+	}
+	if minTagMatches > 0 {
+		conditions = append(conditions, "(SELECT COUNT(DISTINCT task_tags.tag_id) FROM task_tags JOIN tags ON tags.id = task_tags.tag_id WHERE task_tags.task_id = tasks.id AND tags.name IN ("+compiler.argList(tags)+")) >= "+compiler.arg(minTagMatches))
+	}
+
+	var statement strings.Builder
+	statement.WriteString("SELECT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at, tasks.deleted_at, tasks.recurrence, tasks.due_all_day")
+	var args []any
+	if len(compiler.terms) > 0 {
+		statement.WriteString(`, COALESCE(ranked.snippet, ''), COALESCE(ranked.rank, 0)
+FROM tasks
+LEFT JOIN (
+  SELECT rowid, CAST(snippet(tasks_fts, -1, char(2), char(3), '…', 12) AS TEXT) AS snippet, CAST(bm25(tasks_fts, 10.0, 1.0) AS REAL) AS rank
+  FROM tasks_fts
+  WHERE tasks_fts MATCH ?
+) AS ranked ON ranked.rowid = tasks.id`)
+		args = append(args, strings.Join(compiler.terms, " OR "))
+	} else {
+		statement.WriteString(", '', 0.0\nFROM tasks")
+	}
+	statement.WriteString("\nWHERE " + strings.Join(conditions, "\n  AND "))
+	if len(compiler.terms) > 0 {
+		statement.WriteString("\nORDER BY ranked.rank IS NULL, ranked.rank ASC, tasks.created_at DESC")
+	} else {
+		statement.WriteString("\nORDER BY tasks.created_at DESC")
+	}
+	return statement.String(), append(args, compiler.args...), nil
+}
+
+func quoteMatchTerm(text string, prefix bool) (string, bool) {
+	hasWord := false
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			hasWord = true
+			break
+		}
+	}
+	if !hasWord {
+		return "", false
+	}
+	quoted := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+	if prefix {
+		quoted += "*"
+	}
+	return quoted, true
+}
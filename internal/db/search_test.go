@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestSearchTasksRanksAndHighlights(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	inputs := []TaskInput{
+		{Title: "Water plants", Description: "Ask someone to review the fertilizer"},
+		{Title: "Review release", Description: "Reviews of the production rollout"},
+		{Title: "Buy groceries", Description: "Milk and eggs"},
+	}
+	for _, input := range inputs {
+		if _, err := store.CreateTask(context.Background(), input); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	results, err := store.SearchTasks(context.Background(), model.Filter{Query: "reviewing"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Task.Title != "Review release" {
+		t.Fatalf("expected title match to rank first, got %q", results[0].Task.Title)
+	}
+	if !strings.Contains(results[0].Snippet, SnippetMatchStart) || !strings.Contains(results[0].Snippet, SnippetMatchEnd) {
+		t.Fatalf("expected snippet to highlight the match, got %q", results[0].Snippet)
+	}
+
+	tasks, err := store.ListTasks(context.Background(), model.Filter{Query: `"production rollout" OR milk`})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks for phrase OR query, got %d", len(tasks))
+	}
+
+	tasks, err = store.ListTasks(context.Background(), model.Filter{Query: "review -production"})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Water plants" {
+		t.Fatalf("expected exclusion to leave 'Water plants', got %v", tasks)
+	}
+}
+
+func TestSearchIndexFollowsUpdatesAndDeletes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	created, err := store.CreateTask(context.Background(), TaskInput{Title: "Draft proposal"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	if _, err := store.UpdateTask(context.Background(), created.ID, TaskInput{Title: "Final proposal"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	assertSearchCount(t, store, "draft", 0)
+	assertSearchCount(t, store, "final", 1)
+
+	if err := store.DeleteTask(context.Background(), created.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	assertSearchCount(t, store, "proposal", 0)
+}
+
+func TestSearchAcceptsArbitraryInput(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	inputs := []string{`"`, `*`, `AND`, `NOT NOT`, `((`, `))`, `a:b`, `"a" OR (`, `^x`, `NEAR(a b)`, `-(-a)`, `'`, `a OR -b`}
+	for _, input := range inputs {
+		if _, err := store.ListTasks(context.Background(), model.Filter{Query: input}); err != nil {
+			t.Errorf("query %q: %v", input, err)
+		}
+	}
+}
+
+func assertSearchCount(t *testing.T, store *Store, query string, want int) {
+	t.Helper()
+	results, err := store.SearchTasks(context.Background(), model.Filter{Query: query})
+	if err != nil {
+		t.Fatalf("search %q: %v", query, err)
+	}
+	if len(results) != want {
+		t.Fatalf("search %q: expected %d results, got %d", query, want, len(results))
+	}
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// TaskSnapshot captures a task as it was at one point in time, including the
+// subtasks that pointed at it, so it can be put back with RestoreTask.
+type TaskSnapshot struct {
+	Task     model.Task
+	ChildIDs []int64
+}
+
+func (s *Store) SnapshotTask(ctx context.Context, taskID int64) (TaskSnapshot, error) {
+	task, err := s.GetTaskWithTags(ctx, taskID)
+	if err != nil {
+		return TaskSnapshot{}, err
+	}
+
+	childIDs, err := s.Queries.ListChildTaskIDs(ctx, sql.NullInt64{Int64: taskID, Valid: true})
+	if err != nil {
+		return TaskSnapshot{}, err
+	}
+
+	return TaskSnapshot{Task: task, ChildIDs: childIDs}, nil
+}
+
+// RestoreTask brings a task back to the state recorded in snap. A task in the
+// trash is taken out of it, a purged task is re-inserted under its original
+// ID and creation time, and an existing task is updated in place when it
+// differs. Subtasks listed in the snapshot are re-attached, skipping any that
+// no longer exist.
+func (s *Store) RestoreTask(ctx context.Context, snap TaskSnapshot) (model.Task, error) {
+	var restored model.Task
+	err := s.WithTx(ctx, func(tx *Store) error {
+		var err error
+		restored, err = tx.restoreTask(ctx, snap)
+		return err
+	})
+	if err != nil {
+		return model.Task{}, err
+	}
+	return restored, nil
+}
+
+func (s *Store) restoreTask(ctx context.Context, snap TaskSnapshot) (model.Task, error) {
+	task := snap.Task
+
+	// A parent that has since been purged cannot be linked to again.
+	parentID := task.ParentTaskID
+	if parentID != nil {
+		if _, err := s.Queries.GetTask(ctx, *parentID); err != nil {
+			if err != sql.ErrNoRows {
+				return model.Task{}, err
+			}
+			parentID = nil
+		}
+	}
+
+	input := InputFromTask(task)
+	input.ParentTaskID = parentID
+
+	row, err := s.Queries.GetTask(ctx, task.ID)
+	switch {
+	case err == sql.ErrNoRows:
+		if err := s.insertTask(ctx, task.ID, task.CreatedAt, input); err != nil {
+			return model.Task{}, err
+		}
+	case err != nil:
+		return model.Task{}, err
+	default:
+		if row.DeletedAt.Valid {
+			if err := s.undeleteTask(ctx, task.ID); err != nil {
+				return model.Task{}, err
+			}
+		}
+		current, err := s.GetTaskWithTags(ctx, task.ID)
+		if err != nil {
+			return model.Task{}, err
+		}
+		target := task
+		target.ParentTaskID = parentID
+		if len(taskChanges(current, target)) > 0 {
+			// Bypass updateTask so that restoring a done task never repeats it.
+			if _, err := s.updateTaskAs(ctx, task.ID, input, model.EventUpdated); err != nil {
+				return model.Task{}, err
+			}
+		}
+	}
+
+	for _, childID := range snap.ChildIDs {
+		if childID == task.ID {
+			continue
+		}
+		if err := s.Queries.SetTaskParent(ctx, sqlc.SetTaskParentParams{
+			ParentTaskID: sql.NullInt64{Int64: task.ID, Valid: true},
+			ID:           childID,
+		}); err != nil {
+			return model.Task{}, err
+		}
+	}
+
+	return s.GetTaskWithTags(ctx, task.ID)
+}
+
+func (s *Store) insertTask(ctx context.Context, taskID int64, createdAt time.Time, input TaskInput) error {
+	var parentTaskID sql.NullInt64
+	if input.ParentTaskID != nil {
+		parentTaskID = sql.NullInt64{Int64: *input.ParentTaskID, Valid: true}
+	}
+
+	if _, err := s.Queries.InsertTaskWithID(ctx, sqlc.InsertTaskWithIDParams{
+		ID:           taskID,
+		Title:        input.Title,
+		Description:  input.Description,
+		Status:       normalizeStatus(input.Status),
+		Priority:     input.Priority,
+		DueAt:        dueParam(input),
+		DueAllDay:    input.DueAt != nil && input.DueAllDay,
+		ParentTaskID: parentTaskID,
+		Recurrence:   input.Recurrence,
+		CreatedAt:    createdAt,
+	}); err != nil {
+		return err
+	}
+
+	if err := s.setTaskTags(ctx, taskID, input.Tags); err != nil {
+		return err
+	}
+
+	restored, err := s.GetTaskWithTags(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	return s.addHistory(ctx, taskID, model.EventRestored, snapshotChanges(model.EventRestored, restored))
+}
@@ -9,6 +9,27 @@ import (
 	"time"
 )
 
+type ApiToken struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	TokenHash string    `db:"token_hash" json:"token_hash"`
+	Scope     string    `db:"scope" json:"scope"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type PomodoroSession struct {
+	ID        int64     `db:"id" json:"id"`
+	TaskID    int64     `db:"task_id" json:"task_id"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+	EndedAt   time.Time `db:"ended_at" json:"ended_at"`
+}
+
+type Setting struct {
+	Key       string    `db:"key" json:"key"`
+	Value     string    `db:"value" json:"value"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
 type Tag struct {
 	ID        int64     `db:"id" json:"id"`
 	Name      string    `db:"name" json:"name"`
@@ -25,6 +46,15 @@ type Task struct {
 	DueAt        sql.NullTime  `db:"due_at" json:"due_at"`
 	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time     `db:"updated_at" json:"updated_at"`
+	DeletedAt    sql.NullTime  `db:"deleted_at" json:"deleted_at"`
+	Recurrence   string        `db:"recurrence" json:"recurrence"`
+	DueAllDay    bool          `db:"due_all_day" json:"due_all_day"`
+}
+
+type TaskDependency struct {
+	TaskID    int64     `db:"task_id" json:"task_id"`
+	BlockerID int64     `db:"blocker_id" json:"blocker_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
 type TaskHistory struct {
@@ -33,6 +63,9 @@ type TaskHistory struct {
 	EventType string    `db:"event_type" json:"event_type"`
 	Details   string    `db:"details" json:"details"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	Changes   string    `db:"changes" json:"changes"`
+	Actor     string    `db:"actor" json:"actor"`
+	Source    string    `db:"source" json:"source"`
 }
 
 type TaskTag struct {
@@ -40,6 +73,18 @@ type TaskTag struct {
 	TagID  int64 `db:"tag_id" json:"tag_id"`
 }
 
+type TasksFt struct {
+	Title       string `db:"title" json:"title"`
+	Description string `db:"description" json:"description"`
+}
+
+type TimeEntry struct {
+	ID        int64        `db:"id" json:"id"`
+	TaskID    int64        `db:"task_id" json:"task_id"`
+	StartedAt time.Time    `db:"started_at" json:"started_at"`
+	EndedAt   sql.NullTime `db:"ended_at" json:"ended_at"`
+}
+
 type View struct {
 	ID         int64     `db:"id" json:"id"`
 	Name       string    `db:"name" json:"name"`
@@ -6,29 +6,62 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 type Querier interface {
+	AddDependency(ctx context.Context, arg AddDependencyParams) error
 	AddHistory(ctx context.Context, arg AddHistoryParams) (TaskHistory, error)
 	AssignTagToTask(ctx context.Context, arg AssignTagToTaskParams) error
 	ClearTagsForTask(ctx context.Context, taskID int64) error
+	CountPomodoroSessions(ctx context.Context) ([]CountPomodoroSessionsRow, error)
+	CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error)
+	CreatePomodoroSession(ctx context.Context, arg CreatePomodoroSessionParams) (PomodoroSession, error)
 	CreateTag(ctx context.Context, name string) (Tag, error)
 	CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error)
+	CreateTimeEntry(ctx context.Context, arg CreateTimeEntryParams) (TimeEntry, error)
 	CreateView(ctx context.Context, arg CreateViewParams) (View, error)
+	DeleteAPIToken(ctx context.Context, id int64) (int64, error)
+	DeleteSetting(ctx context.Context, key string) error
 	DeleteTag(ctx context.Context, id int64) error
 	DeleteTask(ctx context.Context, id int64) error
 	DeleteView(ctx context.Context, id int64) error
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error)
+	GetRunningTimeEntry(ctx context.Context, taskID int64) (TimeEntry, error)
+	GetSetting(ctx context.Context, key string) (string, error)
 	GetTagByName(ctx context.Context, name string) (Tag, error)
 	GetTask(ctx context.Context, id int64) (Task, error)
+	GetTimeEntry(ctx context.Context, id int64) (TimeEntry, error)
+	GetView(ctx context.Context, id int64) (View, error)
 	GetViewByName(ctx context.Context, name string) (View, error)
+	InsertTaskWithID(ctx context.Context, arg InsertTaskWithIDParams) (Task, error)
+	ListAPITokens(ctx context.Context) ([]ApiToken, error)
+	ListActivity(ctx context.Context, arg ListActivityParams) ([]ListActivityRow, error)
+	ListBlockerIDs(ctx context.Context, taskID int64) ([]int64, error)
+	ListBlockers(ctx context.Context, taskID int64) ([]Task, error)
+	ListChildTaskIDs(ctx context.Context, parentTaskID sql.NullInt64) ([]int64, error)
+	ListDeletedTasks(ctx context.Context) ([]Task, error)
+	ListDependencies(ctx context.Context) ([]TaskDependency, error)
+	ListDependents(ctx context.Context, blockerID int64) ([]Task, error)
 	ListHistoryByTask(ctx context.Context, taskID int64) ([]TaskHistory, error)
+	ListRunningTimeEntries(ctx context.Context) ([]TimeEntry, error)
 	ListTags(ctx context.Context) ([]Tag, error)
 	ListTagsForTask(ctx context.Context, taskID int64) ([]Tag, error)
-	ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, error)
-	ListTasksByTags(ctx context.Context, arg ListTasksByTagsParams) ([]Task, error)
+	ListTaskTags(ctx context.Context) ([]TaskTag, error)
+	ListTaskTagsForTasks(ctx context.Context, taskIds []int64) ([]TaskTag, error)
+	ListTimeEntriesByTask(ctx context.Context, taskID int64) ([]TimeEntry, error)
+	ListTimeEntriesSince(ctx context.Context, endedAt sql.NullTime) ([]TimeEntry, error)
 	ListViews(ctx context.Context) ([]View, error)
+	PurgeDeletedTasks(ctx context.Context, deletedAt sql.NullTime) (int64, error)
+	RemoveDependency(ctx context.Context, arg RemoveDependencyParams) (int64, error)
 	RemoveTagFromTask(ctx context.Context, arg RemoveTagFromTaskParams) error
+	SetSetting(ctx context.Context, arg SetSettingParams) error
+	SetTaskParent(ctx context.Context, arg SetTaskParentParams) error
+	SoftDeleteTask(ctx context.Context, arg SoftDeleteTaskParams) error
+	TaskDependsOn(ctx context.Context, arg TaskDependsOnParams) (int64, error)
+	UndeleteTask(ctx context.Context, id int64) error
 	UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, error)
+	UpdateTimeEntry(ctx context.Context, arg UpdateTimeEntryParams) (TimeEntry, error)
 	UpdateView(ctx context.Context, arg UpdateViewParams) (View, error)
 }
 
@@ -9,22 +9,48 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 )
 
+const addDependency = `-- name: AddDependency :exec
+INSERT INTO task_dependencies (task_id, blocker_id) VALUES (?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type AddDependencyParams struct {
+	TaskID    int64 `db:"task_id" json:"task_id"`
+	BlockerID int64 `db:"blocker_id" json:"blocker_id"`
+}
+
+func (q *Queries) AddDependency(ctx context.Context, arg AddDependencyParams) error {
+	_, err := q.db.ExecContext(ctx, addDependency, arg.TaskID, arg.BlockerID)
+	return err
+}
+
 const addHistory = `-- name: AddHistory :one
-INSERT INTO task_history (task_id, event_type, details)
-VALUES (?, ?, ?)
-RETURNING id, task_id, event_type, details, created_at
+INSERT INTO task_history (task_id, event_type, details, changes, actor, source)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id, task_id, event_type, details, created_at, changes, actor, source
 `
 
 type AddHistoryParams struct {
 	TaskID    int64  `db:"task_id" json:"task_id"`
 	EventType string `db:"event_type" json:"event_type"`
 	Details   string `db:"details" json:"details"`
+	Changes   string `db:"changes" json:"changes"`
+	Actor     string `db:"actor" json:"actor"`
+	Source    string `db:"source" json:"source"`
 }
 
 func (q *Queries) AddHistory(ctx context.Context, arg AddHistoryParams) (TaskHistory, error) {
-	row := q.db.QueryRowContext(ctx, addHistory, arg.TaskID, arg.EventType, arg.Details)
+	row := q.db.QueryRowContext(ctx, addHistory,
+		arg.TaskID,
+		arg.EventType,
+		arg.Details,
+		arg.Changes,
+		arg.Actor,
+		arg.Source,
+	)
 	var i TaskHistory
 	err := row.Scan(
 		&i.ID,
@@ -32,6 +58,9 @@ func (q *Queries) AddHistory(ctx context.Context, arg AddHistoryParams) (TaskHis
 		&i.EventType,
 		&i.Details,
 		&i.CreatedAt,
+		&i.Changes,
+		&i.Actor,
+		&i.Source,
 	)
 	return i, err
 }
@@ -60,6 +89,85 @@ func (q *Queries) ClearTagsForTask(ctx context.Context, taskID int64) error {
 	return err
 }
 
+const countPomodoroSessions = `-- name: CountPomodoroSessions :many
+SELECT task_id, COUNT(*) AS sessions FROM pomodoro_sessions GROUP BY task_id ORDER BY task_id ASC
+`
+
+type CountPomodoroSessionsRow struct {
+	TaskID   int64 `db:"task_id" json:"task_id"`
+	Sessions int64 `db:"sessions" json:"sessions"`
+}
+
+func (q *Queries) CountPomodoroSessions(ctx context.Context) ([]CountPomodoroSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countPomodoroSessions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountPomodoroSessionsRow
+	for rows.Next() {
+		var i CountPomodoroSessionsRow
+		if err := rows.Scan(&i.TaskID, &i.Sessions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO api_tokens (name, token_hash, scope) VALUES (?, ?, ?)
+RETURNING id, name, token_hash, scope, created_at
+`
+
+type CreateAPITokenParams struct {
+	Name      string `db:"name" json:"name"`
+	TokenHash string `db:"token_hash" json:"token_hash"`
+	Scope     string `db:"scope" json:"scope"`
+}
+
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken, arg.Name, arg.TokenHash, arg.Scope)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scope,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPomodoroSession = `-- name: CreatePomodoroSession :one
+INSERT INTO pomodoro_sessions (task_id, started_at, ended_at) VALUES (?, ?, ?)
+RETURNING id, task_id, started_at, ended_at
+`
+
+type CreatePomodoroSessionParams struct {
+	TaskID    int64     `db:"task_id" json:"task_id"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+	EndedAt   time.Time `db:"ended_at" json:"ended_at"`
+}
+
+func (q *Queries) CreatePomodoroSession(ctx context.Context, arg CreatePomodoroSessionParams) (PomodoroSession, error) {
+	row := q.db.QueryRowContext(ctx, createPomodoroSession, arg.TaskID, arg.StartedAt, arg.EndedAt)
+	var i PomodoroSession
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.StartedAt,
+		&i.EndedAt,
+	)
+	return i, err
+}
+
 const createTag = `-- name: CreateTag :one
 INSERT INTO tags (name)
 VALUES (?)
@@ -75,9 +183,9 @@ func (q *Queries) CreateTag(ctx context.Context, name string) (Tag, error) {
 }
 
 const createTask = `-- name: CreateTask :one
-INSERT INTO tasks (title, description, status, priority, due_at, parent_task_id)
-VALUES (?, ?, ?, ?, ?, ?)
-RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at
+INSERT INTO tasks (title, description, status, priority, due_at, due_all_day, parent_task_id, recurrence)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
 `
 
 type CreateTaskParams struct {
@@ -86,7 +194,9 @@ type CreateTaskParams struct {
 	Status       string        `db:"status" json:"status"`
 	Priority     int64         `db:"priority" json:"priority"`
 	DueAt        sql.NullTime  `db:"due_at" json:"due_at"`
+	DueAllDay    bool          `db:"due_all_day" json:"due_all_day"`
 	ParentTaskID sql.NullInt64 `db:"parent_task_id" json:"parent_task_id"`
+	Recurrence   string        `db:"recurrence" json:"recurrence"`
 }
 
 func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
@@ -96,7 +206,9 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		arg.Status,
 		arg.Priority,
 		arg.DueAt,
+		arg.DueAllDay,
 		arg.ParentTaskID,
+		arg.Recurrence,
 	)
 	var i Task
 	err := row.Scan(
@@ -109,6 +221,31 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		&i.DueAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Recurrence,
+		&i.DueAllDay,
+	)
+	return i, err
+}
+
+const createTimeEntry = `-- name: CreateTimeEntry :one
+INSERT INTO time_entries (task_id, started_at) VALUES (?, ?)
+RETURNING id, task_id, started_at, ended_at
+`
+
+type CreateTimeEntryParams struct {
+	TaskID    int64     `db:"task_id" json:"task_id"`
+	StartedAt time.Time `db:"started_at" json:"started_at"`
+}
+
+func (q *Queries) CreateTimeEntry(ctx context.Context, arg CreateTimeEntryParams) (TimeEntry, error) {
+	row := q.db.QueryRowContext(ctx, createTimeEntry, arg.TaskID, arg.StartedAt)
+	var i TimeEntry
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.StartedAt,
+		&i.EndedAt,
 	)
 	return i, err
 }
@@ -137,6 +274,27 @@ func (q *Queries) CreateView(ctx context.Context, arg CreateViewParams) (View, e
 	return i, err
 }
 
+const deleteAPIToken = `-- name: DeleteAPIToken :execrows
+DELETE FROM api_tokens WHERE id = ?
+`
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAPIToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSetting = `-- name: DeleteSetting :exec
+DELETE FROM settings WHERE key = ?
+`
+
+func (q *Queries) DeleteSetting(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteSetting, key)
+	return err
+}
+
 const deleteTag = `-- name: DeleteTag :exec
 DELETE FROM tags WHERE id = ?
 `
@@ -164,6 +322,50 @@ func (q *Queries) DeleteView(ctx context.Context, id int64) error {
 	return err
 }
 
+const getAPITokenByHash = `-- name: GetAPITokenByHash :one
+SELECT id, name, token_hash, scope, created_at FROM api_tokens WHERE token_hash = ?
+`
+
+func (q *Queries) GetAPITokenByHash(ctx context.Context, tokenHash string) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, getAPITokenByHash, tokenHash)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.TokenHash,
+		&i.Scope,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRunningTimeEntry = `-- name: GetRunningTimeEntry :one
+SELECT id, task_id, started_at, ended_at FROM time_entries WHERE task_id = ? AND ended_at IS NULL
+`
+
+func (q *Queries) GetRunningTimeEntry(ctx context.Context, taskID int64) (TimeEntry, error) {
+	row := q.db.QueryRowContext(ctx, getRunningTimeEntry, taskID)
+	var i TimeEntry
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.StartedAt,
+		&i.EndedAt,
+	)
+	return i, err
+}
+
+const getSetting = `-- name: GetSetting :one
+SELECT value FROM settings WHERE key = ?
+`
+
+func (q *Queries) GetSetting(ctx context.Context, key string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getSetting, key)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
 const getTagByName = `-- name: GetTagByName :one
 SELECT id, name, created_at FROM tags WHERE name = ?
 `
@@ -176,7 +378,7 @@ func (q *Queries) GetTagByName(ctx context.Context, name string) (Tag, error) {
 }
 
 const getTask = `-- name: GetTask :one
-SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at
+SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
 FROM tasks
 WHERE id = ?
 `
@@ -194,6 +396,44 @@ func (q *Queries) GetTask(ctx context.Context, id int64) (Task, error) {
 		&i.DueAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Recurrence,
+		&i.DueAllDay,
+	)
+	return i, err
+}
+
+const getTimeEntry = `-- name: GetTimeEntry :one
+SELECT id, task_id, started_at, ended_at FROM time_entries WHERE id = ?
+`
+
+func (q *Queries) GetTimeEntry(ctx context.Context, id int64) (TimeEntry, error) {
+	row := q.db.QueryRowContext(ctx, getTimeEntry, id)
+	var i TimeEntry
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.StartedAt,
+		&i.EndedAt,
+	)
+	return i, err
+}
+
+const getView = `-- name: GetView :one
+SELECT id, name, filter_json, created_at, updated_at
+FROM views
+WHERE id = ?
+`
+
+func (q *Queries) GetView(ctx context.Context, id int64) (View, error) {
+	row := q.db.QueryRowContext(ctx, getView, id)
+	var i View
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.FilterJson,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -217,27 +457,74 @@ func (q *Queries) GetViewByName(ctx context.Context, name string) (View, error)
 	return i, err
 }
 
-const listHistoryByTask = `-- name: ListHistoryByTask :many
-SELECT id, task_id, event_type, details, created_at
-FROM task_history
-WHERE task_id = ?
-ORDER BY created_at DESC
+const insertTaskWithID = `-- name: InsertTaskWithID :one
+INSERT INTO tasks (id, title, description, status, priority, due_at, due_all_day, parent_task_id, recurrence, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
 `
 
-func (q *Queries) ListHistoryByTask(ctx context.Context, taskID int64) ([]TaskHistory, error) {
-	rows, err := q.db.QueryContext(ctx, listHistoryByTask, taskID)
+type InsertTaskWithIDParams struct {
+	ID           int64         `db:"id" json:"id"`
+	Title        string        `db:"title" json:"title"`
+	Description  string        `db:"description" json:"description"`
+	Status       string        `db:"status" json:"status"`
+	Priority     int64         `db:"priority" json:"priority"`
+	DueAt        sql.NullTime  `db:"due_at" json:"due_at"`
+	DueAllDay    bool          `db:"due_all_day" json:"due_all_day"`
+	ParentTaskID sql.NullInt64 `db:"parent_task_id" json:"parent_task_id"`
+	Recurrence   string        `db:"recurrence" json:"recurrence"`
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) InsertTaskWithID(ctx context.Context, arg InsertTaskWithIDParams) (Task, error) {
+	row := q.db.QueryRowContext(ctx, insertTaskWithID,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.Status,
+		arg.Priority,
+		arg.DueAt,
+		arg.DueAllDay,
+		arg.ParentTaskID,
+		arg.Recurrence,
+		arg.CreatedAt,
+	)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.ParentTaskID,
+		&i.Title,
+		&i.Description,
+		&i.Status,
+		&i.Priority,
+		&i.DueAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Recurrence,
+		&i.DueAllDay,
+	)
+	return i, err
+}
+
+const listAPITokens = `-- name: ListAPITokens :many
+SELECT id, name, token_hash, scope, created_at FROM api_tokens ORDER BY id ASC
+`
+
+func (q *Queries) ListAPITokens(ctx context.Context) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, listAPITokens)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []TaskHistory
+	var items []ApiToken
 	for rows.Next() {
-		var i TaskHistory
+		var i ApiToken
 		if err := rows.Scan(
 			&i.ID,
-			&i.TaskID,
-			&i.EventType,
-			&i.Details,
+			&i.Name,
+			&i.TokenHash,
+			&i.Scope,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -253,20 +540,53 @@ func (q *Queries) ListHistoryByTask(ctx context.Context, taskID int64) ([]TaskHi
 	return items, nil
 }
 
-const listTags = `-- name: ListTags :many
-SELECT id, name, created_at FROM tags ORDER BY name ASC
+const listActivity = `-- name: ListActivity :many
+SELECT task_history.id, task_history.task_id, task_history.event_type, task_history.details, task_history.created_at, task_history.changes, task_history.actor, task_history.source, tasks.title
+FROM task_history
+JOIN tasks ON tasks.id = task_history.task_id
+WHERE task_history.created_at >= ?
+ORDER BY task_history.created_at DESC, task_history.id DESC
+LIMIT ? OFFSET ?
 `
 
-func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
-	rows, err := q.db.QueryContext(ctx, listTags)
+type ListActivityParams struct {
+	CreatedAt time.Time
+	Limit     int64
+	Offset    int64
+}
+
+type ListActivityRow struct {
+	ID        int64
+	TaskID    int64
+	EventType string
+	Details   string
+	CreatedAt time.Time
+	Changes   string
+	Actor     string
+	Source    string
+	Title     string
+}
+
+func (q *Queries) ListActivity(ctx context.Context, arg ListActivityParams) ([]ListActivityRow, error) {
+	rows, err := q.db.QueryContext(ctx, listActivity, arg.CreatedAt, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Tag
+	var items []ListActivityRow
 	for rows.Next() {
-		var i Tag
-		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+		var i ListActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.EventType,
+			&i.Details,
+			&i.CreatedAt,
+			&i.Changes,
+			&i.Actor,
+			&i.Source,
+			&i.Title,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -280,27 +600,23 @@ func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
 	return items, nil
 }
 
-const listTagsForTask = `-- name: ListTagsForTask :many
-SELECT tags.id, tags.name, tags.created_at
-FROM tags
-JOIN task_tags ON task_tags.tag_id = tags.id
-WHERE task_tags.task_id = ?
-ORDER BY tags.name ASC
+const listBlockerIDs = `-- name: ListBlockerIDs :many
+SELECT blocker_id FROM task_dependencies WHERE task_id = ? ORDER BY blocker_id ASC
 `
 
-func (q *Queries) ListTagsForTask(ctx context.Context, taskID int64) ([]Tag, error) {
-	rows, err := q.db.QueryContext(ctx, listTagsForTask, taskID)
+func (q *Queries) ListBlockerIDs(ctx context.Context, taskID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockerIDs, taskID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Tag
+	var items []int64
 	for rows.Next() {
-		var i Tag
-		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+		var blocker_id int64
+		if err := rows.Scan(&blocker_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, blocker_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -311,30 +627,16 @@ func (q *Queries) ListTagsForTask(ctx context.Context, taskID int64) ([]Tag, err
 	return items, nil
 }
 
-const listTasks = `-- name: ListTasks :many
-SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at
-FROM tasks
-WHERE (?1 = '' OR title LIKE '%' || ?1 || '%' OR description LIKE '%' || ?1 || '%')
-  AND (?2 = '' OR status = ?2)
-  AND (?3 IS NULL OR due_at <= ?3)
-  AND (?4 IS NULL OR due_at >= ?4)
-ORDER BY created_at DESC
+const listBlockers = `-- name: ListBlockers :many
+SELECT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at, tasks.deleted_at, tasks.recurrence, tasks.due_all_day
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.blocker_id
+WHERE task_dependencies.task_id = ? AND tasks.deleted_at IS NULL
+ORDER BY tasks.id ASC
 `
 
-type ListTasksParams struct {
-	Query     interface{} `db:"query" json:"query"`
-	Status    interface{} `db:"status" json:"status"`
-	DueBefore interface{} `db:"due_before" json:"due_before"`
-	DueAfter  interface{} `db:"due_after" json:"due_after"`
-}
-
-func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, error) {
-	rows, err := q.db.QueryContext(ctx, listTasks,
-		arg.Query,
-		arg.Status,
-		arg.DueBefore,
-		arg.DueAfter,
-	)
+func (q *Queries) ListBlockers(ctx context.Context, taskID int64) ([]Task, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockers, taskID)
 	if err != nil {
 		return nil, err
 	}
@@ -352,6 +654,9 @@ func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, e
 			&i.DueAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Recurrence,
+			&i.DueAllDay,
 		); err != nil {
 			return nil, err
 		}
@@ -366,34 +671,42 @@ func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]Task, e
 	return items, nil
 }
 
-const listTasksByTags = `-- name: ListTasksByTags :many
-SELECT DISTINCT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at
-FROM tasks
-JOIN task_tags ON task_tags.task_id = tasks.id
-JOIN tags ON tags.id = task_tags.tag_id
-WHERE tags.name IN (/*SLICE:tags*/?)
-  AND (?2 = '' OR tasks.title LIKE '%' || ?2 || '%' OR tasks.description LIKE '%' || ?2 || '%')
-ORDER BY tasks.created_at DESC
+const listChildTaskIDs = `-- name: ListChildTaskIDs :many
+SELECT id FROM tasks WHERE parent_task_id = ? AND deleted_at IS NULL ORDER BY id ASC
 `
 
-type ListTasksByTagsParams struct {
-	Tags  []string    `db:"tags" json:"tags"`
-	Query interface{} `db:"query" json:"query"`
-}
-
-func (q *Queries) ListTasksByTags(ctx context.Context, arg ListTasksByTagsParams) ([]Task, error) {
-	query := listTasksByTags
-	var queryParams []interface{}
-	if len(arg.Tags) > 0 {
-		for _, v := range arg.Tags {
-			queryParams = append(queryParams, v)
+func (q *Queries) ListChildTaskIDs(ctx context.Context, parentTaskID sql.NullInt64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listChildTaskIDs, parentTaskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
-		query = strings.Replace(query, "/*SLICE:tags*/?", strings.Repeat(",?", len(arg.Tags))[1:], 1)
-	} else {
-		query = strings.Replace(query, "/*SLICE:tags*/?", "NULL", 1)
+		items = append(items, id)
 	}
-	queryParams = append(queryParams, arg.Query)
-	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedTasks = `-- name: ListDeletedTasks :many
+SELECT id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
+FROM tasks
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) ListDeletedTasks(ctx context.Context) ([]Task, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedTasks)
 	if err != nil {
 		return nil, err
 	}
@@ -411,6 +724,9 @@ func (q *Queries) ListTasksByTags(ctx context.Context, arg ListTasksByTagsParams
 			&i.DueAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Recurrence,
+			&i.DueAllDay,
 		); err != nil {
 			return nil, err
 		}
@@ -425,28 +741,25 @@ func (q *Queries) ListTasksByTags(ctx context.Context, arg ListTasksByTagsParams
 	return items, nil
 }
 
-const listViews = `-- name: ListViews :many
-SELECT id, name, filter_json, created_at, updated_at
-FROM views
-ORDER BY name ASC
+const listDependencies = `-- name: ListDependencies :many
+SELECT task_dependencies.task_id, task_dependencies.blocker_id, task_dependencies.created_at
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.task_id
+JOIN tasks AS blockers ON blockers.id = task_dependencies.blocker_id
+WHERE tasks.deleted_at IS NULL AND blockers.deleted_at IS NULL
+ORDER BY task_dependencies.task_id ASC, task_dependencies.blocker_id ASC
 `
 
-func (q *Queries) ListViews(ctx context.Context) ([]View, error) {
-	rows, err := q.db.QueryContext(ctx, listViews)
+func (q *Queries) ListDependencies(ctx context.Context) ([]TaskDependency, error) {
+	rows, err := q.db.QueryContext(ctx, listDependencies)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []View
+	var items []TaskDependency
 	for rows.Next() {
-		var i View
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.FilterJson,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
+		var i TaskDependency
+		if err := rows.Scan(&i.TaskID, &i.BlockerID, &i.CreatedAt); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -460,17 +773,464 @@ func (q *Queries) ListViews(ctx context.Context) ([]View, error) {
 	return items, nil
 }
 
-const removeTagFromTask = `-- name: RemoveTagFromTask :exec
-DELETE FROM task_tags WHERE task_id = ? AND tag_id = ?
+const listDependents = `-- name: ListDependents :many
+SELECT tasks.id, tasks.parent_task_id, tasks.title, tasks.description, tasks.status, tasks.priority, tasks.due_at, tasks.created_at, tasks.updated_at, tasks.deleted_at, tasks.recurrence, tasks.due_all_day
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.task_id
+WHERE task_dependencies.blocker_id = ? AND tasks.deleted_at IS NULL
+ORDER BY tasks.id ASC
 `
 
-type RemoveTagFromTaskParams struct {
-	TaskID int64 `db:"task_id" json:"task_id"`
-	TagID  int64 `db:"tag_id" json:"tag_id"`
-}
-
-func (q *Queries) RemoveTagFromTask(ctx context.Context, arg RemoveTagFromTaskParams) error {
-	_, err := q.db.ExecContext(ctx, removeTagFromTask, arg.TaskID, arg.TagID)
+func (q *Queries) ListDependents(ctx context.Context, blockerID int64) ([]Task, error) {
+	rows, err := q.db.QueryContext(ctx, listDependents, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Task
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentTaskID,
+			&i.Title,
+			&i.Description,
+			&i.Status,
+			&i.Priority,
+			&i.DueAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Recurrence,
+			&i.DueAllDay,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHistoryByTask = `-- name: ListHistoryByTask :many
+SELECT id, task_id, event_type, details, created_at, changes, actor, source
+FROM task_history
+WHERE task_id = ?
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListHistoryByTask(ctx context.Context, taskID int64) ([]TaskHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryByTask, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TaskHistory
+	for rows.Next() {
+		var i TaskHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.EventType,
+			&i.Details,
+			&i.CreatedAt,
+			&i.Changes,
+			&i.Actor,
+			&i.Source,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunningTimeEntries = `-- name: ListRunningTimeEntries :many
+SELECT time_entries.id, time_entries.task_id, time_entries.started_at, time_entries.ended_at
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+WHERE time_entries.ended_at IS NULL AND tasks.deleted_at IS NULL
+ORDER BY time_entries.started_at ASC, time_entries.id ASC
+`
+
+func (q *Queries) ListRunningTimeEntries(ctx context.Context) ([]TimeEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listRunningTimeEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TimeEntry
+	for rows.Next() {
+		var i TimeEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.StartedAt,
+			&i.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT id, name, created_at FROM tags ORDER BY name ASC
+`
+
+func (q *Queries) ListTags(ctx context.Context) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagsForTask = `-- name: ListTagsForTask :many
+SELECT tags.id, tags.name, tags.created_at
+FROM tags
+JOIN task_tags ON task_tags.tag_id = tags.id
+WHERE task_tags.task_id = ?
+ORDER BY tags.name ASC
+`
+
+func (q *Queries) ListTagsForTask(ctx context.Context, taskID int64) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, listTagsForTask, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskTags = `-- name: ListTaskTags :many
+SELECT task_id, tag_id FROM task_tags
+`
+
+func (q *Queries) ListTaskTags(ctx context.Context) ([]TaskTag, error) {
+	rows, err := q.db.QueryContext(ctx, listTaskTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TaskTag
+	for rows.Next() {
+		var i TaskTag
+		if err := rows.Scan(&i.TaskID, &i.TagID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskTagsForTasks = `-- name: ListTaskTagsForTasks :many
+SELECT task_id, tag_id FROM task_tags WHERE task_id IN (/*SLICE:task_ids*/?)
+`
+
+func (q *Queries) ListTaskTagsForTasks(ctx context.Context, taskIds []int64) ([]TaskTag, error) {
+	query := listTaskTagsForTasks
+	var queryParams []interface{}
+	if len(taskIds) > 0 {
+		for _, v := range taskIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:task_ids*/?", strings.Repeat(",?", len(taskIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:task_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TaskTag
+	for rows.Next() {
+		var i TaskTag
+		if err := rows.Scan(&i.TaskID, &i.TagID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimeEntriesByTask = `-- name: ListTimeEntriesByTask :many
+SELECT id, task_id, started_at, ended_at FROM time_entries WHERE task_id = ? ORDER BY started_at ASC, id ASC
+`
+
+func (q *Queries) ListTimeEntriesByTask(ctx context.Context, taskID int64) ([]TimeEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listTimeEntriesByTask, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TimeEntry
+	for rows.Next() {
+		var i TimeEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.StartedAt,
+			&i.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTimeEntriesSince = `-- name: ListTimeEntriesSince :many
+SELECT time_entries.id, time_entries.task_id, time_entries.started_at, time_entries.ended_at
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+WHERE (time_entries.ended_at IS NULL OR time_entries.ended_at > ?) AND tasks.deleted_at IS NULL
+ORDER BY time_entries.started_at ASC, time_entries.id ASC
+`
+
+func (q *Queries) ListTimeEntriesSince(ctx context.Context, endedAt sql.NullTime) ([]TimeEntry, error) {
+	rows, err := q.db.QueryContext(ctx, listTimeEntriesSince, endedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TimeEntry
+	for rows.Next() {
+		var i TimeEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.StartedAt,
+			&i.EndedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listViews = `-- name: ListViews :many
+SELECT id, name, filter_json, created_at, updated_at
+FROM views
+ORDER BY name ASC
+`
+
+func (q *Queries) ListViews(ctx context.Context) ([]View, error) {
+	rows, err := q.db.QueryContext(ctx, listViews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []View
+	for rows.Next() {
+		var i View
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.FilterJson,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedTasks = `-- name: PurgeDeletedTasks :execrows
+DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+`
+
+func (q *Queries) PurgeDeletedTasks(ctx context.Context, deletedAt sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedTasks, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeDependency = `-- name: RemoveDependency :execrows
+DELETE FROM task_dependencies WHERE task_id = ? AND blocker_id = ?
+`
+
+type RemoveDependencyParams struct {
+	TaskID    int64 `db:"task_id" json:"task_id"`
+	BlockerID int64 `db:"blocker_id" json:"blocker_id"`
+}
+
+func (q *Queries) RemoveDependency(ctx context.Context, arg RemoveDependencyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeDependency, arg.TaskID, arg.BlockerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeTagFromTask = `-- name: RemoveTagFromTask :exec
+DELETE FROM task_tags WHERE task_id = ? AND tag_id = ?
+`
+
+type RemoveTagFromTaskParams struct {
+	TaskID int64 `db:"task_id" json:"task_id"`
+	TagID  int64 `db:"tag_id" json:"tag_id"`
+}
+
+func (q *Queries) RemoveTagFromTask(ctx context.Context, arg RemoveTagFromTaskParams) error {
+	_, err := q.db.ExecContext(ctx, removeTagFromTask, arg.TaskID, arg.TagID)
+	return err
+}
+
+const setSetting = `-- name: SetSetting :exec
+INSERT INTO settings (key, value)
+VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+`
+
+type SetSettingParams struct {
+	Key   string `db:"key" json:"key"`
+	Value string `db:"value" json:"value"`
+}
+
+func (q *Queries) SetSetting(ctx context.Context, arg SetSettingParams) error {
+	_, err := q.db.ExecContext(ctx, setSetting, arg.Key, arg.Value)
+	return err
+}
+
+const setTaskParent = `-- name: SetTaskParent :exec
+UPDATE tasks SET parent_task_id = ? WHERE id = ?
+`
+
+type SetTaskParentParams struct {
+	ParentTaskID sql.NullInt64 `db:"parent_task_id" json:"parent_task_id"`
+	ID           int64         `db:"id" json:"id"`
+}
+
+func (q *Queries) SetTaskParent(ctx context.Context, arg SetTaskParentParams) error {
+	_, err := q.db.ExecContext(ctx, setTaskParent, arg.ParentTaskID, arg.ID)
+	return err
+}
+
+const softDeleteTask = `-- name: SoftDeleteTask :exec
+UPDATE tasks SET deleted_at = ? WHERE id = ?
+`
+
+type SoftDeleteTaskParams struct {
+	DeletedAt sql.NullTime `db:"deleted_at" json:"deleted_at"`
+	ID        int64        `db:"id" json:"id"`
+}
+
+func (q *Queries) SoftDeleteTask(ctx context.Context, arg SoftDeleteTaskParams) error {
+	_, err := q.db.ExecContext(ctx, softDeleteTask, arg.DeletedAt, arg.ID)
+	return err
+}
+
+const taskDependsOn = `-- name: TaskDependsOn :one
+WITH RECURSIVE blockers(id) AS (
+  SELECT task_dependencies.blocker_id FROM task_dependencies WHERE task_dependencies.task_id = ?1
+  UNION
+  SELECT task_dependencies.blocker_id FROM task_dependencies JOIN blockers ON task_dependencies.task_id = blockers.id
+)
+SELECT EXISTS (SELECT 1 FROM blockers WHERE blockers.id = ?2)
+`
+
+type TaskDependsOnParams struct {
+	TaskID    int64 `db:"task_id" json:"task_id"`
+	BlockerID int64 `db:"blocker_id" json:"blocker_id"`
+}
+
+func (q *Queries) TaskDependsOn(ctx context.Context, arg TaskDependsOnParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, taskDependsOn, arg.TaskID, arg.BlockerID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const undeleteTask = `-- name: UndeleteTask :exec
+UPDATE tasks SET deleted_at = NULL WHERE id = ?
+`
+
+func (q *Queries) UndeleteTask(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, undeleteTask, id)
 	return err
 }
 
@@ -481,10 +1241,12 @@ SET title = ?,
     status = ?,
     priority = ?,
     due_at = ?,
+    due_all_day = ?,
     parent_task_id = ?,
+    recurrence = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
-RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at
+RETURNING id, parent_task_id, title, description, status, priority, due_at, created_at, updated_at, deleted_at, recurrence, due_all_day
 `
 
 type UpdateTaskParams struct {
@@ -493,7 +1255,9 @@ type UpdateTaskParams struct {
 	Status       string        `db:"status" json:"status"`
 	Priority     int64         `db:"priority" json:"priority"`
 	DueAt        sql.NullTime  `db:"due_at" json:"due_at"`
+	DueAllDay    bool          `db:"due_all_day" json:"due_all_day"`
 	ParentTaskID sql.NullInt64 `db:"parent_task_id" json:"parent_task_id"`
+	Recurrence   string        `db:"recurrence" json:"recurrence"`
 	ID           int64         `db:"id" json:"id"`
 }
 
@@ -504,7 +1268,9 @@ func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, e
 		arg.Status,
 		arg.Priority,
 		arg.DueAt,
+		arg.DueAllDay,
 		arg.ParentTaskID,
+		arg.Recurrence,
 		arg.ID,
 	)
 	var i Task
@@ -518,6 +1284,32 @@ func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) (Task, e
 		&i.DueAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Recurrence,
+		&i.DueAllDay,
+	)
+	return i, err
+}
+
+const updateTimeEntry = `-- name: UpdateTimeEntry :one
+UPDATE time_entries SET started_at = ?, ended_at = ? WHERE id = ?
+RETURNING id, task_id, started_at, ended_at
+`
+
+type UpdateTimeEntryParams struct {
+	StartedAt time.Time    `db:"started_at" json:"started_at"`
+	EndedAt   sql.NullTime `db:"ended_at" json:"ended_at"`
+	ID        int64        `db:"id" json:"id"`
+}
+
+func (q *Queries) UpdateTimeEntry(ctx context.Context, arg UpdateTimeEntryParams) (TimeEntry, error) {
+	row := q.db.QueryRowContext(ctx, updateTimeEntry, arg.StartedAt, arg.EndedAt, arg.ID)
+	var i TimeEntry
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.StartedAt,
+		&i.EndedAt,
 	)
 	return i, err
 }
@@ -6,38 +6,153 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
 	"github.com/Joseda-hg/lazytask/internal/model"
 )
 
 type Store struct {
 	DB      *sql.DB
 	Queries *sqlc.Queries
+
+	tx       *sql.Tx
+	origin   Origin
+	location *time.Location
+	events   *eventBus
+	// pending holds the events of the transaction tx until it commits.
+	pending *[]Event
 }
 
 type TaskInput struct {
-	Title        string
-	Description  string
-	Status       string
-	Priority     int64
-	DueAt        *time.Time
+	Title       string
+	Description string
+	Status      string
+	Priority    int64
+	DueAt       *time.Time
+	// DueAllDay makes DueAt a day, stored as midnight UTC of its date.
+	DueAllDay    bool
 	ParentTaskID *int64
-	Tags         []string
+	// Recurrence is an RRULE such as "FREQ=WEEKLY;BYDAY=MO"; see
+	// model.ParseRecurrence.
+	Recurrence string
+	Tags       []string
+}
+
+// SetDue sets the due date of the input, or clears it when due is nil.
+func (input *TaskInput) SetDue(due *duedate.Due) {
+	input.DueAt, input.DueAllDay = nil, false
+	if due != nil {
+		instant := due.Instant()
+		input.DueAt, input.DueAllDay = &instant, !due.HasTime
+	}
+}
+
+// InputFromTask returns the input that writes task as it is, for callers
+// that change a few fields of a stored task.
+func InputFromTask(task model.Task) TaskInput {
+	names := make([]string, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		names = append(names, tag.Name)
+	}
+	return TaskInput{
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		Priority:     task.Priority,
+		DueAt:        task.DueAt,
+		DueAllDay:    task.DueAllDay,
+		ParentTaskID: task.ParentTaskID,
+		Recurrence:   task.Recurrence,
+		Tags:         names,
+	}
 }
 
 func NewStore(db *sql.DB) *Store {
-	return &Store{DB: db, Queries: sqlc.New(db)}
+	return &Store{DB: db, Queries: sqlc.New(db), events: newEventBus()}
+}
+
+// WithLocation returns a store sharing s's connection that reads due dates
+// in loc: due filters compare timed tasks by their day there, and recurring
+// tasks repeat at the same time of day there.
+func (s *Store) WithLocation(loc *time.Location) *Store {
+	clone := *s
+	clone.location = loc
+	return &clone
+}
+
+// Location returns the time zone due dates are read and shown in, which is
+// the system's unless set with WithLocation.
+func (s *Store) Location() *time.Location {
+	if s.location == nil {
+		return time.Local
+	}
+	return s.location
+}
+
+// WithTx runs fn inside a single transaction, committing when fn returns nil
+// and rolling back otherwise. The *Store passed to fn is bound to the
+// transaction and must be used for every operation inside fn; calls on a store
+// already bound to a transaction join it instead of starting a new one.
+func (s *Store) WithTx(ctx context.Context, fn func(*Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = tx.Rollback() }()
+
+	pending := []Event{}
+	if err := fn(&Store{DB: s.DB, Queries: s.Queries.WithTx(tx), tx: tx, origin: s.origin, location: s.location, events: s.events, pending: &pending}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.events.publish(pending...)
+	return nil
+}
+
+// conn returns the transaction the store is bound to, or the database.
+func (s *Store) conn() sqlc.DBTX {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.DB
 }
 
 func (s *Store) CreateTask(ctx context.Context, input TaskInput) (model.Task, error) {
+	var createdTask model.Task
+	err := s.WithTx(ctx, func(tx *Store) error {
+		var err error
+		createdTask, err = tx.createTask(ctx, input)
+		return err
+	})
+	if err != nil {
+		return model.Task{}, err
+	}
+	return createdTask, nil
+}
+
+// createTask creates a task; links are recorded in its history next to its
+// fields.
+func (s *Store) createTask(ctx context.Context, input TaskInput, links ...model.HistoryChange) (model.Task, error) {
+	if err := s.validateInput(ctx, 0, input, nil); err != nil {
+		return model.Task{}, err
+	}
 	status := normalizeStatus(input.Status)
 
-	var dueAt sql.NullTime
-	if input.DueAt != nil {
-		dueAt = sql.NullTime{Time: *input.DueAt, Valid: true}
+	recurrence, err := model.NormalizeRecurrence(input.Recurrence)
+	if err != nil {
+		return model.Task{}, err
 	}
 
 	var parentTaskID sql.NullInt64
@@ -50,14 +165,16 @@ func (s *Store) CreateTask(ctx context.Context, input TaskInput) (model.Task, er
 		Description:  input.Description,
 		Status:       status,
 		Priority:     input.Priority,
-		DueAt:        dueAt,
+		DueAt:        dueParam(input),
+		DueAllDay:    input.DueAt != nil && input.DueAllDay,
 		ParentTaskID: parentTaskID,
+		Recurrence:   recurrence,
 	})
 	if err != nil {
 		return model.Task{}, err
 	}
 
-	if err := s.SetTaskTags(ctx, created.ID, input.Tags); err != nil {
+	if err := s.setTaskTags(ctx, created.ID, input.Tags); err != nil {
 		return model.Task{}, err
 	}
 
@@ -66,11 +183,11 @@ func (s *Store) CreateTask(ctx context.Context, input TaskInput) (model.Task, er
 		return model.Task{}, err
 	}
 
-	if _, err := s.Queries.AddHistory(ctx, sqlc.AddHistoryParams{
-		TaskID:    created.ID,
-		EventType: "created",
-		Details:   formatCreatedDetails(createdTask),
-	}); err != nil {
+	if err := s.addHistory(ctx, created.ID, model.EventCreated, append(snapshotChanges(model.EventCreated, createdTask), links...)); err != nil {
+		return model.Task{}, err
+	}
+
+	if err := s.trackStatus(ctx, created.ID, "", createdTask.Status); err != nil {
 		return model.Task{}, err
 	}
 
@@ -78,16 +195,59 @@ func (s *Store) CreateTask(ctx context.Context, input TaskInput) (model.Task, er
 }
 
 func (s *Store) UpdateTask(ctx context.Context, taskID int64, input TaskInput) (model.Task, error) {
+	var after model.Task
+	err := s.WithTx(ctx, func(tx *Store) error {
+		var err error
+		after, err = tx.updateTask(ctx, taskID, input)
+		return err
+	})
+	if err != nil {
+		return model.Task{}, err
+	}
+	return after, nil
+}
+
+// updateTask updates a task. Marking a recurring task done moves its
+// recurrence to a newly created next occurrence.
+func (s *Store) updateTask(ctx context.Context, taskID int64, input TaskInput) (model.Task, error) {
 	before, err := s.GetTaskWithTags(ctx, taskID)
 	if err != nil {
 		return model.Task{}, err
 	}
+	recurrence, err := model.NormalizeRecurrence(input.Recurrence)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if recurrence == "" || normalizeStatus(input.Status) != "done" || before.Status == "done" {
+		return s.updateTaskAs(ctx, taskID, input, model.EventUpdated)
+	}
+
+	input.Recurrence = ""
+	after, err := s.updateTaskAs(ctx, taskID, input, model.EventUpdated)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if _, err := s.createNextOccurrence(ctx, after, recurrence); err != nil {
+		return model.Task{}, err
+	}
+	return after, nil
+}
+
+// updateTaskAs updates a task and records the change as eventType.
+func (s *Store) updateTaskAs(ctx context.Context, taskID int64, input TaskInput, eventType string) (model.Task, error) {
+	before, err := s.GetTaskWithTags(ctx, taskID)
+	if err != nil {
+		return model.Task{}, err
+	}
+	if err := s.validateInput(ctx, taskID, input, before.ParentTaskID); err != nil {
+		return model.Task{}, err
+	}
 
 	status := normalizeStatus(input.Status)
 
-	var dueAt sql.NullTime
-	if input.DueAt != nil {
-		dueAt = sql.NullTime{Time: *input.DueAt, Valid: true}
+	recurrence, err := model.NormalizeRecurrence(input.Recurrence)
+	if err != nil {
+		return model.Task{}, err
 	}
 
 	var parentTaskID sql.NullInt64
@@ -100,15 +260,17 @@ func (s *Store) UpdateTask(ctx context.Context, taskID int64, input TaskInput) (
 		Description:  input.Description,
 		Status:       status,
 		Priority:     input.Priority,
-		DueAt:        dueAt,
+		DueAt:        dueParam(input),
+		DueAllDay:    input.DueAt != nil && input.DueAllDay,
 		ParentTaskID: parentTaskID,
+		Recurrence:   recurrence,
 		ID:           taskID,
 	})
 	if err != nil {
 		return model.Task{}, err
 	}
 
-	if err := s.SetTaskTags(ctx, updated.ID, input.Tags); err != nil {
+	if err := s.setTaskTags(ctx, updated.ID, input.Tags); err != nil {
 		return model.Task{}, err
 	}
 
@@ -117,39 +279,54 @@ func (s *Store) UpdateTask(ctx context.Context, taskID int64, input TaskInput) (
 		return model.Task{}, err
 	}
 
-	if _, err := s.Queries.AddHistory(ctx, sqlc.AddHistoryParams{
-		TaskID:    updated.ID,
-		EventType: "updated",
-		Details:   formatTaskDiff(before, after),
-	}); err != nil {
+	if err := s.addHistory(ctx, updated.ID, eventType, taskChanges(before, after)); err != nil {
+		return model.Task{}, err
+	}
+
+	if err := s.trackStatus(ctx, updated.ID, before.Status, after.Status); err != nil {
 		return model.Task{}, err
 	}
 
 	return after, nil
 }
 
+// DeleteTask moves a task to the trash. Its history and subtask links are kept
+// until it is purged; see RestoreFromTrash and PurgeTask.
 func (s *Store) DeleteTask(ctx context.Context, taskID int64) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		return tx.deleteTask(ctx, taskID)
+	})
+}
+
+func (s *Store) deleteTask(ctx context.Context, taskID int64) error {
 	before, err := s.GetTaskWithTags(ctx, taskID)
 	if err != nil {
 		return err
 	}
 
-	if _, err := s.Queries.AddHistory(ctx, sqlc.AddHistoryParams{
-		TaskID:    taskID,
-		EventType: "deleted",
-		Details:   formatDeletedDetails(before),
-	}); err != nil {
+	if err := s.addHistory(ctx, taskID, model.EventDeleted, snapshotChanges(model.EventDeleted, before)); err != nil {
 		return err
 	}
 
-	return s.Queries.DeleteTask(ctx, taskID)
+	if err := s.trackStatus(ctx, taskID, before.Status, ""); err != nil {
+		return err
+	}
+
+	return s.Queries.SoftDeleteTask(ctx, sqlc.SoftDeleteTaskParams{
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        taskID,
+	})
 }
 
+// GetTaskWithTags returns a task that is not in the trash, or sql.ErrNoRows.
 func (s *Store) GetTaskWithTags(ctx context.Context, taskID int64) (model.Task, error) {
 	row, err := s.Queries.GetTask(ctx, taskID)
 	if err != nil {
 		return model.Task{}, err
 	}
+	if row.DeletedAt.Valid {
+		return model.Task{}, sql.ErrNoRows
+	}
 
 	tags, err := s.Queries.ListTagsForTask(ctx, taskID)
 	if err != nil {
@@ -160,51 +337,110 @@ func (s *Store) GetTaskWithTags(ctx context.Context, taskID int64) (model.Task,
 }
 
 func (s *Store) ListTasks(ctx context.Context, filter model.Filter) ([]model.Task, error) {
-	var dueBefore sql.NullTime
-	if filter.DueBefore != nil {
-		dueBefore = sql.NullTime{Time: *filter.DueBefore, Valid: true}
+	results, err := s.SearchTasks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]model.Task, 0, len(results))
+	for _, result := range results {
+		tasks = append(tasks, result.Task)
+	}
+	return tasks, nil
+}
+
+// tagFilter returns the distinct filter tags together with how many of them a
+// task must carry: none without tags, one for TagMatchAny and all of them for
+// TagMatchAll.
+func tagFilter(filter model.Filter) ([]string, int, error) {
+	seen := make(map[string]struct{}, len(filter.Tags))
+	names := make([]string, 0, len(filter.Tags))
+	for _, name := range filter.Tags {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
 	}
 
-	var dueAfter sql.NullTime
-	if filter.DueAfter != nil {
-		dueAfter = sql.NullTime{Time: *filter.DueAfter, Valid: true}
+	switch strings.TrimSpace(filter.TagMatch) {
+	case "", model.TagMatchAny:
+		return names, min(len(names), 1), nil
+	case model.TagMatchAll:
+		return names, len(names), nil
+	default:
+		return nil, 0, fmt.Errorf("invalid tag match mode %q: expected %s or %s", filter.TagMatch, model.TagMatchAny, model.TagMatchAll)
 	}
+}
 
-	query := strings.TrimSpace(filter.Query)
-	status := strings.TrimSpace(filter.Status)
+// tagBatchSize keeps batched tag lookups well below SQLite's bound parameter
+// limit. Larger result sets read every task/tag pair in a single query instead.
+const tagBatchSize = 500
 
-	var rows []sqlc.Task
+// mapTasks attaches tags to rows using a fixed number of queries: the tag
+// list plus either the pairs for these tasks or, for large sets, all pairs.
+func (s *Store) mapTasks(ctx context.Context, rows []sqlc.Task) ([]model.Task, error) {
+	if len(rows) == 0 {
+		return []model.Task{}, nil
+	}
+
+	var pairs []sqlc.TaskTag
 	var err error
-	if len(filter.Tags) > 0 {
-		rows, err = s.Queries.ListTasksByTags(ctx, sqlc.ListTasksByTagsParams{
-			Tags:  filter.Tags,
-			Query: query,
-		})
+	if len(rows) <= tagBatchSize {
+		ids := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		pairs, err = s.Queries.ListTaskTagsForTasks(ctx, ids)
 	} else {
-		rows, err = s.Queries.ListTasks(ctx, sqlc.ListTasksParams{
-			Query:     query,
-			Status:    status,
-			DueBefore: dueBefore,
-			DueAfter:  dueAfter,
-		})
+		pairs, err = s.Queries.ListTaskTags(ctx)
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	result := make([]model.Task, 0, len(rows))
-	for _, row := range rows {
-		tags, err := s.Queries.ListTagsForTask(ctx, row.ID)
+	tagIDsByTask := make(map[int64][]int64, len(rows))
+	for _, pair := range pairs {
+		tagIDsByTask[pair.TaskID] = append(tagIDsByTask[pair.TaskID], pair.TagID)
+	}
+
+	var tagsByID map[int64]sqlc.Tag
+	if len(pairs) > 0 {
+		allTags, err := s.Queries.ListTags(ctx)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, mapTask(row, tags))
+		tagsByID = make(map[int64]sqlc.Tag, len(allTags))
+		for _, tag := range allTags {
+			tagsByID[tag.ID] = tag
+		}
 	}
 
+	result := make([]model.Task, 0, len(rows))
+	for _, row := range rows {
+		tagIDs := tagIDsByTask[row.ID]
+		tags := make([]sqlc.Tag, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			if tag, ok := tagsByID[tagID]; ok {
+				tags = append(tags, tag)
+			}
+		}
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+		result = append(result, mapTask(row, tags))
+	}
 	return result, nil
 }
 
 func (s *Store) SetTaskTags(ctx context.Context, taskID int64, tagNames []string) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		return tx.setTaskTags(ctx, taskID, tagNames)
+	})
+}
+
+func (s *Store) setTaskTags(ctx context.Context, taskID int64, tagNames []string) error {
 	if err := s.Queries.ClearTagsForTask(ctx, taskID); err != nil {
 		return err
 	}
@@ -236,29 +472,26 @@ func (s *Store) ListTags(ctx context.Context) ([]model.Tag, error) {
 }
 
 func (s *Store) DeleteTag(ctx context.Context, tagID int64) error {
-	return s.Queries.DeleteTag(ctx, tagID)
+	if err := s.Queries.DeleteTag(ctx, tagID); err != nil {
+		return err
+	}
+	s.publish(Event{Kind: EventTag, Action: "deleted", TagID: tagID})
+	return nil
 }
 
-func (s *Store) ListHistory(ctx context.Context, taskID int64) ([]model.HistoryEntry, error) {
-	rows, err := s.Queries.ListHistoryByTask(ctx, taskID)
-	if err != nil {
-		return nil, err
+func (s *Store) SaveView(ctx context.Context, view model.View) (model.View, error) {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return model.View{}, fmt.Errorf("view name is required")
 	}
-
-	history := make([]model.HistoryEntry, 0, len(rows))
-	for _, row := range rows {
-		history = append(history, model.HistoryEntry{
-			ID:        row.ID,
-			TaskID:    row.TaskID,
-			EventType: row.EventType,
-			Details:   row.Details,
-			CreatedAt: row.CreatedAt,
-		})
+	existing, err := s.Queries.GetViewByName(ctx, view.Name)
+	if err == nil && existing.ID != view.ID {
+		return model.View{}, fmt.Errorf("view %q already exists", view.Name)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return model.View{}, err
 	}
-	return history, nil
-}
 
-func (s *Store) SaveView(ctx context.Context, view model.View) (model.View, error) {
 	payload, err := json.Marshal(view.Filter)
 	if err != nil {
 		return model.View{}, err
@@ -313,6 +546,53 @@ func (s *Store) GetViewByName(ctx context.Context, name string) (model.View, err
 	return mapView(row)
 }
 
+func (s *Store) GetView(ctx context.Context, viewID int64) (model.View, error) {
+	row, err := s.Queries.GetView(ctx, viewID)
+	if err != nil {
+		return model.View{}, err
+	}
+	return mapView(row)
+}
+
+const activeViewSetting = "active_view"
+
+// ActiveView returns the view last selected with SetActiveView, or nil when
+// none is selected or it has since been deleted.
+func (s *Store) ActiveView(ctx context.Context) (*model.View, error) {
+	value, err := s.Queries.GetSetting(ctx, activeViewSetting)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	viewID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	view, err := s.GetView(ctx, viewID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// SetActiveView remembers the selected view across sessions; a zero ID
+// clears it.
+func (s *Store) SetActiveView(ctx context.Context, viewID int64) error {
+	if viewID == 0 {
+		return s.Queries.DeleteSetting(ctx, activeViewSetting)
+	}
+	return s.Queries.SetSetting(ctx, sqlc.SetSettingParams{
+		Key:   activeViewSetting,
+		Value: strconv.FormatInt(viewID, 10),
+	})
+}
+
 func mapTask(task sqlc.Task, tags []sqlc.Tag) model.Task {
 	result := model.Task{
 		ID:          task.ID,
@@ -322,9 +602,14 @@ func mapTask(task sqlc.Task, tags []sqlc.Tag) model.Task {
 		Priority:    task.Priority,
 		CreatedAt:   task.CreatedAt,
 		UpdatedAt:   task.UpdatedAt,
+		Recurrence:  task.Recurrence,
 	}
 	if task.DueAt.Valid {
 		result.DueAt = &task.DueAt.Time
+		result.DueAllDay = task.DueAllDay
+	}
+	if task.DeletedAt.Valid {
+		result.DeletedAt = &task.DeletedAt.Time
 	}
 	if task.ParentTaskID.Valid {
 		parentID := task.ParentTaskID.Int64
@@ -361,6 +646,19 @@ func normalizeStatus(status string) string {
 	return value
 }
 
+// dueParam returns the due date of input as it is stored: all-day dates as
+// midnight UTC of their date, timed ones as the instant in UTC.
+func dueParam(input TaskInput) sql.NullTime {
+	if input.DueAt == nil {
+		return sql.NullTime{}
+	}
+	if input.DueAllDay {
+		year, month, day := input.DueAt.Date()
+		return sql.NullTime{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+	}
+	return sql.NullTime{Time: input.DueAt.UTC(), Valid: true}
+}
+
 func normalizeTags(tags []string) []string {
 	seen := make(map[string]struct{})
 	result := make([]string, 0, len(tags))
@@ -378,83 +676,3 @@ func normalizeTags(tags []string) []string {
 	}
 	return result
 }
-
-func formatCreatedDetails(task model.Task) string {
-	return fmt.Sprintf("created: title='%s' status=%s priority=%d due=%s tags=%s", task.Title, task.Status, task.Priority, formatDue(task.DueAt), formatTags(task.Tags))
-}
-
-func formatDeletedDetails(task model.Task) string {
-	return fmt.Sprintf("deleted: title='%s' status=%s priority=%d due=%s tags=%s", task.Title, task.Status, task.Priority, formatDue(task.DueAt), formatTags(task.Tags))
-}
-
-func formatTaskDiff(before, after model.Task) string {
-	changes := []string{}
-	if before.Title != after.Title {
-		changes = append(changes, formatChange("title", before.Title, after.Title))
-	}
-	if before.Description != after.Description {
-		changes = append(changes, formatChange("description", before.Description, after.Description))
-	}
-	if before.Status != after.Status {
-		changes = append(changes, formatChange("status", before.Status, after.Status))
-	}
-	if before.Priority != after.Priority {
-		changes = append(changes, formatChange("priority", fmt.Sprintf("%d", before.Priority), fmt.Sprintf("%d", after.Priority)))
-	}
-	if formatParent(before.ParentTaskID) != formatParent(after.ParentTaskID) {
-		changes = append(changes, formatChange("parent", formatParent(before.ParentTaskID), formatParent(after.ParentTaskID)))
-	}
-	if formatDue(before.DueAt) != formatDue(after.DueAt) {
-		changes = append(changes, formatChange("due", formatDue(before.DueAt), formatDue(after.DueAt)))
-	}
-	beforeTags := formatTags(before.Tags)
-	afterTags := formatTags(after.Tags)
-	if beforeTags != afterTags {
-		changes = append(changes, formatChange("tags", beforeTags, afterTags))
-	}
-
-	if len(changes) == 0 {
-		return "updated: no changes"
-	}
-
-	return "updated: " + strings.Join(changes, "; ")
-}
-
-func formatChange(field, before, after string) string {
-	return fmt.Sprintf("%s: '%s' -> '%s'", field, valueOrNone(before), valueOrNone(after))
-}
-
-func valueOrNone(value string) string {
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return "none"
-	}
-	return trimmed
-}
-
-func formatDue(value *time.Time) string {
-	if value == nil {
-		return "none"
-	}
-	return value.Format("2006-01-02")
-}
-
-func formatParent(parentID *int64) string {
-	if parentID == nil || *parentID == 0 {
-		return "none"
-	}
-	return fmt.Sprintf("%d", *parentID)
-}
-
-func formatTags(tags []model.Tag) string {
-	if len(tags) == 0 {
-		return "none"
-	}
-
-	names := make([]string, 0, len(tags))
-	for _, tag := range tags {
-		names = append(names, tag.Name)
-	}
-	sort.Strings(names)
-	return strings.Join(names, ",")
-}
@@ -2,7 +2,16 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
 )
 
 func TestCreateTaskPersistsTagsAndHistory(t *testing.T) {
@@ -59,6 +68,592 @@ func TestCreateTaskPersistsTagsAndHistory(t *testing.T) {
 	}
 }
 
+func TestHistoryRecordsStructuredChanges(t *testing.T) {
+	base, cleanup := newTestStore(t)
+	defer cleanup()
+	store := base.WithOrigin(Origin{Actor: "ana", Source: SourceCLI})
+	ctx := context.Background()
+
+	created, err := store.CreateTask(ctx, TaskInput{Title: "Ship", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	due := time.Date(2026, 11, 1, 14, 30, 0, 0, time.UTC)
+	if _, err := store.UpdateTask(ctx, created.ID, TaskInput{Title: "Ship it", Status: "doing", DueAt: &due, Tags: []string{"work"}}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+
+	history, err := store.ListHistory(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history entries, got %d", len(history))
+	}
+	updated := history[0]
+	if updated.Actor != "ana" || updated.Source != SourceCLI {
+		t.Fatalf("expected origin ana/cli, got %q/%q", updated.Actor, updated.Source)
+	}
+	want := []model.HistoryChange{
+		{Field: model.FieldTitle, Old: "Ship", New: "Ship it"},
+		{Field: model.FieldStatus, Old: "todo", New: "doing"},
+		{Field: model.FieldDue, Old: "", New: "2026-11-01T14:30:00Z"},
+	}
+	if fmt.Sprint(updated.Changes) != fmt.Sprint(want) {
+		t.Fatalf("expected changes %v, got %v", want, updated.Changes)
+	}
+	if got := updated.Details(time.UTC); got != "updated: title: 'Ship' -> 'Ship it'; status: 'todo' -> 'doing'; due: 'none' -> '2026-11-01 14:30'" {
+		t.Fatalf("unexpected details %q", got)
+	}
+	if got := history[1].Details(time.UTC); got != "created: title='Ship' status=todo priority=0 due=none tags=work" {
+		t.Fatalf("unexpected created details %q", got)
+	}
+
+	// Operations inside a transaction keep the origin.
+	err = store.WithTx(ctx, func(tx *Store) error {
+		return tx.DeleteTask(ctx, created.ID)
+	})
+	if err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	history, err = store.ListHistory(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if history[0].EventType != model.EventDeleted || history[0].Actor != "ana" {
+		t.Fatalf("expected deleted entry by ana, got %+v", history[0])
+	}
+}
+
+func TestListActivityPagesAcrossTasks(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	old, err := store.CreateTask(ctx, TaskInput{Title: "Old"})
+	if err != nil {
+		t.Fatalf("create old task: %v", err)
+	}
+	if _, err := store.DB.ExecContext(ctx, "UPDATE task_history SET created_at = '2020-01-01 00:00:00' WHERE task_id = ?", old.ID); err != nil {
+		t.Fatalf("backdate history: %v", err)
+	}
+	first, err := store.CreateTask(ctx, TaskInput{Title: "First"})
+	if err != nil {
+		t.Fatalf("create first task: %v", err)
+	}
+	second, err := store.CreateTask(ctx, TaskInput{Title: "Second"})
+	if err != nil {
+		t.Fatalf("create second task: %v", err)
+	}
+	if err := store.DeleteTask(ctx, first.ID); err != nil {
+		t.Fatalf("delete first task: %v", err)
+	}
+
+	since := time.Now().Add(-time.Hour)
+	page, err := store.ListActivity(ctx, since, 2, 0)
+	if err != nil {
+		t.Fatalf("list activity: %v", err)
+	}
+	if len(page) != 2 {
+		t.Fatalf("expected a full first page, got %d entries", len(page))
+	}
+	if page[0].TaskID != first.ID || page[0].EventType != model.EventDeleted || page[0].TaskTitle != "First" {
+		t.Fatalf("expected the deletion of 'First' first, got %+v", page[0])
+	}
+	if page[1].TaskID != second.ID {
+		t.Fatalf("expected 'Second' next, got %+v", page[1])
+	}
+
+	rest, err := store.ListActivity(ctx, since, 2, 2)
+	if err != nil {
+		t.Fatalf("list activity page 2: %v", err)
+	}
+	if len(rest) != 1 || rest[0].TaskID != first.ID || rest[0].EventType != model.EventCreated {
+		t.Fatalf("expected only the creation of 'First' on page 2, got %+v", rest)
+	}
+
+	all, err := store.ListActivity(ctx, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), 10, 0)
+	if err != nil {
+		t.Fatalf("list all activity: %v", err)
+	}
+	if len(all) != 4 || all[3].TaskID != old.ID {
+		t.Fatalf("expected the backdated entry last, got %d entries", len(all))
+	}
+}
+
+func TestCompletingRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	due := time.Now().AddDate(0, 0, 1).Truncate(time.Second).UTC()
+	checklist, err := store.CreateTask(ctx, TaskInput{Title: "Ops checklist", DueAt: &due, Tags: []string{"ops"}, Recurrence: "rrule:freq=weekly"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if checklist.Recurrence != "FREQ=WEEKLY" {
+		t.Fatalf("expected canonical recurrence, got %q", checklist.Recurrence)
+	}
+	step, err := store.CreateTask(ctx, TaskInput{Title: "Rotate logs", Status: "done", DueAt: &due, ParentTaskID: &checklist.ID})
+	if err != nil {
+		t.Fatalf("create subtask: %v", err)
+	}
+
+	input := InputFromTask(checklist)
+	input.Status = "done"
+	done, err := store.UpdateTask(ctx, checklist.ID, input)
+	if err != nil {
+		t.Fatalf("complete task: %v", err)
+	}
+	if done.Recurrence != "" {
+		t.Fatalf("expected the recurrence to move to the next occurrence, got %q", done.Recurrence)
+	}
+
+	history, err := store.ListHistory(ctx, checklist.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	link, ok := history[0].Change(model.FieldNext)
+	if history[0].EventType != model.EventRecurred || !ok {
+		t.Fatalf("expected a recurred entry linking the next occurrence, got %+v", history[0])
+	}
+	nextID, _ := strconv.ParseInt(link.New, 10, 64)
+	next, err := store.GetTaskWithTags(ctx, nextID)
+	if err != nil {
+		t.Fatalf("get next occurrence: %v", err)
+	}
+	if next.Status != "todo" || next.Recurrence != "FREQ=WEEKLY" || len(next.Tags) != 1 || next.Tags[0].Name != "ops" {
+		t.Fatalf("expected a todo copy with tags and recurrence, got %+v", next)
+	}
+	if next.DueAt == nil || !next.DueAt.Equal(due.AddDate(0, 0, 7)) {
+		t.Fatalf("expected next occurrence due a week later, got %v", next.DueAt)
+	}
+	nextHistory, err := store.ListHistory(ctx, next.ID)
+	if err != nil {
+		t.Fatalf("list next history: %v", err)
+	}
+	if previous, ok := nextHistory[0].Change(model.FieldPrevious); !ok || previous.New != strconv.FormatInt(checklist.ID, 10) {
+		t.Fatalf("expected the next occurrence to link back, got %+v", nextHistory[0])
+	}
+
+	children, err := store.ListTasks(ctx, model.Filter{Query: fmt.Sprintf("parent:%d", next.ID)})
+	if err != nil {
+		t.Fatalf("list subtasks: %v", err)
+	}
+	if len(children) != 1 || children[0].Title != step.Title || children[0].Status != "todo" {
+		t.Fatalf("expected a fresh copy of the subtask, got %+v", children)
+	}
+
+	// Reopening and completing the old task again does not repeat it.
+	input.Status = "todo"
+	input.Recurrence = ""
+	if _, err := store.UpdateTask(ctx, checklist.ID, input); err != nil {
+		t.Fatalf("reopen task: %v", err)
+	}
+	input.Status = "done"
+	if _, err := store.UpdateTask(ctx, checklist.ID, input); err != nil {
+		t.Fatalf("complete task again: %v", err)
+	}
+	all, err := store.ListTasks(ctx, model.Filter{Query: "Ops"})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected one occurrence per completion, got %d tasks", len(all))
+	}
+
+	if _, err := store.CreateTask(ctx, TaskInput{Title: "Bad", Recurrence: "FREQ=HOURLY"}); err == nil {
+		t.Fatalf("expected unsupported recurrence to be rejected")
+	}
+}
+
+func TestUndatedRecurringTaskRepeatsAllDayFromToday(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no zone database: %v", err)
+	}
+	base, cleanup := newTestStore(t)
+	defer cleanup()
+	store := base.WithLocation(newYork)
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, TaskInput{Title: "Water plants", Recurrence: "daily"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	input := InputFromTask(task)
+	input.Status = "done"
+	if _, err := store.UpdateTask(ctx, task.ID, input); err != nil {
+		t.Fatalf("complete task: %v", err)
+	}
+	nextID, ok, err := store.NextOccurrenceID(ctx, task.ID)
+	if err != nil || !ok {
+		t.Fatalf("expected a next occurrence: %v", err)
+	}
+	next, err := store.GetTaskWithTags(ctx, nextID)
+	if err != nil {
+		t.Fatalf("get next occurrence: %v", err)
+	}
+	year, month, day := time.Now().In(newYork).AddDate(0, 0, 1).Date()
+	want := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if next.DueAt == nil || !next.DueAt.Equal(want) || !next.DueAllDay {
+		t.Fatalf("expected the next occurrence to be due all day on %s, got %v (all day %t)", want.Format("2006-01-02"), next.DueAt, next.DueAllDay)
+	}
+}
+
+func TestNextOccurrenceShiftsSubtasksByCalendarDays(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no zone database: %v", err)
+	}
+	base, cleanup := newTestStore(t)
+	defer cleanup()
+	store := base.WithLocation(newYork)
+	ctx := context.Background()
+
+	// A week that crosses the start of daylight saving time on March 9.
+	due := time.Date(2036, time.March, 8, 9, 0, 0, 0, newYork).UTC()
+	allDay := time.Date(2036, time.March, 7, 0, 0, 0, 0, time.UTC)
+	timed := time.Date(2036, time.March, 8, 8, 0, 0, 0, newYork).UTC()
+	checklist, err := store.CreateTask(ctx, TaskInput{Title: "Weekly review", DueAt: &due, Recurrence: "weekly"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	for _, childDue := range []time.Time{allDay, timed} {
+		if _, err := store.CreateTask(ctx, TaskInput{Title: "Step", DueAt: &childDue, DueAllDay: childDue.Equal(allDay), ParentTaskID: &checklist.ID}); err != nil {
+			t.Fatalf("create subtask: %v", err)
+		}
+	}
+
+	input := InputFromTask(checklist)
+	input.Status = "done"
+	if _, err := store.UpdateTask(ctx, checklist.ID, input); err != nil {
+		t.Fatalf("complete task: %v", err)
+	}
+	nextID, ok, err := store.NextOccurrenceID(ctx, checklist.ID)
+	if err != nil || !ok {
+		t.Fatalf("expected a next occurrence: %v", err)
+	}
+	children, err := store.ListTasks(ctx, model.Filter{Query: fmt.Sprintf("parent:%d", nextID)})
+	if err != nil {
+		t.Fatalf("list subtasks: %v", err)
+	}
+	var got []string
+	for _, child := range children {
+		got = append(got, child.DueAt.UTC().Format(time.RFC3339))
+	}
+	sort.Strings(got)
+	want := []string{
+		allDay.AddDate(0, 0, 7).Format(time.RFC3339),
+		time.Date(2036, time.March, 15, 8, 0, 0, 0, newYork).UTC().Format(time.RFC3339),
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected subtasks due %v, got %v", want, got)
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	start := time.Date(2026, time.January, 30, 9, 0, 0, 0, time.UTC) // a Friday
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{"daily", "2026-01-31 09:00"},
+		{"FREQ=DAILY;INTERVAL=3", "2026-02-02 09:00"},
+		{"FREQ=WEEKLY;BYDAY=MO,SA", "2026-01-31 09:00"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-02-09 09:00"},
+		{"monthly", "2026-03-30 09:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,15", "2026-02-01 09:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-31 09:00"},
+		{"FREQ=MONTHLY;BYDAY=1MO", "2026-02-02 09:00"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "2026-02-27 09:00"},
+		{"FREQ=YEARLY", "2027-01-30 09:00"},
+	}
+	for _, tc := range cases {
+		rule, err := model.ParseRecurrence(tc.rule)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.rule, err)
+		}
+		if got := rule.Next(start).Format("2006-01-02 15:04"); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.rule, tc.want, got)
+		}
+	}
+}
+
+func TestRecurrenceKeepsTheDayItStartedOn(t *testing.T) {
+	cases := []struct {
+		rule  string
+		start time.Time
+		want  []string
+	}{
+		{"monthly", time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC), []string{"2026-03-31", "2026-05-31", "2026-07-31", "2026-08-31"}},
+		{"FREQ=MONTHLY;INTERVAL=2", time.Date(2026, time.August, 31, 0, 0, 0, 0, time.UTC), []string{"2026-10-31", "2026-12-31", "2027-08-31"}},
+		{"yearly", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC), []string{"2032-02-29", "2036-02-29"}},
+		{"monthly", time.Date(2026, time.January, 28, 0, 0, 0, 0, time.UTC), []string{"2026-02-28", "2026-03-28"}},
+	}
+	for _, tc := range cases {
+		rule, err := model.ParseRecurrence(tc.rule)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.rule, err)
+		}
+		var got []string
+		for due := tc.start; len(got) < len(tc.want); {
+			due = rule.Next(due)
+			got = append(got, due.Format("2006-01-02"))
+		}
+		if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Fatalf("%s from %s: expected %v, got %v", tc.rule, tc.start.Format("2006-01-02"), tc.want, got)
+		}
+	}
+}
+
+func TestRevertTaskToHistoryEntry(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	created, err := store.CreateTask(ctx, TaskInput{Title: "Plan", Description: "first draft", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if _, err := store.UpdateTask(ctx, created.ID, TaskInput{Title: "Plan", Description: "careful notes", Priority: 3, Tags: []string{"work"}}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	if _, err := store.UpdateTask(ctx, created.ID, TaskInput{Title: "Plan v2", Description: "oops", Priority: 3, Tags: []string{"home"}}); err != nil {
+		t.Fatalf("clobber task: %v", err)
+	}
+
+	history, err := store.ListHistory(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	careful, first := history[1], history[2]
+
+	reverted, err := store.RevertTask(ctx, created.ID, careful.ID, model.FieldDescription)
+	if err != nil {
+		t.Fatalf("revert description: %v", err)
+	}
+	if reverted.Description != "careful notes" || reverted.Title != "Plan v2" || model.FieldValue(reverted, model.FieldTags) != "home" {
+		t.Fatalf("expected only the description reverted, got %+v", reverted)
+	}
+
+	reverted, err = store.RevertTask(ctx, created.ID, first.ID)
+	if err != nil {
+		t.Fatalf("revert task: %v", err)
+	}
+	if reverted.Title != "Plan" || reverted.Description != "first draft" || reverted.Priority != 0 || model.FieldValue(reverted, model.FieldTags) != "work" {
+		t.Fatalf("expected the task as created, got %+v", reverted)
+	}
+
+	history, err = store.ListHistory(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if history[0].EventType != model.EventReverted || history[1].EventType != model.EventReverted {
+		t.Fatalf("expected reverted events, got %q and %q", history[0].EventType, history[1].EventType)
+	}
+	if change, ok := history[1].Change(model.FieldDescription); !ok || change.Old != "oops" || change.New != "careful notes" {
+		t.Fatalf("expected description change in reverted event, got %+v", history[1].Changes)
+	}
+
+	var revertErr *RevertError
+	if _, err := store.RevertTask(ctx, created.ID, first.ID, "color"); !errors.As(err, &revertErr) {
+		t.Fatalf("expected RevertError for an unknown field, got %v", err)
+	}
+	if _, err := store.RevertTask(ctx, created.ID, 9999); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows for an unknown entry, got %v", err)
+	}
+	if _, err := store.DB.ExecContext(ctx, "INSERT INTO task_history (task_id, event_type, details) VALUES (?, 'updated', 'legacy text')", created.ID); err != nil {
+		t.Fatalf("insert legacy entry: %v", err)
+	}
+	if _, err := store.RevertTask(ctx, created.ID, first.ID); !errors.As(err, &revertErr) {
+		t.Fatalf("expected RevertError past a legacy entry, got %v", err)
+	}
+}
+
+func TestDeadlineAtMidnightUTCKeepsItsTime(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	deadline := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	created, err := store.CreateTask(ctx, TaskInput{Title: "Submit", DueAt: &deadline})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if created.DueAllDay || !created.DueAt.Equal(deadline) {
+		t.Fatalf("expected a timed deadline, got %v (all day %v)", created.DueAt, created.DueAllDay)
+	}
+
+	day := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	updated, err := store.UpdateTask(ctx, created.ID, TaskInput{Title: "Submit", DueAt: &day, DueAllDay: true})
+	if err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	if !updated.DueAllDay || !updated.DueAt.Equal(time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected an all-day date stored as midnight UTC, got %v", updated.DueAt)
+	}
+
+	history, err := store.ListHistory(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if change, ok := history[0].Change(model.FieldDue); !ok || change.Old != "2026-01-15T00:00:00Z" || change.New != "2026-01-15" {
+		t.Fatalf("expected the change from a deadline to a day in history, got %+v", history[0].Changes)
+	}
+	reverted, err := store.RevertTask(ctx, created.ID, history[1].ID, model.FieldDue)
+	if err != nil {
+		t.Fatalf("revert due: %v", err)
+	}
+	if reverted.DueAllDay || !reverted.DueAt.Equal(deadline) {
+		t.Fatalf("expected the timed deadline back, got %v (all day %v)", reverted.DueAt, reverted.DueAllDay)
+	}
+}
+
+func TestBlockersRejectCyclesAndFilterReadyTasks(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	create := func(title string) model.Task {
+		task, err := store.CreateTask(ctx, TaskInput{Title: title})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		return task
+	}
+	design := create("Design")
+	build := create("Build")
+	ship := create("Ship")
+
+	if err := store.AddBlocker(ctx, build.ID, design.ID); err != nil {
+		t.Fatalf("add blocker: %v", err)
+	}
+	if err := store.AddBlocker(ctx, ship.ID, build.ID); err != nil {
+		t.Fatalf("add blocker: %v", err)
+	}
+	var depErr *DependencyError
+	if err := store.AddBlocker(ctx, design.ID, ship.ID); !errors.As(err, &depErr) {
+		t.Fatalf("expected a cycle to be rejected, got %v", err)
+	}
+	if err := store.AddBlocker(ctx, design.ID, design.ID); !errors.As(err, &depErr) {
+		t.Fatalf("expected a self dependency to be rejected, got %v", err)
+	}
+
+	titles := func(query string) string {
+		tasks, err := store.ListTasks(ctx, model.Filter{Query: query})
+		if err != nil {
+			t.Fatalf("%q: %v", query, err)
+		}
+		names := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			names = append(names, task.Title)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+	if got := titles("is:ready"); got != "Design" {
+		t.Fatalf("expected only Design to be ready, got %q", got)
+	}
+	if got := titles("is:blocked"); got != "Build,Ship" {
+		t.Fatalf("expected Build and Ship to be blocked, got %q", got)
+	}
+
+	input := InputFromTask(design)
+	input.Status = "done"
+	if _, err := store.UpdateTask(ctx, design.ID, input); err != nil {
+		t.Fatalf("complete task: %v", err)
+	}
+	if got := titles("is:ready"); got != "Build" {
+		t.Fatalf("expected Build to become ready, got %q", got)
+	}
+
+	blockers, err := store.ListBlockers(ctx, ship.ID)
+	if err != nil || len(blockers) != 1 || blockers[0].ID != build.ID {
+		t.Fatalf("expected Ship to be blocked by Build, got %+v (%v)", blockers, err)
+	}
+	dependents, err := store.ListDependents(ctx, design.ID)
+	if err != nil || len(dependents) != 1 || dependents[0].ID != build.ID {
+		t.Fatalf("expected Design to block Build, got %+v (%v)", dependents, err)
+	}
+
+	history, err := store.ListHistory(ctx, ship.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	change, ok := history[0].Change(model.FieldBlockedBy)
+	if !ok || change.Old != "" || change.New != strconv.FormatInt(build.ID, 10) {
+		t.Fatalf("expected the blocker in history, got %+v", history[0])
+	}
+
+	if err := store.DeleteTask(ctx, build.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	if got := titles("is:ready"); got != "Ship" {
+		t.Fatalf("expected a trashed blocker not to block, got %q", got)
+	}
+	edges, err := store.ListDependencies(ctx)
+	if err != nil || len(edges) != 0 {
+		t.Fatalf("expected edges to trashed tasks to be hidden, got %+v (%v)", edges, err)
+	}
+
+	if err := store.RemoveBlocker(ctx, ship.ID, build.ID); err != nil {
+		t.Fatalf("remove blocker: %v", err)
+	}
+	if err := store.RemoveBlocker(ctx, ship.ID, build.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected removing a missing blocker to fail with ErrNoRows, got %v", err)
+	}
+}
+
+func TestTimeEntriesFollowDoingStatus(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, TaskInput{Title: "Write report", Status: "doing"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	entries, err := store.ListTimeEntries(ctx, task.ID)
+	if err != nil || len(entries) != 1 || !entries[0].Running() {
+		t.Fatalf("expected a running entry for a doing task, got %+v (%v)", entries, err)
+	}
+	if again, err := store.StartTimer(ctx, task.ID); err != nil || again.ID != entries[0].ID {
+		t.Fatalf("expected starting a running timer to return it, got %+v (%v)", again, err)
+	}
+
+	input := InputFromTask(task)
+	input.Status = "todo"
+	if _, err := store.UpdateTask(ctx, task.ID, input); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	if _, err := store.StopTimer(ctx, task.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected leaving doing to stop the timer, got %v", err)
+	}
+
+	start := time.Now().Add(-3 * time.Hour)
+	end := start.Add(90 * time.Minute)
+	if _, err := store.AdjustTimeEntry(ctx, entries[0].ID, end, &start); !errors.As(err, new(*TimeEntryError)) {
+		t.Fatalf("expected an entry ending before it starts to be rejected, got %v", err)
+	}
+	if _, err := store.AdjustTimeEntry(ctx, entries[0].ID, start, &end); err != nil {
+		t.Fatalf("adjust entry: %v", err)
+	}
+	total, running, err := store.TrackedTime(ctx, task.ID, time.Now())
+	if err != nil || running || total.Round(time.Second) != 90*time.Minute {
+		t.Fatalf("expected 1h30m tracked, got %v running=%v (%v)", total, running, err)
+	}
+
+	since := start.Add(time.Hour)
+	recent, err := store.ListTimeEntriesSince(ctx, since)
+	if err != nil || len(recent) != 1 || recent[0].Within(since, time.Now()).Round(time.Second) != 30*time.Minute {
+		t.Fatalf("expected the last 30 minutes of the entry since %v, got %+v (%v)", since, recent, err)
+	}
+	if model.FormatDuration(total) != "1h30m" {
+		t.Fatalf("unexpected formatted duration %q", model.FormatDuration(total))
+	}
+}
+
 func TestNestedTasksKeepChildrenOnDelete(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -83,12 +678,564 @@ func TestNestedTasksKeepChildrenOnDelete(t *testing.T) {
 	if err != nil {
 		t.Fatalf("get child after parent delete: %v", err)
 	}
+	if reloaded.ParentTaskID == nil || *reloaded.ParentTaskID != parent.ID {
+		t.Fatalf("expected child to stay linked to the trashed parent, got %v", reloaded.ParentTaskID)
+	}
+
+	if err := store.PurgeTask(context.Background(), parent.ID); err != nil {
+		t.Fatalf("purge parent: %v", err)
+	}
+	reloaded, err = store.GetTaskWithTags(context.Background(), child.ID)
+	if err != nil {
+		t.Fatalf("get child after parent purge: %v", err)
+	}
 	if reloaded.ParentTaskID != nil {
-		t.Fatalf("expected child.ParentTaskID to be nil after parent delete")
+		t.Fatalf("expected child.ParentTaskID to be nil after parent purge")
+	}
+}
+
+func TestValidationRejectsBadInput(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	parent, err := store.CreateTask(ctx, TaskInput{Title: "Parent"})
+	if err != nil {
+		t.Fatalf("create parent: %v", err)
+	}
+	child, err := store.CreateTask(ctx, TaskInput{Title: "Child", ParentTaskID: &parent.ID})
+	if err != nil {
+		t.Fatalf("create child: %v", err)
+	}
+
+	missing := int64(99)
+	cases := []struct {
+		name   string
+		taskID int64
+		input  TaskInput
+	}{
+		{"empty title", 0, TaskInput{Title: "  "}},
+		{"unknown status", 0, TaskInput{Title: "Task", Status: "blocked"}},
+		{"bad recurrence", 0, TaskInput{Title: "Task", Recurrence: "fortnightly"}},
+		{"missing parent", 0, TaskInput{Title: "Task", ParentTaskID: &missing}},
+		{"own parent", parent.ID, TaskInput{Title: "Parent", ParentTaskID: &parent.ID}},
+		{"under subtask", parent.ID, TaskInput{Title: "Parent", ParentTaskID: &child.ID}},
+	}
+	for _, tc := range cases {
+		if tc.taskID == 0 {
+			_, err = store.CreateTask(ctx, tc.input)
+		} else {
+			_, err = store.UpdateTask(ctx, tc.taskID, tc.input)
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("%s: expected a validation error, got %v", tc.name, err)
+		}
+	}
+
+	if err := store.DeleteTask(ctx, parent.ID); err != nil {
+		t.Fatalf("delete parent: %v", err)
+	}
+	if _, err := store.UpdateTask(ctx, child.ID, TaskInput{Title: "Renamed", ParentTaskID: &parent.ID}); err != nil {
+		t.Fatalf("expected a task under a trashed parent to stay editable, got %v", err)
+	}
+}
+
+func TestAPITokensStoreOnlyAHash(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	token, secret, err := store.CreateAPIToken(ctx, "ci", model.ScopeRead)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	var stored string
+	if err := store.DB.QueryRowContext(ctx, "SELECT token_hash FROM api_tokens WHERE id = ?", token.ID).Scan(&stored); err != nil {
+		t.Fatalf("read token hash: %v", err)
+	}
+	if stored == secret || strings.Contains(stored, strings.TrimPrefix(secret, tokenPrefix)) {
+		t.Fatalf("expected only a hash of the secret to be stored, got %q", stored)
+	}
+
+	authenticated, err := store.AuthenticateToken(ctx, secret)
+	if err != nil || authenticated.ID != token.ID || authenticated.CanWrite() {
+		t.Fatalf("expected the secret to authenticate the read token, got %+v (%v)", authenticated, err)
+	}
+	if _, err := store.AuthenticateToken(ctx, secret+"x"); err != sql.ErrNoRows {
+		t.Fatalf("expected a wrong secret to be rejected, got %v", err)
+	}
+}
+
+func TestChangesArePublishedOnCommit(t *testing.T) {
+	base, cleanup := newTestStore(t)
+	defer cleanup()
+	store := base.WithOrigin(Origin{Actor: "ana", Source: SourceWeb})
+	ctx := context.Background()
+
+	events, stop := base.Subscribe()
+	defer stop()
+
+	err := store.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.CreateTask(ctx, TaskInput{Title: "Rolled back"}); err != nil {
+			return err
+		}
+		select {
+		case event := <-events:
+			t.Fatalf("expected nothing to be published before the commit, got %+v", event)
+		default:
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatalf("expected the transaction to fail")
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("expected nothing to be published for a rolled back change, got %+v", event)
+	default:
+	}
+
+	created, err := store.CreateTask(ctx, TaskInput{Title: "Published"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	task, history := <-events, <-events
+	if task.Kind != EventTask || task.Action != model.EventCreated || task.TaskID != created.ID || task.Actor != "ana" || task.Source != SourceWeb {
+		t.Fatalf("unexpected task event %+v", task)
+	}
+	if history.Kind != EventHistory || history.TaskID != created.ID || history.HistoryID == 0 {
+		t.Fatalf("unexpected history event %+v", history)
+	}
+
+	stop()
+	if _, ok := <-events; ok {
+		t.Fatalf("expected the channel to close when the subscription ends")
+	}
+	if _, err := store.UpdateTask(ctx, created.ID, TaskInput{Title: "After unsubscribing"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+}
+
+func TestSlowSubscribersAreDropped(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	slow, stopSlow := store.Subscribe()
+	defer stopSlow()
+	fast, stopFast := store.Subscribe()
+	defer stopFast()
+
+	// Each task publishes a task and a history event.
+	received := 0
+	for i := 0; i < eventBufferSize; i++ {
+		if _, err := store.CreateTask(ctx, TaskInput{Title: fmt.Sprintf("Task %d", i)}); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		for len(fast) > 0 {
+			<-fast
+			received++
+		}
+	}
+	if received != 2*eventBufferSize {
+		t.Fatalf("expected a subscriber keeping up to get every event, got %d", received)
+	}
+
+	drained := 0
+	for range slow {
+		drained++
+	}
+	if drained != eventBufferSize {
+		t.Fatalf("expected the slow subscriber to get a full buffer before its channel closed, got %d", drained)
+	}
+}
+
+func TestTrashKeepsHistoryUntilPurged(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	kept, err := store.CreateTask(ctx, TaskInput{Title: "Kept", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create kept: %v", err)
+	}
+	old, err := store.CreateTask(ctx, TaskInput{Title: "Old"})
+	if err != nil {
+		t.Fatalf("create old: %v", err)
+	}
+	for _, id := range []int64{kept.ID, old.ID} {
+		if err := store.DeleteTask(ctx, id); err != nil {
+			t.Fatalf("delete %d: %v", id, err)
+		}
+	}
+
+	tasks, err := store.ListTasks(ctx, model.Filter{Query: "kept OR old"})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("expected deleted tasks to be hidden, got %d", len(tasks))
+	}
+	if _, err := store.GetTaskWithTags(ctx, kept.ID); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows for a trashed task, got %v", err)
+	}
+	if err := store.DeleteTask(ctx, kept.ID); err != sql.ErrNoRows {
+		t.Fatalf("expected deleting a trashed task to fail with sql.ErrNoRows, got %v", err)
+	}
+
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(trash) != 2 || trash[0].DeletedAt == nil {
+		t.Fatalf("expected 2 trashed tasks with deleted_at, got %+v", trash)
+	}
+
+	history, err := store.ListHistory(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if len(history) != 2 || history[0].EventType != "deleted" {
+		t.Fatalf("expected created and deleted history entries, got %+v", history)
+	}
+
+	restored, err := store.RestoreFromTrash(ctx, kept.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.DeletedAt != nil || model.FieldValue(restored, model.FieldTags) != "work" {
+		t.Fatalf("expected restored task with its tags, got %+v", restored)
+	}
+	if _, err := store.RestoreFromTrash(ctx, kept.ID); err == nil {
+		t.Fatalf("expected restoring a task outside the trash to fail")
+	}
+	if err := store.PurgeTask(ctx, kept.ID); err == nil {
+		t.Fatalf("expected purging a task outside the trash to fail")
+	}
+
+	purged, err := store.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("purge with old cutoff: %v", err)
+	}
+	if purged != 0 {
+		t.Fatalf("expected nothing older than the cutoff, purged %d", purged)
+	}
+	purged, err = store.PurgeTrash(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("expected 1 purged task, got %d", purged)
+	}
+	history, err = store.ListHistory(ctx, old.ID)
+	if err != nil {
+		t.Fatalf("list purged history: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("expected purged task history to be removed, got %d entries", len(history))
+	}
+}
+
+func TestRestoreTaskReinsertsWithOriginalIDTagsAndChildren(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	parent, err := store.CreateTask(context.Background(), TaskInput{Title: "Parent", Tags: []string{"work", "home"}, Priority: 2})
+	if err != nil {
+		t.Fatalf("create parent: %v", err)
+	}
+	child, err := store.CreateTask(context.Background(), TaskInput{Title: "Child", ParentTaskID: &parent.ID})
+	if err != nil {
+		t.Fatalf("create child: %v", err)
+	}
+
+	snap, err := store.SnapshotTask(context.Background(), parent.ID)
+	if err != nil {
+		t.Fatalf("snapshot parent: %v", err)
+	}
+	if len(snap.ChildIDs) != 1 || snap.ChildIDs[0] != child.ID {
+		t.Fatalf("expected snapshot children [%d], got %v", child.ID, snap.ChildIDs)
+	}
+	if err := store.DeleteTask(context.Background(), parent.ID); err != nil {
+		t.Fatalf("delete parent: %v", err)
+	}
+	if err := store.PurgeTask(context.Background(), parent.ID); err != nil {
+		t.Fatalf("purge parent: %v", err)
+	}
+
+	restored, err := store.RestoreTask(context.Background(), snap)
+	if err != nil {
+		t.Fatalf("restore parent: %v", err)
+	}
+	if restored.ID != parent.ID || restored.Title != "Parent" || restored.Priority != 2 {
+		t.Fatalf("expected parent restored as #%d, got %+v", parent.ID, restored)
+	}
+	if !restored.CreatedAt.Equal(parent.CreatedAt) {
+		t.Fatalf("expected created_at %v, got %v", parent.CreatedAt, restored.CreatedAt)
+	}
+	if model.FieldValue(restored, model.FieldTags) != "home,work" {
+		t.Fatalf("expected tags restored, got %q", model.FieldValue(restored, model.FieldTags))
+	}
+
+	reloaded, err := store.GetTaskWithTags(context.Background(), child.ID)
+	if err != nil {
+		t.Fatalf("get child: %v", err)
+	}
+	if reloaded.ParentTaskID == nil || *reloaded.ParentTaskID != parent.ID {
+		t.Fatalf("expected child re-attached to %d, got %v", parent.ID, reloaded.ParentTaskID)
+	}
+
+	history, err := store.ListHistory(context.Background(), parent.ID)
+	if err != nil {
+		t.Fatalf("list history: %v", err)
+	}
+	if len(history) == 0 || history[0].EventType != "restored" {
+		t.Fatalf("expected a restored history entry, got %+v", history)
+	}
+
+	// Restoring over an existing task updates it in place.
+	if _, err := store.UpdateTask(context.Background(), parent.ID, TaskInput{Title: "Renamed"}); err != nil {
+		t.Fatalf("update parent: %v", err)
+	}
+	restored, err = store.RestoreTask(context.Background(), snap)
+	if err != nil {
+		t.Fatalf("restore existing parent: %v", err)
+	}
+	if restored.Title != "Parent" || len(restored.Tags) != 2 {
+		t.Fatalf("expected title and tags reverted, got %+v", restored)
+	}
+}
+
+func TestCreateTaskRollsBackOnHistoryFailure(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if _, err := store.DB.Exec(`CREATE TRIGGER fail_history BEFORE INSERT ON task_history
+BEGIN
+  SELECT RAISE(ABORT, 'history unavailable');
+END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	if _, err := store.CreateTask(context.Background(), TaskInput{Title: "Doomed", Tags: []string{"Work"}}); err == nil {
+		t.Fatalf("expected create to fail")
+	}
+
+	tasks, err := store.ListTasks(context.Background(), model.Filter{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("expected no tasks after rollback, got %d", len(tasks))
+	}
+	tags, err := store.ListTags(context.Background())
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("expected no tags after rollback, got %d", len(tags))
+	}
+}
+
+func TestWithTxGroupsOperations(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	errAbort := errors.New("abort")
+	err := store.WithTx(context.Background(), func(tx *Store) error {
+		parent, err := tx.CreateTask(context.Background(), TaskInput{Title: "Parent"})
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateTask(context.Background(), TaskInput{Title: "Child", ParentTaskID: &parent.ID}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+
+	tasks, err := store.ListTasks(context.Background(), model.Filter{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Fatalf("expected grouped creates to roll back, got %d tasks", len(tasks))
+	}
+
+	if err := store.WithTx(context.Background(), func(tx *Store) error {
+		_, err := tx.CreateTask(context.Background(), TaskInput{Title: "Kept"})
+		return err
+	}); err != nil {
+		t.Fatalf("with tx: %v", err)
+	}
+	tasks, err = store.ListTasks(context.Background(), model.Filter{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected committed task, got %d tasks", len(tasks))
+	}
+}
+
+func TestListTasksComposesTagFilter(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	inputs := []TaskInput{
+		{Title: "Write report", Status: "doing", Tags: []string{"Work", "Urgent"}, DueAt: &due},
+		{Title: "Review report", Status: "todo", Tags: []string{"Work"}},
+		{Title: "Fix sink", Status: "doing", Tags: []string{"Home", "Urgent"}},
+		{Title: "Call plumber", Status: "doing"},
+	}
+	for _, input := range inputs {
+		if _, err := store.CreateTask(context.Background(), input); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	dueBefore := due.AddDate(0, 0, 1)
+	cases := []struct {
+		name   string
+		filter model.Filter
+		want   []string
+	}{
+		{name: "tags with status", filter: model.Filter{Status: "doing", Tags: []string{"Work"}}, want: []string{"Write report"}},
+		{name: "tags with due", filter: model.Filter{Tags: []string{"Urgent"}, DueBefore: &dueBefore}, want: []string{"Write report"}},
+		{name: "tags with query", filter: model.Filter{Query: "report", Tags: []string{"Work"}, Status: "todo"}, want: []string{"Review report"}},
+		{name: "any of", filter: model.Filter{Tags: []string{"Work", "Home"}, TagMatch: model.TagMatchAny}, want: []string{"Fix sink", "Review report", "Write report"}},
+		{name: "all of", filter: model.Filter{Tags: []string{"Work", "Urgent"}, TagMatch: model.TagMatchAll}, want: []string{"Write report"}},
+		{name: "all of with duplicates", filter: model.Filter{Tags: []string{"Work", "Work"}, TagMatch: model.TagMatchAll}, want: []string{"Review report", "Write report"}},
+		{name: "all of with status", filter: model.Filter{Status: "doing", Tags: []string{"Home", "Urgent"}, TagMatch: model.TagMatchAll}, want: []string{"Fix sink"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tasks, err := store.ListTasks(context.Background(), tc.filter)
+			if err != nil {
+				t.Fatalf("list tasks: %v", err)
+			}
+			got := make([]string, 0, len(tasks))
+			for _, task := range tasks {
+				got = append(got, task.Title)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if _, err := store.ListTasks(context.Background(), model.Filter{Tags: []string{"Work"}, TagMatch: "some"}); err == nil {
+		t.Fatalf("expected invalid tag match mode to fail")
+	}
+}
+
+func TestActiveViewFollowsSavedViews(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	view, err := store.SaveView(ctx, model.View{Name: " Work ", Filter: model.Filter{Status: "doing", Tags: []string{"Work"}}})
+	if err != nil {
+		t.Fatalf("save view: %v", err)
+	}
+	if view.Name != "Work" {
+		t.Fatalf("expected trimmed view name, got %q", view.Name)
+	}
+	if _, err := store.SaveView(ctx, model.View{Name: "Work"}); err == nil {
+		t.Fatalf("expected duplicate view name to fail")
+	}
+
+	active, err := store.ActiveView(ctx)
+	if err != nil {
+		t.Fatalf("active view: %v", err)
+	}
+	if active != nil {
+		t.Fatalf("expected no active view, got %v", active)
+	}
+
+	if err := store.SetActiveView(ctx, view.ID); err != nil {
+		t.Fatalf("set active view: %v", err)
+	}
+	view.Name = "Office"
+	if _, err := store.SaveView(ctx, view); err != nil {
+		t.Fatalf("rename view: %v", err)
+	}
+	active, err = store.ActiveView(ctx)
+	if err != nil {
+		t.Fatalf("active view: %v", err)
+	}
+	if active == nil || active.Name != "Office" || active.Filter.Status != "doing" {
+		t.Fatalf("expected renamed active view, got %v", active)
+	}
+
+	if err := store.DeleteView(ctx, view.ID); err != nil {
+		t.Fatalf("delete view: %v", err)
+	}
+	active, err = store.ActiveView(ctx)
+	if err != nil {
+		t.Fatalf("active view: %v", err)
+	}
+	if active != nil {
+		t.Fatalf("expected deleted view to no longer be active, got %v", active)
+	}
+}
+
+func BenchmarkListTasks(b *testing.B) {
+	for _, size := range []int{10_000, 100_000} {
+		b.Run(fmt.Sprintf("%dk", size/1000), func(b *testing.B) {
+			db, err := Open(":memory:")
+			if err != nil {
+				b.Fatalf("open db: %v", err)
+			}
+			defer db.Close()
+			store := NewStore(db)
+			seedTasks(b, store, size)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				tasks, err := store.ListTasks(context.Background(), model.Filter{})
+				if err != nil {
+					b.Fatalf("list tasks: %v", err)
+				}
+				if len(tasks) != size {
+					b.Fatalf("expected %d tasks, got %d", size, len(tasks))
+				}
+			}
+		})
+	}
+}
+
+// seedTasks bulk-inserts count tasks with two tags each, bypassing the
+// per-task history writes of CreateTask.
+func seedTasks(tb testing.TB, store *Store, count int) {
+	tb.Helper()
+	err := store.WithTx(context.Background(), func(tx *Store) error {
+		statements := []string{
+			`WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < 20)
+INSERT INTO tags (name) SELECT 'tag-' || n FROM seq`,
+			fmt.Sprintf(`WITH RECURSIVE seq(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < %d)
+INSERT INTO tasks (title, description, status, priority)
+SELECT 'Task ' || n, 'Description for task ' || n, CASE n %% 4 WHEN 0 THEN 'done' WHEN 1 THEN 'doing' ELSE 'todo' END, n %% 5 FROM seq`, count),
+			`INSERT INTO task_tags (task_id, tag_id) SELECT id, 1 + id % 20 FROM tasks`,
+			`INSERT INTO task_tags (task_id, tag_id) SELECT id, 1 + (id + 7) % 20 FROM tasks`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.tx.ExecContext(context.Background(), statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("seed tasks: %v", err)
 	}
 }
 
-func newTestStore(t *testing.T) (*Store, func()) {
+func newTestStore(t testing.TB) (*Store, func()) {
 	t.Helper()
 	db, err := Open(":memory:")
 	if err != nil {
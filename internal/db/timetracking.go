@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// TimeEntryError reports a time entry change that cannot be carried out, such
+// as one that would end an entry before it starts.
+type TimeEntryError struct {
+	Msg string
+}
+
+func (e *TimeEntryError) Error() string {
+	return e.Msg
+}
+
+// StartTimer starts a time entry for a task, or returns the one already
+// running.
+func (s *Store) StartTimer(ctx context.Context, taskID int64) (model.TimeEntry, error) {
+	var entry model.TimeEntry
+	err := s.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.GetTaskWithTags(ctx, taskID); err != nil {
+			return err
+		}
+		var err error
+		entry, err = tx.startTimer(ctx, taskID)
+		return err
+	})
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return entry, nil
+}
+
+func (s *Store) startTimer(ctx context.Context, taskID int64) (model.TimeEntry, error) {
+	row, err := s.Queries.GetRunningTimeEntry(ctx, taskID)
+	if err == nil {
+		return mapTimeEntry(row), nil
+	}
+	if err != sql.ErrNoRows {
+		return model.TimeEntry{}, err
+	}
+	row, err = s.Queries.CreateTimeEntry(ctx, sqlc.CreateTimeEntryParams{TaskID: taskID, StartedAt: time.Now().UTC()})
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return mapTimeEntry(row), nil
+}
+
+// StopTimer stops the running time entry of a task, or returns sql.ErrNoRows
+// if none is running.
+func (s *Store) StopTimer(ctx context.Context, taskID int64) (model.TimeEntry, error) {
+	row, err := s.Queries.GetRunningTimeEntry(ctx, taskID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	row, err = s.Queries.UpdateTimeEntry(ctx, sqlc.UpdateTimeEntryParams{
+		StartedAt: row.StartedAt,
+		EndedAt:   sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        row.ID,
+	})
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return mapTimeEntry(row), nil
+}
+
+// AdjustTimeEntry sets when an entry started and ended; a nil end leaves it
+// running. The entry must not end before it starts or start in the future.
+func (s *Store) AdjustTimeEntry(ctx context.Context, entryID int64, start time.Time, end *time.Time) (model.TimeEntry, error) {
+	if start.After(time.Now()) {
+		return model.TimeEntry{}, &TimeEntryError{Msg: "a time entry cannot start in the future"}
+	}
+	var endedAt sql.NullTime
+	if end != nil {
+		if end.Before(start) {
+			return model.TimeEntry{}, &TimeEntryError{Msg: "a time entry cannot end before it starts"}
+		}
+		endedAt = sql.NullTime{Time: end.UTC(), Valid: true}
+	}
+
+	var entry model.TimeEntry
+	err := s.WithTx(ctx, func(tx *Store) error {
+		current, err := tx.Queries.GetTimeEntry(ctx, entryID)
+		if err != nil {
+			return err
+		}
+		if !endedAt.Valid && current.EndedAt.Valid {
+			if _, err := tx.Queries.GetRunningTimeEntry(ctx, current.TaskID); err == nil {
+				return &TimeEntryError{Msg: fmt.Sprintf("task %d already has a running time entry", current.TaskID)}
+			}
+		}
+		row, err := tx.Queries.UpdateTimeEntry(ctx, sqlc.UpdateTimeEntryParams{StartedAt: start.UTC(), EndedAt: endedAt, ID: entryID})
+		if err != nil {
+			return err
+		}
+		entry = mapTimeEntry(row)
+		return nil
+	})
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return entry, nil
+}
+
+// GetTimeEntry returns a time entry by ID, or sql.ErrNoRows.
+func (s *Store) GetTimeEntry(ctx context.Context, entryID int64) (model.TimeEntry, error) {
+	row, err := s.Queries.GetTimeEntry(ctx, entryID)
+	if err != nil {
+		return model.TimeEntry{}, err
+	}
+	return mapTimeEntry(row), nil
+}
+
+// ListTimeEntries returns the time entries of a task, oldest first.
+func (s *Store) ListTimeEntries(ctx context.Context, taskID int64) ([]model.TimeEntry, error) {
+	rows, err := s.Queries.ListTimeEntriesByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return mapTimeEntries(rows), nil
+}
+
+// ListRunningTimers returns the running time entries of tasks outside the
+// trash.
+func (s *Store) ListRunningTimers(ctx context.Context) ([]model.TimeEntry, error) {
+	rows, err := s.Queries.ListRunningTimeEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mapTimeEntries(rows), nil
+}
+
+// ListTimeEntriesSince returns the entries of tasks outside the trash that
+// were still running at since, oldest first.
+func (s *Store) ListTimeEntriesSince(ctx context.Context, since time.Time) ([]model.TimeEntry, error) {
+	rows, err := s.Queries.ListTimeEntriesSince(ctx, sql.NullTime{Time: since.UTC(), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return mapTimeEntries(rows), nil
+}
+
+// TrackedTime returns the time recorded for a task up to now and whether an
+// entry is running.
+func (s *Store) TrackedTime(ctx context.Context, taskID int64, now time.Time) (time.Duration, bool, error) {
+	entries, err := s.ListTimeEntries(ctx, taskID)
+	if err != nil {
+		return 0, false, err
+	}
+	var total time.Duration
+	running := false
+	for _, entry := range entries {
+		total += entry.Duration(now)
+		running = running || entry.Running()
+	}
+	return total, running, nil
+}
+
+// trackStatus starts a time entry when a task enters "doing" and stops it
+// when the task leaves it.
+func (s *Store) trackStatus(ctx context.Context, taskID int64, before, after string) error {
+	switch {
+	case before != "doing" && after == "doing":
+		_, err := s.startTimer(ctx, taskID)
+		return err
+	case before == "doing" && after != "doing":
+		if _, err := s.StopTimer(ctx, taskID); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+	}
+	return nil
+}
+
+func mapTimeEntries(rows []sqlc.TimeEntry) []model.TimeEntry {
+	entries := make([]model.TimeEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, mapTimeEntry(row))
+	}
+	return entries
+}
+
+func mapTimeEntry(row sqlc.TimeEntry) model.TimeEntry {
+	entry := model.TimeEntry{ID: row.ID, TaskID: row.TaskID, StartedAt: row.StartedAt}
+	if row.EndedAt.Valid {
+		endedAt := row.EndedAt.Time
+		entry.EndedAt = &endedAt
+	}
+	return entry
+}
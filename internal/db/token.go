@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// tokenPrefix marks LazyTask secrets, so they are easy to spot in scripts
+// and secret scanners.
+const tokenPrefix = "lt_"
+
+// CreateAPIToken creates a token and returns it together with its secret,
+// which cannot be read back later. The secret is random, so a fast hash is
+// enough to keep it out of the database.
+func (s *Store) CreateAPIToken(ctx context.Context, name, scope string) (model.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.APIToken{}, "", &ValidationError{Msg: "token name is required"}
+	}
+	if scope != model.ScopeRead && scope != model.ScopeReadWrite {
+		return model.APIToken{}, "", &ValidationError{Msg: fmt.Sprintf("invalid scope %q (want %s or %s)", scope, model.ScopeRead, model.ScopeReadWrite)}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return model.APIToken{}, "", err
+	}
+	secret := tokenPrefix + hex.EncodeToString(raw)
+	row, err := s.Queries.CreateAPIToken(ctx, sqlc.CreateAPITokenParams{Name: name, TokenHash: hashToken(secret), Scope: scope})
+	if err != nil {
+		return model.APIToken{}, "", err
+	}
+	return apiTokenFromRow(row), secret, nil
+}
+
+// AuthenticateToken returns the token with the given secret, or
+// sql.ErrNoRows when there is none.
+func (s *Store) AuthenticateToken(ctx context.Context, secret string) (model.APIToken, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return model.APIToken{}, sql.ErrNoRows
+	}
+	row, err := s.Queries.GetAPITokenByHash(ctx, hashToken(secret))
+	if err != nil {
+		return model.APIToken{}, err
+	}
+	return apiTokenFromRow(row), nil
+}
+
+func (s *Store) ListAPITokens(ctx context.Context) ([]model.APIToken, error) {
+	rows, err := s.Queries.ListAPITokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]model.APIToken, 0, len(rows))
+	for _, row := range rows {
+		tokens = append(tokens, apiTokenFromRow(row))
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken deletes a token, so its secret stops working.
+func (s *Store) RevokeAPIToken(ctx context.Context, id int64) error {
+	removed, err := s.Queries.DeleteAPIToken(ctx, id)
+	if err == nil && removed == 0 {
+		err = sql.ErrNoRows
+	}
+	return err
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiTokenFromRow(row sqlc.ApiToken) model.APIToken {
+	return model.APIToken{ID: row.ID, Name: row.Name, Scope: row.Scope, CreatedAt: row.CreatedAt}
+}
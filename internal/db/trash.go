@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	sqlc "github.com/Joseda-hg/lazytask/internal/db/sqlc"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// ListTrash returns the deleted tasks, most recently deleted first.
+func (s *Store) ListTrash(ctx context.Context) ([]model.Task, error) {
+	rows, err := s.Queries.ListDeletedTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.mapTasks(ctx, rows)
+}
+
+// RestoreFromTrash takes a deleted task out of the trash as it was when it was
+// deleted.
+func (s *Store) RestoreFromTrash(ctx context.Context, taskID int64) (model.Task, error) {
+	var restored model.Task
+	err := s.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.trashedTask(ctx, taskID); err != nil {
+			return err
+		}
+		if err := tx.undeleteTask(ctx, taskID); err != nil {
+			return err
+		}
+		var err error
+		restored, err = tx.GetTaskWithTags(ctx, taskID)
+		return err
+	})
+	if err != nil {
+		return model.Task{}, err
+	}
+	return restored, nil
+}
+
+func (s *Store) undeleteTask(ctx context.Context, taskID int64) error {
+	if err := s.Queries.UndeleteTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	restored, err := s.GetTaskWithTags(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	return s.addHistory(ctx, taskID, model.EventRestored, snapshotChanges(model.EventRestored, restored))
+}
+
+// PurgeTask permanently removes a task from the trash together with its
+// history. Its subtasks become top-level tasks.
+func (s *Store) PurgeTask(ctx context.Context, taskID int64) error {
+	return s.WithTx(ctx, func(tx *Store) error {
+		if _, err := tx.trashedTask(ctx, taskID); err != nil {
+			return err
+		}
+		if err := tx.Queries.DeleteTask(ctx, taskID); err != nil {
+			return err
+		}
+		tx.publish(Event{Kind: EventTask, Action: "purged", TaskID: taskID})
+		return nil
+	})
+}
+
+// PurgeTrash permanently removes the tasks deleted at or before cutoff and
+// returns how many were removed.
+func (s *Store) PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error) {
+	purged, err := s.Queries.PurgeDeletedTasks(ctx, sql.NullTime{Time: cutoff.UTC(), Valid: true})
+	if err == nil && purged > 0 {
+		s.publish(Event{Kind: EventTask, Action: "purged"})
+	}
+	return purged, err
+}
+
+func (s *Store) trashedTask(ctx context.Context, taskID int64) (sqlc.Task, error) {
+	row, err := s.Queries.GetTask(ctx, taskID)
+	if err != nil {
+		return sqlc.Task{}, err
+	}
+	if !row.DeletedAt.Valid {
+		return sqlc.Task{}, fmt.Errorf("task %d is not in the trash", taskID)
+	}
+	return row, nil
+}
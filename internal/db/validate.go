@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// ValidationError reports task input the store refuses to save, such as an
+// empty title, an unknown status or a parent that would make a cycle.
+type ValidationError struct {
+	Msg string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Msg
+}
+
+// validateInput checks input for the task taskID, or for a new task when
+// taskID is 0. The parent is only checked when it differs from
+// parentBefore, so that tasks under a trashed parent can still be edited.
+func (s *Store) validateInput(ctx context.Context, taskID int64, input TaskInput, parentBefore *int64) error {
+	if strings.TrimSpace(input.Title) == "" {
+		return &ValidationError{Msg: "title is required"}
+	}
+	if status := normalizeStatus(input.Status); !slices.Contains(model.Statuses, status) {
+		return &ValidationError{Msg: fmt.Sprintf("invalid status %q (want %s)", input.Status, strings.Join(model.Statuses, ", "))}
+	}
+	if _, err := model.NormalizeRecurrence(input.Recurrence); err != nil {
+		return &ValidationError{Msg: err.Error()}
+	}
+
+	parentID := input.ParentTaskID
+	if parentID == nil || (parentBefore != nil && *parentBefore == *parentID) {
+		return nil
+	}
+	if _, err := s.GetTaskWithTags(ctx, *parentID); err == sql.ErrNoRows {
+		return &ValidationError{Msg: fmt.Sprintf("parent task %d not found", *parentID)}
+	} else if err != nil {
+		return err
+	}
+
+	// Walk up from the new parent; meeting the task itself means it would
+	// end up under its own subtask.
+	seen := map[int64]bool{}
+	for parentID != nil && !seen[*parentID] {
+		if *parentID == taskID {
+			return &ValidationError{Msg: fmt.Sprintf("task %d cannot be moved under itself or its subtasks", taskID)}
+		}
+		seen[*parentID] = true
+		parent, err := s.Queries.GetTask(ctx, *parentID)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		parentID = nil
+		if parent.ParentTaskID.Valid {
+			parentID = &parent.ParentTaskID.Int64
+		}
+	}
+	return nil
+}
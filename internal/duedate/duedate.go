@@ -0,0 +1,283 @@
+// Package duedate resolves due dates written the way people type them, such
+// as "tomorrow 14:00", "fri", "+3d" or "end of month".
+package duedate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Due is a resolved due date. Date is in the location of the reference time
+// passed to Parse, at midnight unless HasTime is set.
+type Due struct {
+	Date    time.Time
+	HasTime bool
+}
+
+// Instant returns the due date in the form it is stored: all-day dates as
+// midnight UTC of their calendar day, timed ones as the instant itself.
+func (d Due) Instant() time.Time {
+	if d.HasTime {
+		return d.Date.UTC()
+	}
+	year, month, day := d.Date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// FromInstant reads back a due date stored by Instant, in loc. allDay tells
+// the two forms apart, as a timed due date may fall at midnight UTC too.
+func FromInstant(t time.Time, allDay bool, loc *time.Location) Due {
+	if allDay {
+		year, month, day := t.UTC().Date()
+		return Due{Date: time.Date(year, month, day, 0, 0, 0, 0, loc)}
+	}
+	return Due{Date: t.In(loc), HasTime: true}
+}
+
+// Format renders a stored due date in loc, such as "2026-11-01" or
+// "2026-11-01 14:00". Parse reads it back given a time in loc.
+func Format(t time.Time, allDay bool, loc *time.Location) string {
+	return FromInstant(t, allDay, loc).Short()
+}
+
+// Day returns the calendar day of the due date stored as an all-day date,
+// for filters that compare by day.
+func (d Due) Day() time.Time {
+	return Due{Date: d.Date}.Instant()
+}
+
+// Short renders the due date as "2006-01-02" or "2006-01-02 15:04".
+func (d Due) Short() string {
+	if d.HasTime {
+		return d.Date.Format("2006-01-02 15:04")
+	}
+	return d.Date.Format("2006-01-02")
+}
+
+// String renders the due date for previews, such as "Fri 2026-10-23" or
+// "Fri 2026-10-23 14:00".
+func (d Due) String() string {
+	if d.HasTime {
+		return d.Date.Format("Mon 2006-01-02 15:04")
+	}
+	return d.Date.Format("Mon 2006-01-02")
+}
+
+// Parse resolves a due date relative to now. It accepts:
+//
+//   - dates: 2026-11-01, today, tomorrow, yesterday
+//   - weekdays: mon .. sun (or monday .. sunday, optionally after "next"),
+//     meaning the next such day after today
+//   - next week, next month, next year: the first day of the period
+//   - end of week, end of month, end of year (or eow, eom, eoy): its last day
+//   - offsets: +3d, +2w, +1m, +1y, -1d, or "in 3 days", "in 2 weeks",
+//     "in a month"
+//
+// optionally followed by a time of day such as "14:00", "2pm" or "at 9:30am".
+// A time on its own means today. RFC 3339 times such as
+// "2026-11-01T14:00:00Z" are accepted too.
+func Parse(value string, now time.Time) (Due, error) {
+	if instant, err := time.Parse(time.RFC3339, strings.TrimSpace(value)); err == nil {
+		return Due{Date: instant.In(now.Location()), HasTime: true}, nil
+	}
+	words := strings.Fields(strings.ToLower(value))
+	if len(words) == 0 {
+		return Due{}, fmt.Errorf("missing due date")
+	}
+
+	hour, minute, hasTime := parseClock(words[len(words)-1])
+	if hasTime {
+		words = words[:len(words)-1]
+		if len(words) > 0 && words[len(words)-1] == "at" {
+			words = words[:len(words)-1]
+		}
+	}
+
+	date, ok := parseDate(strings.Join(words, " "), now)
+	if !ok {
+		return Due{}, fmt.Errorf("invalid due date %q", strings.TrimSpace(value))
+	}
+	if hasTime {
+		date = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location())
+	}
+	return Due{Date: date, HasTime: hasTime}, nil
+}
+
+// parseDate resolves the date part of an expression to midnight of a day in
+// now's location; an empty phrase means today.
+func parseDate(phrase string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch phrase {
+	case "", "today", "tod":
+		return today, true
+	case "tomorrow", "tmr", "tom":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "next week":
+		return startOfWeek(today).AddDate(0, 0, 7), true
+	case "next month":
+		return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), true
+	case "next year":
+		return time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location()), true
+	case "end of week", "eow":
+		return startOfWeek(today).AddDate(0, 0, 6), true
+	case "end of month", "eom":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), true
+	case "end of year", "eoy":
+		return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()), true
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", phrase, today.Location()); err == nil {
+		return date, true
+	}
+
+	if weekday, ok := parseWeekday(strings.TrimPrefix(phrase, "next ")); ok {
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), true
+	}
+
+	if amount, unit, ok := parseOffset(phrase); ok {
+		return shift(today, amount, unit), true
+	}
+	return time.Time{}, false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+func parseWeekday(word string) (time.Weekday, bool) {
+	weekday, ok := weekdays[word]
+	return weekday, ok
+}
+
+// parseOffset reads "+3d", "-1w", "in 2 weeks" or "in a month" as an amount
+// of a unit: 'd', 'w', 'm' or 'y'.
+func parseOffset(phrase string) (int, byte, bool) {
+	if rest, ok := strings.CutPrefix(phrase, "in "); ok {
+		fields := strings.Fields(rest)
+		if len(fields) != 2 {
+			return 0, 0, false
+		}
+		amount := 1
+		if fields[0] != "a" && fields[0] != "an" {
+			parsed, err := strconv.Atoi(fields[0])
+			if err != nil || parsed < 0 {
+				return 0, 0, false
+			}
+			amount = parsed
+		}
+		unit, ok := parseUnit(fields[1])
+		return amount, unit, ok
+	}
+
+	if len(phrase) < 3 || (phrase[0] != '+' && phrase[0] != '-') {
+		return 0, 0, false
+	}
+	amount, err := strconv.Atoi(phrase[1 : len(phrase)-1])
+	if err != nil || amount < 0 {
+		return 0, 0, false
+	}
+	if phrase[0] == '-' {
+		amount = -amount
+	}
+	unit, ok := parseUnit(phrase[len(phrase)-1:])
+	return amount, unit, ok
+}
+
+func parseUnit(word string) (byte, bool) {
+	switch strings.TrimSuffix(word, "s") {
+	case "d", "day":
+		return 'd', true
+	case "w", "week":
+		return 'w', true
+	case "m", "month":
+		return 'm', true
+	case "y", "year":
+		return 'y', true
+	}
+	return 0, false
+}
+
+// shift moves a date by an amount of a unit. Months and years keep the day
+// of the month where it exists and clamp it to the last day otherwise.
+func shift(date time.Time, amount int, unit byte) time.Time {
+	switch unit {
+	case 'd':
+		return date.AddDate(0, 0, amount)
+	case 'w':
+		return date.AddDate(0, 0, 7*amount)
+	case 'y':
+		amount *= 12
+	}
+	first := time.Date(date.Year(), date.Month()+time.Month(amount), 1, 0, 0, 0, 0, date.Location())
+	last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, date.Location()).Day()
+	return first.AddDate(0, 0, min(date.Day(), last)-1)
+}
+
+// parseClock reads a time of day: "14:00", "9:30", "2pm" or "9:30am".
+func parseClock(word string) (int, int, bool) {
+	meridiem := ""
+	for _, suffix := range []string{"am", "pm"} {
+		if rest, ok := strings.CutSuffix(word, suffix); ok {
+			word, meridiem = rest, suffix
+		}
+	}
+
+	hourText, minuteText, hasMinutes := strings.Cut(word, ":")
+	if !hasMinutes && meridiem == "" {
+		return 0, 0, false
+	}
+	hour, err := strconv.Atoi(hourText)
+	if err != nil || hour < 0 || !isDigits(hourText) {
+		return 0, 0, false
+	}
+	minute := 0
+	if hasMinutes {
+		if len(minuteText) != 2 {
+			return 0, 0, false
+		}
+		if minute, err = strconv.Atoi(minuteText); err != nil || minute < 0 || minute > 59 || !isDigits(minuteText) {
+			return 0, 0, false
+		}
+	}
+
+	switch meridiem {
+	case "":
+		if hour > 23 {
+			return 0, 0, false
+		}
+	default:
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	}
+	return hour, minute, true
+}
+
+// isDigits reports whether text is made of ASCII digits only, which
+// strconv.Atoi also accepts with a sign.
+func isDigits(text string) bool {
+	return strings.Trim(text, "0123456789") == ""
+}
+
+func startOfWeek(date time.Time) time.Time {
+	return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+}
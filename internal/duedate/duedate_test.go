@@ -0,0 +1,106 @@
+package duedate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// A Saturday.
+	now := time.Date(2026, time.October, 17, 16, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{input: "today", want: "Sat 2026-10-17"},
+		{input: "Tomorrow", want: "Sun 2026-10-18"},
+		{input: "yesterday", want: "Fri 2026-10-16"},
+		{input: "fri", want: "Fri 2026-10-23"},
+		{input: "sat", want: "Sat 2026-10-24"},
+		{input: "next monday", want: "Mon 2026-10-19"},
+		{input: "next week", want: "Mon 2026-10-19"},
+		{input: "next month", want: "Sun 2026-11-01"},
+		{input: "next year", want: "Fri 2027-01-01"},
+		{input: "end of week", want: "Sun 2026-10-18"},
+		{input: "end of month", want: "Sat 2026-10-31"},
+		{input: "eoy", want: "Thu 2026-12-31"},
+		{input: "+3d", want: "Tue 2026-10-20"},
+		{input: "+2w", want: "Sat 2026-10-31"},
+		{input: "-1d", want: "Fri 2026-10-16"},
+		{input: "+1y", want: "Sun 2027-10-17"},
+		{input: "in 2 weeks", want: "Sat 2026-10-31"},
+		{input: "in a month", want: "Tue 2026-11-17"},
+		{input: "2026-11-01", want: "Sun 2026-11-01"},
+		{input: "tomorrow 14:00", want: "Sun 2026-10-18 14:00"},
+		{input: "fri at 2pm", want: "Fri 2026-10-23 14:00"},
+		{input: "2026-11-01 9:30am", want: "Sun 2026-11-01 09:30"},
+		{input: "12am", want: "Sat 2026-10-17 00:00"},
+		{input: "18:45", want: "Sat 2026-10-17 18:45"},
+		{input: "2026-11-01T12:00:00Z", want: "Sun 2026-11-01 14:00"},
+	}
+	for _, tc := range cases {
+		due, err := Parse(tc.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.input, err)
+			continue
+		}
+		if got := due.String(); got != tc.want {
+			t.Errorf("Parse(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+
+	for _, input := range []string{"", "soon", "fri 25:00", "+3x", "in weeks", "2026-13-01", "13pm", "tomorrow 9:5", "9:-1", "tomorrow 9:+1", "+9:00", "-1:00"} {
+		if _, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q): expected an error", input)
+		}
+	}
+}
+
+func TestEndOfMonthClampsOffsets(t *testing.T) {
+	now := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+	due, err := Parse("+1m", now)
+	if err != nil || due.String() != "Sat 2026-02-28" {
+		t.Fatalf("expected +1m from Jan 31 to clamp to Feb 28, got %q (%v)", due.String(), err)
+	}
+}
+
+func TestInstant(t *testing.T) {
+	zone := time.FixedZone("PDT", -7*60*60)
+	now := time.Date(2026, time.October, 17, 22, 0, 0, 0, zone)
+
+	allDay, _ := Parse("today", now)
+	if got := allDay.Instant(); !got.Equal(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected an all-day date to be stored as its day at midnight UTC, got %v", got)
+	}
+	timed, _ := Parse("today 23:00", now)
+	if got := timed.Instant(); !got.Equal(time.Date(2026, time.October, 18, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a timed date to be stored as its instant in UTC, got %v", got)
+	}
+}
+
+func TestFromInstantKeepsTheCalendarDay(t *testing.T) {
+	zone := time.FixedZone("PDT", -7*60*60)
+
+	allDay := FromInstant(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC), true, zone)
+	if allDay.HasTime || allDay.Short() != "2026-10-17" {
+		t.Fatalf("expected an all-day date to keep its day in any zone, got %q", allDay.Short())
+	}
+	timed := FromInstant(time.Date(2026, time.October, 18, 6, 0, 0, 0, time.UTC), false, zone)
+	if !timed.HasTime || timed.Short() != "2026-10-17 23:00" {
+		t.Fatalf("expected a timed date in the local zone, got %q", timed.Short())
+	}
+	if got := timed.Day(); !got.Equal(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the local calendar day, got %v", got)
+	}
+
+	midnight := FromInstant(time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC), false, zone)
+	if !midnight.HasTime || midnight.Short() != "2026-10-17 17:00" {
+		t.Fatalf("expected a deadline at midnight UTC to keep its time, got %q", midnight.Short())
+	}
+
+	parsed, err := Parse(timed.Short(), time.Date(2026, time.January, 1, 0, 0, 0, 0, zone))
+	if err != nil || !parsed.Instant().Equal(timed.Date) {
+		t.Fatalf("expected Short to round-trip through Parse, got %v (%v)", parsed.Instant(), err)
+	}
+}
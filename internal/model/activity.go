@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartOfDay returns midnight of t's day in t's location.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns midnight of the Monday of t's week.
+func StartOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return StartOfDay(t).AddDate(0, 0, -daysSinceMonday)
+}
+
+// ParseSince parses the start of an activity window relative to now:
+// "today", "yesterday", "week" (since Monday), a number of days such as
+// "3d", a duration such as "12h" or a date such as "2026-11-01".
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "", "today":
+		return StartOfDay(now), nil
+	case "yesterday":
+		return StartOfDay(now).AddDate(0, 0, -1), nil
+	case "week":
+		return StartOfWeek(now), nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if count, err := strconv.Atoi(days); err == nil && count >= 0 {
+			return StartOfDay(now).AddDate(0, 0, -count), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q (use today, yesterday, week, 3d, 12h or YYYY-MM-DD)", value)
+}
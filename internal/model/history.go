@@ -0,0 +1,223 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/duedate"
+)
+
+// History event types.
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventDeleted  = "deleted"
+	EventRestored = "restored"
+	EventReverted = "reverted"
+	// EventRecurred links a completed recurring task to its next occurrence.
+	EventRecurred = "recurred"
+)
+
+// Task fields recorded in HistoryChange.Field.
+const (
+	FieldTitle       = "title"
+	FieldDescription = "description"
+	FieldStatus      = "status"
+	FieldPriority    = "priority"
+	FieldDue         = "due"
+	FieldParent      = "parent"
+	FieldTags        = "tags"
+	FieldRecurrence  = "recurrence"
+)
+
+// Fields linking occurrences of a recurring task: the completed task records
+// FieldNext in an EventRecurred entry, and the created occurrence records
+// FieldPrevious in its EventCreated entry. They are not task fields.
+const (
+	FieldNext     = "next"
+	FieldPrevious = "previous"
+)
+
+// FieldBlockedBy records the blockers of a task as sorted, comma-separated
+// IDs when they change. Dependencies are not task fields, so reverting a task
+// leaves them alone.
+const FieldBlockedBy = "blocked_by"
+
+// HistoryFields lists the recorded task fields in display order.
+var HistoryFields = []string{FieldTitle, FieldDescription, FieldStatus, FieldPriority, FieldDue, FieldParent, FieldTags, FieldRecurrence}
+
+// HistoryChange is one field of a task before and after a history event.
+// Values use the field's canonical form (see FieldValue); an empty string
+// means the field was unset.
+type HistoryChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// FieldValue returns the canonical history form of a task field: priority
+// and parent as decimal numbers, all-day due dates as "2006-01-02", timed
+// ones in RFC 3339 and tags as their sorted, comma-separated names.
+func FieldValue(task Task, field string) string {
+	switch field {
+	case FieldTitle:
+		return task.Title
+	case FieldDescription:
+		return task.Description
+	case FieldStatus:
+		return task.Status
+	case FieldPriority:
+		return strconv.FormatInt(task.Priority, 10)
+	case FieldDue:
+		if task.DueAt == nil {
+			return ""
+		}
+		if task.DueAllDay {
+			return task.DueAt.UTC().Format("2006-01-02")
+		}
+		return task.DueAt.UTC().Format(time.RFC3339Nano)
+	case FieldParent:
+		if task.ParentTaskID == nil || *task.ParentTaskID == 0 {
+			return ""
+		}
+		return strconv.FormatInt(*task.ParentTaskID, 10)
+	case FieldTags:
+		names := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			names = append(names, tag.Name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	case FieldRecurrence:
+		return task.Recurrence
+	}
+	return ""
+}
+
+// SetFieldValue sets a task field from its canonical history form, the
+// inverse of FieldValue. Tags only get their names.
+func SetFieldValue(task *Task, field, value string) error {
+	switch field {
+	case FieldTitle:
+		task.Title = value
+	case FieldDescription:
+		task.Description = value
+	case FieldStatus:
+		task.Status = value
+	case FieldPriority:
+		priority, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid priority %q", value)
+		}
+		task.Priority = priority
+	case FieldDue:
+		due, allDay, err := parseDueValue(value)
+		if err != nil {
+			return err
+		}
+		task.DueAt, task.DueAllDay = due, allDay
+	case FieldParent:
+		task.ParentTaskID = nil
+		if value != "" {
+			parentID, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid parent %q", value)
+			}
+			task.ParentTaskID = &parentID
+		}
+	case FieldTags:
+		task.Tags = nil
+		for _, name := range strings.Split(value, ",") {
+			if name != "" {
+				task.Tags = append(task.Tags, Tag{Name: name})
+			}
+		}
+	case FieldRecurrence:
+		task.Recurrence = value
+	default:
+		return fmt.Errorf("unknown field %q", field)
+	}
+	return nil
+}
+
+// Change returns the recorded change of field, if any.
+func (e HistoryEntry) Change(field string) (HistoryChange, bool) {
+	for _, change := range e.Changes {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return HistoryChange{}, false
+}
+
+// Details renders the entry as a single line of text, such as
+// "updated: title: 'a' -> 'b'; due: 'none' -> '2026-11-01'", with due times
+// in loc.
+func (e HistoryEntry) Details(loc *time.Location) string {
+	if len(e.Changes) == 0 && e.Text != "" {
+		return e.Text
+	}
+
+	switch e.EventType {
+	case EventCreated, EventDeleted, EventRestored:
+		value := func(field string) string {
+			change, _ := e.Change(field)
+			if e.EventType == EventDeleted {
+				return DisplayValue(field, change.Old, loc)
+			}
+			return DisplayValue(field, change.New, loc)
+		}
+		details := fmt.Sprintf("%s: title='%s' status=%s priority=%s due=%s tags=%s", e.EventType, value(FieldTitle), value(FieldStatus), value(FieldPriority), value(FieldDue), value(FieldTags))
+		if change, ok := e.Change(FieldPrevious); ok {
+			details += fmt.Sprintf(" (next occurrence of #%s)", change.New)
+		}
+		return details
+	case EventRecurred:
+		change, _ := e.Change(FieldNext)
+		return fmt.Sprintf("recurred: next occurrence is #%s", change.New)
+	}
+
+	if len(e.Changes) == 0 {
+		return e.EventType + ": no changes"
+	}
+	parts := make([]string, 0, len(e.Changes))
+	for _, change := range e.Changes {
+		parts = append(parts, fmt.Sprintf("%s: '%s' -> '%s'", change.Field, DisplayValue(change.Field, change.Old, loc), DisplayValue(change.Field, change.New, loc)))
+	}
+	return e.EventType + ": " + strings.Join(parts, "; ")
+}
+
+// DisplayValue formats a canonical field value for people: dates without a
+// time of day are shown as days, due times in loc, and unset values as
+// "none".
+func DisplayValue(field, value string, loc *time.Location) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "none"
+	}
+	if field == FieldDue {
+		if due, allDay, err := parseDueValue(value); err == nil && due != nil {
+			return duedate.Format(*due, allDay, loc)
+		}
+	}
+	return value
+}
+
+// parseDueValue reads a due date in its canonical history form, reporting
+// whether it is all-day; an empty value is no due date.
+func parseDueValue(value string) (*time.Time, bool, error) {
+	if value == "" {
+		return nil, false, nil
+	}
+	if due, err := time.Parse("2006-01-02", value); err == nil {
+		return &due, true, nil
+	}
+	due, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid due date %q", value)
+	}
+	return &due, false, nil
+}
@@ -10,9 +10,26 @@ type Task struct {
 	Status       string
 	Priority     int64
 	DueAt        *time.Time
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
-	Tags         []Tag
+	// DueAllDay marks a due date without a time of day, stored as midnight
+	// UTC of its day.
+	DueAllDay bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time
+	// Recurrence is an RRULE (see ParseRecurrence), or empty for one-off
+	// tasks. Completing a recurring task creates its next occurrence.
+	Recurrence string
+	Tags       []Tag
+}
+
+// Statuses are the task statuses, in the order the task form cycles through
+// them.
+var Statuses = []string{"todo", "doing", "eventually", "done"}
+
+type SearchResult struct {
+	Task    Task
+	Snippet string
+	Rank    float64
 }
 
 type Tag struct {
@@ -25,7 +42,27 @@ type HistoryEntry struct {
 	ID        int64
 	TaskID    int64
 	EventType string
-	Details   string
+	Changes   []HistoryChange
+	Actor     string
+	Source    string
+	// Text is the free-form description recorded by versions that predate
+	// Changes, kept for entries it could not be parsed from.
+	Text      string
+	CreatedAt time.Time
+}
+
+// ActivityEntry is a history entry in the activity log across all tasks,
+// together with the current title of its task.
+type ActivityEntry struct {
+	HistoryEntry
+	TaskTitle string
+}
+
+// Dependency is an edge saying that TaskID cannot start until BlockerID is
+// done.
+type Dependency struct {
+	TaskID    int64
+	BlockerID int64
 	CreatedAt time.Time
 }
 
@@ -37,10 +74,19 @@ type View struct {
 	UpdatedAt time.Time
 }
 
+// Tag match modes for Filter.TagMatch. An empty mode means TagMatchAny.
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Filter selects tasks. DueBefore and DueAfter name calendar days, by their
+// date in UTC, and include them.
 type Filter struct {
 	Query     string     `json:"query"`
 	Status    string     `json:"status"`
 	Tags      []string   `json:"tags"`
+	TagMatch  string     `json:"tag_match,omitempty"`
 	DueBefore *time.Time `json:"due_before"`
 	DueAfter  *time.Time `json:"due_after"`
 }
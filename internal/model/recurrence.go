@@ -0,0 +1,329 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies, as in the FREQ part of an RFC 5545 RRULE.
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// Recurrence is the supported subset of an RFC 5545 RRULE: FREQ, INTERVAL,
+// BYDAY (with ordinals such as 1MO or -1FR for monthly rules) and
+// BYMONTHDAY (monthly rules only, negative values count from the end).
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+}
+
+// RecurrenceDay is a BYDAY entry. Ordinal selects the nth (or, when
+// negative, nth last) such weekday of the month; zero means every one.
+type RecurrenceDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRecurrence parses a rule such as "FREQ=WEEKLY;BYDAY=MO,TH" (an
+// "RRULE:" prefix is allowed) or one of the shorthands daily, weekly,
+// monthly and yearly.
+func ParseRecurrence(value string) (Recurrence, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "RRULE:")
+	switch value {
+	case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+		return Recurrence{Freq: value, Interval: 1}, nil
+	}
+
+	rule := Recurrence{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("invalid recurrence part %q", part)
+		}
+		switch key {
+		case "FREQ":
+			switch val {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				rule.Freq = val
+			default:
+				return Recurrence{}, fmt.Errorf("unsupported recurrence frequency %q", val)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return Recurrence{}, fmt.Errorf("invalid recurrence interval %q", val)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, err := parseRecurrenceDay(code)
+				if err != nil {
+					return Recurrence{}, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, number := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(number)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return Recurrence{}, fmt.Errorf("invalid recurrence month day %q", number)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		default:
+			return Recurrence{}, fmt.Errorf("unsupported recurrence part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return Recurrence{}, fmt.Errorf("recurrence %q has no FREQ", value)
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != FreqMonthly {
+		return Recurrence{}, fmt.Errorf("BYMONTHDAY is only supported for monthly recurrences")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqWeekly && rule.Freq != FreqMonthly {
+		return Recurrence{}, fmt.Errorf("BYDAY is only supported for weekly and monthly recurrences")
+	}
+	for _, day := range rule.ByDay {
+		if day.Ordinal != 0 && rule.Freq != FreqMonthly {
+			return Recurrence{}, fmt.Errorf("BYDAY ordinals are only supported for monthly recurrences")
+		}
+	}
+	return rule, nil
+}
+
+func parseRecurrenceDay(code string) (RecurrenceDay, error) {
+	code = strings.TrimSpace(code)
+	if len(code) < 2 {
+		return RecurrenceDay{}, fmt.Errorf("invalid recurrence day %q", code)
+	}
+	weekday := slices.Index(weekdayCodes, code[len(code)-2:])
+	if weekday < 0 {
+		return RecurrenceDay{}, fmt.Errorf("invalid recurrence day %q", code)
+	}
+	day := RecurrenceDay{Weekday: time.Weekday(weekday)}
+	if ordinal := code[:len(code)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return RecurrenceDay{}, fmt.Errorf("invalid recurrence day %q", code)
+		}
+		day.Ordinal = n
+	}
+	return day, nil
+}
+
+// NormalizeRecurrence returns the canonical RRULE form of value, or "" when
+// value is blank.
+func NormalizeRecurrence(value string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", nil
+	}
+	rule, err := ParseRecurrence(value)
+	if err != nil {
+		return "", err
+	}
+	return rule.String(), nil
+}
+
+// String returns the rule in canonical RRULE form, without the prefix.
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			codes = append(codes, day.code())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d RecurrenceDay) code() string {
+	if d.Ordinal == 0 {
+		return weekdayCodes[d.Weekday]
+	}
+	return strconv.Itoa(d.Ordinal) + weekdayCodes[d.Weekday]
+}
+
+// Describe renders the rule for people, such as "every 2 weeks on Mon, Thu".
+func (r Recurrence) Describe() string {
+	units := map[string]string{FreqDaily: "day", FreqWeekly: "week", FreqMonthly: "month", FreqYearly: "year"}
+	description := "every " + units[r.Freq]
+	if r.Interval > 1 {
+		description = fmt.Sprintf("every %d %ss", r.Interval, units[r.Freq])
+	}
+
+	var on []string
+	for _, day := range r.ByDay {
+		name := day.Weekday.String()[:3]
+		switch {
+		case day.Ordinal == -1:
+			name = "last " + name
+		case day.Ordinal < 0:
+			name = fmt.Sprintf("%s from last %s", ordinal(-day.Ordinal), name)
+		case day.Ordinal > 0:
+			name = ordinal(day.Ordinal) + " " + name
+		}
+		on = append(on, name)
+	}
+	for _, day := range r.ByMonthDay {
+		if day == -1 {
+			on = append(on, "the last day")
+			continue
+		}
+		if day < 0 {
+			on = append(on, fmt.Sprintf("the %s last day", ordinal(-day)))
+			continue
+		}
+		on = append(on, "the "+ordinal(day))
+	}
+	if len(on) > 0 {
+		description += " on " + strings.Join(on, ", ")
+	}
+	return description
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// Next returns the first occurrence of the rule strictly after t, counting
+// intervals from t and keeping its time of day. Monthly and yearly rules
+// without BYDAY or BYMONTHDAY skip the months that lack t's day, as RFC 5545
+// does, so that every occurrence keeps the day the series started on.
+func (r Recurrence) Next(t time.Time) time.Time {
+	interval := max(r.Interval, 1)
+	switch r.Freq {
+	case FreqDaily:
+		return t.AddDate(0, 0, interval)
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*interval)
+		}
+		// Later days of t's week come first, then the matching days of the
+		// week interval weeks on.
+		weekStart := StartOfWeek(t)
+		for offset := 1; offset < 7; offset++ {
+			candidate := t.AddDate(0, 0, offset)
+			if !StartOfWeek(candidate).Equal(weekStart) {
+				break
+			}
+			if r.matchesWeekday(candidate.Weekday()) {
+				return candidate
+			}
+		}
+		monday := t.AddDate(0, 0, -((int(t.Weekday())+6)%7)+7*interval)
+		for offset := range 7 {
+			if candidate := monday.AddDate(0, 0, offset); r.matchesWeekday(candidate.Weekday()) {
+				return candidate
+			}
+		}
+	case FreqMonthly:
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			return addMonths(t, interval)
+		}
+		if day, ok := r.firstMonthDay(t.Year(), t.Month(), t.Day()); ok {
+			return withDay(t, t.Year(), t.Month(), day)
+		}
+		// Months without a matching day (such as a fifth Monday) are skipped.
+		for step := 1; step <= 12*5; step++ {
+			month := time.Date(t.Year(), t.Month()+time.Month(step*interval), 1, 0, 0, 0, 0, t.Location())
+			if day, ok := r.firstMonthDay(month.Year(), month.Month(), 0); ok {
+				return withDay(t, month.Year(), month.Month(), day)
+			}
+		}
+	case FreqYearly:
+		return addMonths(t, 12*interval)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+func (r Recurrence) matchesWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// firstMonthDay returns the first day of the month after the given day that
+// matches BYDAY or BYMONTHDAY.
+func (r Recurrence) firstMonthDay(year int, month time.Month, after int) (int, bool) {
+	last := daysIn(year, month)
+	for day := after + 1; day <= last; day++ {
+		for _, monthDay := range r.ByMonthDay {
+			if monthDay == day || last+monthDay+1 == day {
+				return day, true
+			}
+		}
+		weekday := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+		for _, byDay := range r.ByDay {
+			if byDay.Weekday != weekday {
+				continue
+			}
+			switch {
+			case byDay.Ordinal == 0,
+				byDay.Ordinal > 0 && (day-1)/7+1 == byDay.Ordinal,
+				byDay.Ordinal < 0 && (last-day)/7+1 == -byDay.Ordinal:
+				return day, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// addMonths moves t on by the first multiple of months that lands on a month
+// with t's day. One always comes: within 12 steps for days up to the 31st,
+// and within a few leap years for February 29.
+func addMonths(t time.Time, months int) time.Time {
+	for step := 1; ; step++ {
+		first := time.Date(t.Year(), t.Month()+time.Month(step*months), 1, 0, 0, 0, 0, t.Location())
+		if t.Day() <= daysIn(first.Year(), first.Month()) {
+			return withDay(t, first.Year(), first.Month(), t.Day())
+		}
+	}
+}
+
+func withDay(t time.Time, year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
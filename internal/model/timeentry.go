@@ -0,0 +1,61 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeEntry is an interval spent on a task. Entries start and stop
+// automatically as the task enters and leaves "doing", and can be started,
+// stopped and adjusted by hand. EndedAt is nil while the entry is running.
+type TimeEntry struct {
+	ID        int64
+	TaskID    int64
+	StartedAt time.Time
+	EndedAt   *time.Time
+}
+
+// Running reports whether the entry has not been stopped yet.
+func (e TimeEntry) Running() bool {
+	return e.EndedAt == nil
+}
+
+// Duration returns how long the entry lasted, counting a running entry up
+// to now.
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	if end.Before(e.StartedAt) {
+		return 0
+	}
+	return end.Sub(e.StartedAt)
+}
+
+// Within returns how much of the entry falls at or after since, counting a
+// running entry up to now.
+func (e TimeEntry) Within(since, now time.Time) time.Duration {
+	if e.StartedAt.Before(since) {
+		e.StartedAt = since
+	}
+	return e.Duration(now)
+}
+
+// FormatDuration renders a duration in hours and minutes, such as "2h05m" or
+// "45m", rounding down to the minute.
+func FormatDuration(d time.Duration) string {
+	minutes := int64(d / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// PomodoroSession is a completed focus session on a task.
+type PomodoroSession struct {
+	ID        int64
+	TaskID    int64
+	StartedAt time.Time
+	EndedAt   time.Time
+}
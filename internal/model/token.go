@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Scopes of an API token: read tokens can only look at tasks, read-write
+// tokens can also change them.
+const (
+	ScopeRead      = "read"
+	ScopeReadWrite = "read-write"
+)
+
+// APIToken is a credential for the web server. Only a hash of its secret is
+// stored, so the secret is shown once, when the token is created.
+type APIToken struct {
+	ID        int64
+	Name      string
+	Scope     string
+	CreatedAt time.Time
+}
+
+// CanWrite reports whether the token may change tasks.
+func (t APIToken) CanWrite() bool {
+	return t.Scope == ScopeReadWrite
+}
@@ -0,0 +1,123 @@
+// Package taskform reads the task form shared by the TUI and the web UI, so
+// that both accept and reject the same input.
+package taskform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// Fields are the editable fields of a task as typed into a form.
+type Fields struct {
+	Title       string
+	Description string
+	Status      string
+	Tags        string
+	Due         string
+	Priority    string
+	Recurrence  string
+}
+
+// FromTask fills the fields from task, with its due time in loc, or with
+// the defaults of a new task when task is nil.
+func FromTask(task *model.Task, loc *time.Location) Fields {
+	if task == nil {
+		return Fields{Status: "todo", Priority: "0"}
+	}
+	fields := Fields{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Tags:        JoinTags(task.Tags),
+		Priority:    strconv.FormatInt(task.Priority, 10),
+		Recurrence:  task.Recurrence,
+	}
+	if task.DueAt != nil {
+		fields.Due = duedate.Format(*task.DueAt, task.DueAllDay, loc)
+	}
+	return fields
+}
+
+// Input parses the fields into the input the store takes, reading due times
+// in loc. The store checks the rest, such as the title being set and the
+// status being known.
+func (f Fields) Input(loc *time.Location) (db.TaskInput, error) {
+	priority, err := ParsePriority(f.Priority)
+	if err != nil {
+		return db.TaskInput{}, err
+	}
+
+	due, err := ParseDue(f.Due, loc)
+	if err != nil {
+		return db.TaskInput{}, err
+	}
+
+	recurrence, err := model.NormalizeRecurrence(f.Recurrence)
+	if err != nil {
+		return db.TaskInput{}, err
+	}
+
+	input := db.TaskInput{
+		Title:       strings.TrimSpace(f.Title),
+		Description: strings.TrimSpace(f.Description),
+		Status:      strings.TrimSpace(f.Status),
+		Priority:    priority,
+		Recurrence:  recurrence,
+		Tags:        ParseTags(f.Tags),
+	}
+	input.SetDue(due)
+	return input, nil
+}
+
+func ParsePriority(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(trimmed, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority")
+	}
+	return parsed, nil
+}
+
+// ParseDue parses a due date such as "tomorrow 14:00" in loc, or returns nil
+// when value is empty.
+func ParseDue(value string, loc *time.Location) (*duedate.Due, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	due, err := duedate.Parse(value, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	return &due, nil
+}
+
+// ParseTags splits a comma-separated list of tag names.
+func ParseTags(value string) []string {
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if trimmed == "" {
+			continue
+		}
+		result = append(result, trimmed)
+	}
+	return result
+}
+
+func JoinTags(tags []model.Tag) string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return strings.Join(names, ",")
+}
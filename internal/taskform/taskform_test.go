@@ -0,0 +1,45 @@
+package taskform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestFieldsRoundTripATask(t *testing.T) {
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	task := model.Task{
+		Title:      "Rotate API keys",
+		Status:     "doing",
+		Priority:   2,
+		DueAt:      &due,
+		DueAllDay:  true,
+		Recurrence: "FREQ=WEEKLY",
+		Tags:       []model.Tag{{Name: "ops"}, {Name: "work"}},
+	}
+
+	input, err := FromTask(&task, time.UTC).Input(time.UTC)
+	if err != nil {
+		t.Fatalf("parse fields: %v", err)
+	}
+	if input.Title != task.Title || input.Status != task.Status || input.Priority != task.Priority || input.Recurrence != task.Recurrence {
+		t.Fatalf("unexpected input %+v", input)
+	}
+	if input.DueAt == nil || !input.DueAt.Equal(due) || !input.DueAllDay {
+		t.Fatalf("expected all-day due %v, got %v", due, input.DueAt)
+	}
+	if len(input.Tags) != 2 || input.Tags[0] != "ops" || input.Tags[1] != "work" {
+		t.Fatalf("unexpected tags %v", input.Tags)
+	}
+
+	for _, fields := range []Fields{
+		{Title: "Task", Priority: "high"},
+		{Title: "Task", Due: "someday"},
+		{Title: "Task", Recurrence: "fortnightly"},
+	} {
+		if _, err := fields.Input(time.UTC); err == nil {
+			t.Fatalf("expected %+v to be rejected", fields)
+		}
+	}
+}
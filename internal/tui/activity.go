@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// History pane modes: the selected task's history, or the activity log of
+// every task since the start of the day or week.
+const (
+	historyModeTask  = ""
+	historyModeToday = "today"
+	historyModeWeek  = "week"
+)
+
+// activityLimit bounds how many entries the activity log loads.
+const activityLimit = 500
+
+// cycleHistoryMode switches the History pane between the selected task's
+// history, today's activity and this week's activity, showing and focusing
+// the pane when it switches to an activity log.
+func (u *UI) cycleHistoryMode(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() {
+		return nil
+	}
+
+	switch u.historyMode {
+	case historyModeTask:
+		u.historyMode = historyModeToday
+	case historyModeToday:
+		u.historyMode = historyModeWeek
+	default:
+		u.historyMode = historyModeTask
+	}
+	u.selectedHistory = 0
+	if err := u.loadHistory(); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+
+	if u.historyMode == historyModeTask {
+		u.status = "History: selected task"
+		return nil
+	}
+	u.status = "History: activity " + historyModeTitle(u.historyMode)
+	u.historyVisible = true
+	u.focus = viewHistory
+	if gui != nil {
+		_, _ = gui.SetCurrentView(u.focus)
+	}
+	return nil
+}
+
+func (u *UI) loadActivity() error {
+	now := u.now()
+	since := model.StartOfDay(now)
+	if u.historyMode == historyModeWeek {
+		since = model.StartOfWeek(now)
+	}
+
+	activity, err := u.store.ListActivity(context.Background(), since, activityLimit, 0)
+	if err != nil {
+		return err
+	}
+	u.history = make([]model.HistoryEntry, 0, len(activity))
+	u.historyTitles = make(map[int64]string, len(activity))
+	for _, entry := range activity {
+		u.history = append(u.history, entry.HistoryEntry)
+		u.historyTitles[entry.TaskID] = entry.TaskTitle
+	}
+	if u.selectedHistory >= len(u.history) {
+		u.selectedHistory = max(len(u.history)-1, 0)
+	}
+	return nil
+}
+
+// historyTask returns the task of the selected activity entry, falling back
+// to its title when the task is no longer listed (for example when trashed).
+func (u *UI) historyTask() *model.Task {
+	entry := u.selectedHistoryEntry()
+	if entry == nil {
+		return nil
+	}
+	task, err := u.taskByID(entry.TaskID)
+	if err != nil {
+		return &model.Task{ID: entry.TaskID, Title: u.historyTitles[entry.TaskID]}
+	}
+	return &task
+}
+
+func historyModeTitle(mode string) string {
+	switch mode {
+	case historyModeToday:
+		return "today"
+	case historyModeWeek:
+		return "this week"
+	}
+	return ""
+}
@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	goerrors "github.com/go-errors/errors"
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/duedate"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// Agenda groups, in the order the agenda shows them.
+const (
+	agendaOverdue  = "Overdue"
+	agendaToday    = "Today"
+	agendaTomorrow = "Tomorrow"
+	agendaThisWeek = "This week"
+	agendaLater    = "Later"
+)
+
+var agendaGroups = []string{agendaOverdue, agendaToday, agendaTomorrow, agendaThisWeek, agendaLater}
+
+// now returns the current time in the location of the store, where due
+// dates are placed.
+func (u *UI) now() time.Time {
+	return time.Now().In(u.store.Location())
+}
+
+// taskDue returns the due date of a task that has one, in loc.
+func taskDue(task model.Task, loc *time.Location) duedate.Due {
+	return duedate.FromInstant(*task.DueAt, task.DueAllDay, loc)
+}
+
+// agendaGroup places the due date of a task relative to now. Timed deadlines
+// are overdue once their time has passed, all-day ones once their day has.
+func agendaGroup(task model.Task, now time.Time) string {
+	due := taskDue(task, now.Location())
+	today := model.StartOfDay(now)
+	switch {
+	case due.HasTime && due.Date.Before(now), model.StartOfDay(due.Date).Before(today):
+		return agendaOverdue
+	case model.StartOfDay(due.Date).Equal(today):
+		return agendaToday
+	case model.StartOfDay(due.Date).Equal(today.AddDate(0, 0, 1)):
+		return agendaTomorrow
+	case due.Date.Before(model.StartOfWeek(now).AddDate(0, 0, 7)):
+		return agendaThisWeek
+	}
+	return agendaLater
+}
+
+func agendaGroupIndex(group string) int {
+	for index, name := range agendaGroups {
+		if name == group {
+			return index
+		}
+	}
+	return len(agendaGroups)
+}
+
+// dueMarker labels the deadline of an open task in the task lists, colored
+// red when overdue, yellow when due today and cyan when due tomorrow.
+func dueMarker(task model.Task, now time.Time) string {
+	if task.DueAt == nil || task.Status == "done" {
+		return ""
+	}
+	group := agendaGroup(task, now)
+	marker := "[due " + dueLabel(taskDue(task, now.Location()), group) + "]"
+	switch group {
+	case agendaOverdue:
+		return " \x1b[31m" + marker + "\x1b[0m"
+	case agendaToday:
+		return " \x1b[33m" + marker + "\x1b[0m"
+	case agendaTomorrow:
+		return " \x1b[36m" + marker + "\x1b[0m"
+	}
+	return " " + marker
+}
+
+// dueLabel names a due date as briefly as its group allows: "today",
+// "tomorrow" or a weekday this week, and the date otherwise.
+func dueLabel(due duedate.Due, group string) string {
+	var label string
+	switch group {
+	case agendaToday:
+		label = "today"
+	case agendaTomorrow:
+		label = "tomorrow"
+	case agendaThisWeek:
+		label = due.Date.Format("Mon")
+	default:
+		return due.Short()
+	}
+	if due.HasTime {
+		label += " " + due.Date.Format("15:04")
+	}
+	return label
+}
+
+// loadAgenda loads the open tasks with a due date, whatever the filters,
+// ordered by group and then by deadline.
+func (u *UI) loadAgenda() error {
+	tasks, err := u.store.ListTasks(context.Background(), model.Filter{Query: "-status:done -due:none"})
+	if err != nil {
+		return err
+	}
+	now := u.now()
+	sort.SliceStable(tasks, func(i, j int) bool {
+		left := agendaGroupIndex(agendaGroup(tasks[i], now))
+		right := agendaGroupIndex(agendaGroup(tasks[j], now))
+		if left != right {
+			return left < right
+		}
+		return taskDue(tasks[i], now.Location()).Date.Before(taskDue(tasks[j], now.Location()).Date)
+	})
+	u.agenda = tasks
+	if u.selectedAgenda >= len(u.agenda) {
+		u.selectedAgenda = max(len(u.agenda)-1, 0)
+	}
+	return nil
+}
+
+// overdueCount is the number of open tasks past their deadline, shown in
+// the header.
+func (u *UI) overdueCount(now time.Time) int {
+	count := 0
+	for _, task := range u.agenda {
+		if agendaGroup(task, now) == agendaOverdue {
+			count++
+		}
+	}
+	return count
+}
+
+func (u *UI) openAgenda(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.moveActive || u.blockActive {
+		return nil
+	}
+	if err := u.loadAgenda(); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.selectedAgenda = 0
+	u.agendaActive = true
+	return nil
+}
+
+func (u *UI) closeAgenda(gui *gocui.Gui, _ *gocui.View) error {
+	u.agendaActive = false
+	if gui != nil {
+		_ = gui.DeleteView(viewAgenda)
+		_, _ = gui.SetCurrentView(u.focus)
+	}
+	return nil
+}
+
+func (u *UI) moveAgendaSelection(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(gui *gocui.Gui, _ *gocui.View) error {
+		if len(u.agenda) == 0 {
+			return nil
+		}
+		u.selectedAgenda = min(max(u.selectedAgenda+delta, 0), len(u.agenda)-1)
+		return nil
+	}
+}
+
+func (u *UI) selectedAgendaTask() *model.Task {
+	if u.selectedAgenda < 0 || u.selectedAgenda >= len(u.agenda) {
+		return nil
+	}
+	return &u.agenda[u.selectedAgenda]
+}
+
+// jumpToAgendaTask closes the agenda and selects its selected task in the
+// pane that lists it.
+func (u *UI) jumpToAgendaTask(gui *gocui.Gui, _ *gocui.View) error {
+	selected := u.selectedAgendaTask()
+	if selected == nil {
+		return nil
+	}
+	task := *selected
+	if err := u.closeAgenda(gui, nil); err != nil {
+		return err
+	}
+	return u.revealTask(gui, task)
+}
+
+// revealTask focuses the pane listing task and selects it, expanding its
+// collapsed ancestors, and clearing the filters if they hide it.
+func (u *UI) revealTask(gui *gocui.Gui, task model.Task) error {
+	for parentID := task.ParentTaskID; parentID != nil; {
+		delete(u.collapsed, *parentID)
+		parent, err := u.store.GetTaskWithTags(context.Background(), *parentID)
+		if err != nil {
+			break
+		}
+		parentID = parent.ParentTaskID
+	}
+	if err := u.loadTasks(); err != nil {
+		return err
+	}
+	if !u.selectTaskByID(task.ID) {
+		if err := u.clearFilters(gui, nil); err != nil {
+			return err
+		}
+		if !u.selectTaskByID(task.ID) {
+			u.status = fmt.Sprintf("Task #%d is not listed", task.ID)
+			return nil
+		}
+	}
+	if gui != nil {
+		_, _ = gui.SetCurrentView(u.focus)
+	}
+	return u.loadHistory()
+}
+
+// selectTaskByID focuses the list showing the task and selects it, if any
+// list shows it.
+func (u *UI) selectTaskByID(taskID int64) bool {
+	lists := []struct {
+		view     string
+		tasks    []model.Task
+		selected *int
+	}{
+		{viewPending, u.pending, &u.selectedPending},
+		{viewEventually, u.eventually, &u.selectedEventually},
+		{viewDone, u.done, &u.selectedDone},
+	}
+	for _, list := range lists {
+		for index, task := range list.tasks {
+			if task.ID == taskID {
+				u.focus = list.view
+				*list.selected = index
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (u *UI) showAgenda(gui *gocui.Gui) error {
+	maxX, maxY := gui.Size()
+	lines, selectedLine := u.agendaLines(u.now())
+	width := max(60, maxX*2/3)
+	height := max(min(len(lines)+2, maxY-4), 5)
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width
+	y1 := y0 + height
+
+	view, err := gui.SetView(viewAgenda, x0, y0, x1, y1, 0)
+	if err != nil && !goerrors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if goerrors.Is(err, gocui.ErrUnknownView) {
+		view.Title = "Agenda"
+		view.Footer = "enter go to task | esc close"
+	}
+	view.FrameRunes = roundedFrameRunes
+	view.Clear()
+	for _, line := range lines {
+		fmt.Fprintln(view, line)
+	}
+	ensureSelectionVisible(view, selectedLine, len(lines))
+	setCursorToSelection(view, selectedLine, len(lines))
+	_, _ = gui.SetViewOnTop(viewAgenda)
+	_, _ = gui.SetCurrentView(viewAgenda)
+	return nil
+}
+
+// agendaLines renders the agenda as a heading per non-empty group followed
+// by its tasks, returning the line of the selected task.
+func (u *UI) agendaLines(now time.Time) ([]string, int) {
+	if len(u.agenda) == 0 {
+		return []string{"  Nothing is due."}, 0
+	}
+	lines := []string{}
+	selectedLine := 0
+	group := ""
+	for index, task := range u.agenda {
+		if taskGroup := agendaGroup(task, now); taskGroup != group {
+			group = taskGroup
+			lines = append(lines, group)
+		}
+		prefix := " "
+		if index == u.selectedAgenda {
+			prefix = ">"
+			selectedLine = len(lines)
+		}
+		lines = append(lines, fmt.Sprintf("%s #%d %s%s", prefix, task.ID, task.Title, dueMarker(task, now)))
+	}
+	return lines, selectedLine
+}
@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// dependencyChange records a blocker added to or removed from a task, so
+// that undo can reverse it.
+type dependencyChange struct {
+	taskID    int64
+	blockerID int64
+	added     bool
+}
+
+// loadDependencies refreshes the dependency edges and the set of tasks that
+// still wait on an unfinished blocker.
+func (u *UI) loadDependencies() error {
+	dependencies, err := u.store.ListDependencies(context.Background())
+	if err != nil {
+		return err
+	}
+	blocked, err := u.store.ListTasks(context.Background(), model.Filter{Query: "is:blocked"})
+	if err != nil {
+		return err
+	}
+
+	u.dependencies = dependencies
+	u.blocked = make(map[int64]bool, len(blocked))
+	for _, task := range blocked {
+		u.blocked[task.ID] = true
+	}
+	return nil
+}
+
+// toggleBlockMode picks the selected task on the first press; the second
+// press adds the task selected then as its blocker, or removes it if it
+// already is one.
+func (u *UI) toggleBlockMode(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.moveActive {
+		return nil
+	}
+	if u.focus != viewPending && u.focus != viewDone && u.focus != viewEventually {
+		return nil
+	}
+	selected := u.selectedTask()
+	if selected == nil {
+		return nil
+	}
+	if !u.blockActive {
+		u.blockActive = true
+		u.blockTaskID = selected.ID
+		u.status = "Block: pick the task it waits on and press b (again to unblock), esc cancel"
+		return nil
+	}
+
+	taskID := u.blockTaskID
+	if selected.ID == taskID {
+		u.status = "Block: a task cannot wait on itself"
+		return nil
+	}
+
+	change := dependencyChange{taskID: taskID, blockerID: selected.ID, added: !u.isBlocker(taskID, selected.ID)}
+	if err := u.applyDependency(change); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	task, err := u.taskByID(taskID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	label := "unblock"
+	if change.added {
+		label = "block"
+	}
+	u.recordUndo(undoEntry{label: taskLabel(label, task), blocker: &change})
+	u.blockActive = false
+	u.blockTaskID = 0
+	u.status = ""
+	return u.loadTasks()
+}
+
+func (u *UI) cancelBlockMode(_ *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || !u.blockActive {
+		return nil
+	}
+	u.blockActive = false
+	u.blockTaskID = 0
+	u.status = ""
+	return nil
+}
+
+// applyDependency adds or removes the blocker of change.
+func (u *UI) applyDependency(change dependencyChange) error {
+	if change.added {
+		return u.store.AddBlocker(context.Background(), change.taskID, change.blockerID)
+	}
+	return u.store.RemoveBlocker(context.Background(), change.taskID, change.blockerID)
+}
+
+func (u *UI) isBlocker(taskID, blockerID int64) bool {
+	for _, dependency := range u.dependencies {
+		if dependency.TaskID == taskID && dependency.BlockerID == blockerID {
+			return true
+		}
+	}
+	return false
+}
+
+// dependencyLines lists the blockers of a task and the tasks it blocks for
+// the Highlighted pane.
+func (u *UI) dependencyLines(taskID int64) []string {
+	var blockedBy, blocks []string
+	for _, dependency := range u.dependencies {
+		switch taskID {
+		case dependency.TaskID:
+			blockedBy = append(blockedBy, u.dependencyLine(dependency.BlockerID))
+		case dependency.BlockerID:
+			blocks = append(blocks, u.dependencyLine(dependency.TaskID))
+		}
+	}
+
+	var lines []string
+	if len(blockedBy) > 0 {
+		lines = append(lines, "", "Blocked by:")
+		lines = append(lines, blockedBy...)
+	}
+	if len(blocks) > 0 {
+		lines = append(lines, "", "Blocks:")
+		lines = append(lines, blocks...)
+	}
+	return lines
+}
+
+func (u *UI) dependencyLine(taskID int64) string {
+	task, err := u.taskByID(taskID)
+	if err != nil {
+		return fmt.Sprintf("- #%d", taskID)
+	}
+	return fmt.Sprintf("- #%d %s (%s)", task.ID, task.Title, task.Status)
+}
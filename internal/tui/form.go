@@ -1,13 +1,13 @@
 package tui
 
 import (
-	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
 	"github.com/Joseda-hg/lazytask/internal/model"
+	"github.com/Joseda-hg/lazytask/internal/taskform"
 )
 
 type formField struct {
@@ -22,110 +22,44 @@ const (
 	fieldTags
 	fieldDue
 	fieldPriority
+	fieldRecurrence
 )
 
-func buildFormFields(task *model.Task) []formField {
-	fields := []formField{
-		{Label: "Title"},
-		{Label: "Description"},
-		{Label: "Status (space/←→)"},
-		{Label: "Tags (space/←→)"},
-		{Label: "Due (YYYY-MM-DD)"},
-		{Label: "Priority"},
+func buildFormFields(task *model.Task, loc *time.Location) []formField {
+	values := taskform.FromTask(task, loc)
+	return []formField{
+		{Label: "Title", Value: values.Title},
+		{Label: "Description", Value: values.Description},
+		{Label: "Status (space/←→)", Value: values.Status},
+		{Label: "Tags (space/←→)", Value: values.Tags},
+		{Label: "Due (tomorrow 14:00, fri, +3d, 2026-11-01)", Value: values.Due},
+		{Label: "Priority", Value: values.Priority},
+		{Label: "Repeat (daily, weekly, FREQ=MONTHLY;BYDAY=1MO)", Value: values.Recurrence},
 	}
-
-	if task == nil {
-		fields[fieldStatus].Value = "todo"
-		fields[fieldPriority].Value = "0"
-		return fields
-	}
-
-	fields[fieldTitle].Value = task.Title
-	fields[fieldDescription].Value = task.Description
-	fields[fieldStatus].Value = task.Status
-	fields[fieldPriority].Value = strconv.FormatInt(task.Priority, 10)
-	if task.DueAt != nil {
-		fields[fieldDue].Value = task.DueAt.Format("2006-01-02")
-	}
-	fields[fieldTags].Value = joinTags(task.Tags)
-
-	return fields
 }
 
-func parseFormFields(fields []formField) (db.TaskInput, error) {
-	priority, err := parsePriority(fields[fieldPriority].Value)
-	if err != nil {
-		return db.TaskInput{}, err
-	}
-
-	dueAt, err := parseDue(fields[fieldDue].Value)
-	if err != nil {
-		return db.TaskInput{}, err
-	}
-
-	return db.TaskInput{
-		Title:       strings.TrimSpace(fields[fieldTitle].Value),
-		Description: strings.TrimSpace(fields[fieldDescription].Value),
-		Status:      strings.TrimSpace(fields[fieldStatus].Value),
-		Priority:    priority,
-		DueAt:       dueAt,
-		Tags:        parseTags(fields[fieldTags].Value),
-	}, nil
+// parseFormFields parses the form the way the web UI parses its forms, with
+// due times in loc.
+func parseFormFields(fields []formField, loc *time.Location) (db.TaskInput, error) {
+	return taskform.Fields{
+		Title:       fields[fieldTitle].Value,
+		Description: fields[fieldDescription].Value,
+		Status:      fields[fieldStatus].Value,
+		Tags:        fields[fieldTags].Value,
+		Due:         fields[fieldDue].Value,
+		Priority:    fields[fieldPriority].Value,
+		Recurrence:  fields[fieldRecurrence].Value,
+	}.Input(loc)
 }
 
-func parsePriority(value string) (int64, error) {
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return 0, nil
+// duePreview shows what the Due field resolves to as it is typed, in loc.
+func duePreview(value string, loc *time.Location) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
 	}
-	parsed, err := strconv.ParseInt(trimmed, 10, 64)
+	due, err := duedate.Parse(value, time.Now().In(loc))
 	if err != nil {
-		return 0, fmt.Errorf("invalid priority")
-	}
-	return parsed, nil
-}
-
-func parseDue(value string) (*time.Time, error) {
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return nil, nil
-	}
-	parsed, err := time.Parse("2006-01-02", trimmed)
-	if err != nil {
-		return nil, fmt.Errorf("invalid due date")
-	}
-	return &parsed, nil
-}
-
-func parseTags(value string) []string {
-	parts := strings.Split(value, ",")
-	result := make([]string, 0, len(parts))
-	for _, part := range parts {
-		trimmed := strings.TrimSpace(part)
-		if trimmed == "" {
-			continue
-		}
-		result = append(result, trimmed)
-	}
-	return result
-}
-
-func joinTags(tags []model.Tag) string {
-	names := make([]string, 0, len(tags))
-	for _, tag := range tags {
-		names = append(names, tag.Name)
-	}
-	return strings.Join(names, ",")
-}
-
-func taskInputFromTask(task model.Task) db.TaskInput {
-	return db.TaskInput{
-		Title:        task.Title,
-		Description:  task.Description,
-		Status:       task.Status,
-		Priority:     task.Priority,
-		DueAt:        task.DueAt,
-		ParentTaskID: task.ParentTaskID,
-		Tags:         parseTags(joinTags(task.Tags)),
+		return "→ ?"
 	}
+	return "→ " + due.String()
 }
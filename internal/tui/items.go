@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/Joseda-hg/lazytask/internal/db"
 	"github.com/Joseda-hg/lazytask/internal/model"
 )
 
@@ -26,7 +27,16 @@ func formatTags(tags []model.Tag) string {
 }
 
 func formatTaskSummary(task model.Task) string {
-	return fmt.Sprintf("%s | %s | p%d | %s", task.Title, task.Status, task.Priority, formatTags(task.Tags))
+	title := task.Title
+	if task.Recurrence != "" {
+		title += " ↻"
+	}
+	return fmt.Sprintf("%s | %s | p%d | %s", title, task.Status, task.Priority, formatTags(task.Tags))
+}
+
+func formatSnippet(snippet string) string {
+	replacer := strings.NewReplacer(db.SnippetMatchStart, "\x1b[1;33m", db.SnippetMatchEnd, "\x1b[0m", "\n", " ")
+	return replacer.Replace(snippet)
 }
 
 func buildVisibleTaskTree(tasks []model.Task, collapsed map[int64]bool) ([]model.Task, map[int64]int, map[int64]bool) {
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// Default focus session lengths, used when Options leaves them unset.
+const (
+	defaultPomodoroWork  = 25 * time.Minute
+	defaultPomodoroBreak = 5 * time.Minute
+)
+
+// Phases of a focus session.
+const (
+	pomodoroWork  = "work"
+	pomodoroBreak = "break"
+)
+
+// pomodoroState is the running focus session: a work period on a task
+// followed by a break.
+type pomodoroState struct {
+	taskID    int64
+	title     string
+	phase     string
+	startedAt time.Time
+	endsAt    time.Time
+}
+
+// togglePomodoro starts a focus session on the selected task, or stops the
+// running one without logging it.
+func (u *UI) togglePomodoro(_ *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.moveActive || u.blockActive {
+		return nil
+	}
+	if u.pomodoro != nil {
+		u.pomodoro = nil
+		u.status = "Focus session stopped"
+		return nil
+	}
+	selected := u.selectedTask()
+	if selected == nil {
+		return nil
+	}
+	u.startPomodoro(*selected, time.Now())
+	return nil
+}
+
+func (u *UI) startPomodoro(task model.Task, now time.Time) {
+	u.pomodoro = &pomodoroState{
+		taskID:    task.ID,
+		title:     task.Title,
+		phase:     pomodoroWork,
+		startedAt: now,
+		endsAt:    now.Add(u.pomodoroLength(pomodoroWork)),
+	}
+	u.status = fmt.Sprintf("Focusing on %q for %s, P to stop", task.Title, model.FormatDuration(u.pomodoroLength(pomodoroWork)))
+}
+
+func (u *UI) pomodoroLength(phase string) time.Duration {
+	if phase == pomodoroBreak {
+		if u.options.PomodoroBreak > 0 {
+			return u.options.PomodoroBreak
+		}
+		return defaultPomodoroBreak
+	}
+	if u.options.PomodoroWork > 0 {
+		return u.options.PomodoroWork
+	}
+	return defaultPomodoroWork
+}
+
+// tickPomodoro advances the focus session to now: a finished work period is
+// logged against its task and followed by a break, and a finished break ends
+// the session.
+func (u *UI) tickPomodoro(now time.Time) error {
+	session := u.pomodoro
+	if session == nil || now.Before(session.endsAt) {
+		return nil
+	}
+
+	if session.phase == pomodoroBreak {
+		u.pomodoro = nil
+		u.status = "Break over, press P to focus again"
+		return nil
+	}
+
+	if _, err := u.store.LogPomodoro(context.Background(), session.taskID, session.startedAt, session.endsAt); err != nil {
+		u.pomodoro = nil
+		u.status = err.Error()
+		return nil
+	}
+	session.phase = pomodoroBreak
+	session.startedAt = session.endsAt
+	session.endsAt = session.endsAt.Add(u.pomodoroLength(pomodoroBreak))
+	u.status = fmt.Sprintf("Pomodoro done on %q, take a %s break", session.title, model.FormatDuration(u.pomodoroLength(pomodoroBreak)))
+	return u.loadTasks()
+}
+
+// runPomodoroClock advances the focus session and redraws its countdown
+// every second until done is closed.
+func (u *UI) runPomodoroClock(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			u.gui.Update(func(*gocui.Gui) error {
+				return u.tickPomodoro(time.Now())
+			})
+		}
+	}
+}
+
+// pomodoroLabel renders the focus session for the header, such as
+// "Focus 18:42 Write report", or returns "" when none is running.
+func (u *UI) pomodoroLabel(now time.Time) string {
+	session := u.pomodoro
+	if session == nil {
+		return ""
+	}
+	remaining := max(int(session.endsAt.Sub(now).Round(time.Second)/time.Second), 0)
+	countdown := fmt.Sprintf("%02d:%02d", remaining/60, remaining%60)
+	if session.phase == pomodoroBreak {
+		return "Break " + countdown
+	}
+	return fmt.Sprintf("Focus %s %s", countdown, session.title)
+}
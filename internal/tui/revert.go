@@ -0,0 +1,146 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	goerrors "github.com/go-errors/errors"
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// revertOption is one choice in the revert picker; an empty field reverts
+// every field.
+type revertOption struct {
+	field string
+	label string
+}
+
+// openRevert offers to put the task of the selected history entry back to
+// the state it had right after that entry, either whole or field by field.
+func (u *UI) openRevert(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.focus != viewHistory {
+		return nil
+	}
+	entry := u.selectedHistoryEntry()
+	if entry == nil {
+		return nil
+	}
+
+	past, err := u.store.TaskAt(context.Background(), entry.TaskID, entry.ID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	current, err := u.store.GetTaskWithTags(context.Background(), entry.TaskID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+
+	options := []revertOption{{label: "All fields"}}
+	for _, field := range model.HistoryFields {
+		now := model.FieldValue(current, field)
+		then := model.FieldValue(past, field)
+		if now == then {
+			continue
+		}
+		options = append(options, revertOption{
+			field: field,
+			label: fmt.Sprintf("%s: '%s' -> '%s'", field, model.DisplayValue(field, now, u.store.Location()), model.DisplayValue(field, then, u.store.Location())),
+		})
+	}
+	if len(options) == 1 {
+		u.status = "Task already matches this history entry"
+		return nil
+	}
+
+	u.revertOptions = options
+	u.revertEntry = *entry
+	u.selectedRevert = 0
+	u.revertActive = true
+	return nil
+}
+
+func (u *UI) closeRevert(gui *gocui.Gui, _ *gocui.View) error {
+	u.revertActive = false
+	u.revertOptions = nil
+	if gui != nil {
+		_ = gui.DeleteView(viewRevert)
+		_, _ = gui.SetCurrentView(u.focus)
+	}
+	return nil
+}
+
+func (u *UI) moveRevertSelection(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(gui *gocui.Gui, _ *gocui.View) error {
+		if len(u.revertOptions) == 0 {
+			return nil
+		}
+		u.selectedRevert = min(max(u.selectedRevert+delta, 0), len(u.revertOptions)-1)
+		return nil
+	}
+}
+
+func (u *UI) applyRevert(gui *gocui.Gui, view *gocui.View) error {
+	if u.selectedRevert < 0 || u.selectedRevert >= len(u.revertOptions) {
+		return nil
+	}
+	option := u.revertOptions[u.selectedRevert]
+	entry := u.revertEntry
+
+	var fields []string
+	if option.field != "" {
+		fields = append(fields, option.field)
+	}
+	changes, err := u.captureTasks(entry.TaskID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	reverted, err := u.store.RevertTask(context.Background(), entry.TaskID, entry.ID, fields...)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	if err := u.closeRevert(gui, view); err != nil {
+		return err
+	}
+	u.status = fmt.Sprintf("Reverted %q to %s", reverted.Title, entry.CreatedAt.In(u.store.Location()).Format("2006-01-02 15:04"))
+	u.recordUndo(undoEntry{label: taskLabel("revert", reverted), changes: changes})
+	return u.loadTasks()
+}
+
+func (u *UI) showRevert(gui *gocui.Gui) error {
+	maxX, maxY := gui.Size()
+	width := max(60, maxX*2/3)
+	height := max(min(len(u.revertOptions)+3, maxY-4), 5)
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width
+	y1 := y0 + height
+
+	view, err := gui.SetView(viewRevert, x0, y0, x1, y1, 0)
+	if err != nil && !goerrors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if goerrors.Is(err, gocui.ErrUnknownView) {
+		view.Title = fmt.Sprintf("Revert to %s", u.revertEntry.CreatedAt.In(u.store.Location()).Format("2006-01-02 15:04"))
+		view.Footer = "enter revert | esc cancel"
+	}
+	view.FrameRunes = roundedFrameRunes
+	view.Clear()
+	for index, option := range u.revertOptions {
+		prefix := " "
+		if index == u.selectedRevert {
+			prefix = ">"
+		}
+		fmt.Fprintf(view, "%s %s\n", prefix, option.label)
+	}
+	ensureSelectionVisible(view, u.selectedRevert, len(u.revertOptions))
+	setCursorToSelection(view, u.selectedRevert, len(u.revertOptions))
+	_, _ = gui.SetViewOnTop(viewRevert)
+	_, _ = gui.SetCurrentView(viewRevert)
+	return nil
+}
@@ -0,0 +1,22 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// trackedTimeLabel describes the time recorded for a task, such as
+// "1h05m (running)", or returns "" if none was recorded.
+func (u *UI) trackedTimeLabel(taskID int64) string {
+	total, running, err := u.store.TrackedTime(context.Background(), taskID, time.Now())
+	if err != nil || (total == 0 && !running) {
+		return ""
+	}
+	label := model.FormatDuration(total)
+	if running {
+		label += " (running)"
+	}
+	return label
+}
@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	goerrors "github.com/go-errors/errors"
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func (u *UI) loadTrash() error {
+	trash, err := u.store.ListTrash(context.Background())
+	if err != nil {
+		return err
+	}
+	u.trash = trash
+	if u.selectedTrash >= len(u.trash) {
+		u.selectedTrash = max(len(u.trash)-1, 0)
+	}
+	return nil
+}
+
+func (u *UI) openTrash(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.moveActive {
+		return nil
+	}
+	if err := u.loadTrash(); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.selectedTrash = 0
+	u.trashActive = true
+	return nil
+}
+
+func (u *UI) closeTrash(gui *gocui.Gui, _ *gocui.View) error {
+	u.trashActive = false
+	if gui != nil {
+		_ = gui.DeleteView(viewTrash)
+		_, _ = gui.SetCurrentView(u.focus)
+	}
+	return nil
+}
+
+func (u *UI) moveTrashSelection(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(gui *gocui.Gui, _ *gocui.View) error {
+		if len(u.trash) == 0 {
+			return nil
+		}
+		u.selectedTrash = min(max(u.selectedTrash+delta, 0), len(u.trash)-1)
+		return nil
+	}
+}
+
+func (u *UI) selectedTrashTask() *model.Task {
+	if u.selectedTrash < 0 || u.selectedTrash >= len(u.trash) {
+		return nil
+	}
+	return &u.trash[u.selectedTrash]
+}
+
+// restoreSelectedTrash takes the selected task out of the trash. Restoring can
+// be undone, which moves the task back to the trash.
+func (u *UI) restoreSelectedTrash(gui *gocui.Gui, _ *gocui.View) error {
+	selected := u.selectedTrashTask()
+	if selected == nil {
+		return nil
+	}
+	task := *selected
+	if _, err := u.store.RestoreFromTrash(context.Background(), task.ID); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.status = fmt.Sprintf("Restored %q", task.Title)
+	u.recordUndo(undoEntry{label: taskLabel("restore", task), changes: []taskChange{{taskID: task.ID}}})
+	if err := u.loadTrash(); err != nil {
+		return err
+	}
+	return u.loadTasks()
+}
+
+func (u *UI) purgeSelectedTrash(gui *gocui.Gui, _ *gocui.View) error {
+	selected := u.selectedTrashTask()
+	if selected == nil {
+		return nil
+	}
+	task := *selected
+	if err := u.store.PurgeTask(context.Background(), task.ID); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.status = fmt.Sprintf("Permanently deleted %q", task.Title)
+	return u.loadTrash()
+}
+
+func (u *UI) emptyTrash(gui *gocui.Gui, _ *gocui.View) error {
+	purged := 0
+	for _, task := range u.trash {
+		if err := u.store.PurgeTask(context.Background(), task.ID); err != nil {
+			u.status = err.Error()
+			return u.loadTrash()
+		}
+		purged++
+	}
+	u.status = fmt.Sprintf("Permanently deleted %d tasks", purged)
+	return u.loadTrash()
+}
+
+func (u *UI) showTrash(gui *gocui.Gui) error {
+	maxX, maxY := gui.Size()
+	width := max(60, maxX*2/3)
+	height := max(min(len(u.trash)+3, maxY-4), 5)
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width
+	y1 := y0 + height
+
+	view, err := gui.SetView(viewTrash, x0, y0, x1, y1, 0)
+	if err != nil && !goerrors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if goerrors.Is(err, gocui.ErrUnknownView) {
+		view.Title = "Trash"
+		view.Footer = "enter restore | d delete forever | D empty trash | esc close"
+	}
+	view.FrameRunes = roundedFrameRunes
+	view.Clear()
+	if len(u.trash) == 0 {
+		fmt.Fprintln(view, "  Trash is empty.")
+	}
+	for index, task := range u.trash {
+		prefix := " "
+		if index == u.selectedTrash {
+			prefix = ">"
+		}
+		deleted := ""
+		if task.DeletedAt != nil {
+			deleted = task.DeletedAt.In(u.store.Location()).Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(view, "%s %s  #%d %s\n", prefix, deleted, task.ID, task.Title)
+	}
+	ensureSelectionVisible(view, u.selectedTrash, len(u.trash))
+	setCursorToSelection(view, u.selectedTrash, len(u.trash))
+	_, _ = gui.SetViewOnTop(viewTrash)
+	_, _ = gui.SetCurrentView(viewTrash)
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
 	"github.com/Joseda-hg/lazytask/internal/model"
+	"github.com/Joseda-hg/lazytask/internal/taskform"
 	goerrors "github.com/go-errors/errors"
 	"github.com/jesseduffield/gocui"
 )
@@ -25,13 +28,27 @@ const (
 	viewForm        = "form"
 	viewHelp        = "help"
 	viewTagCreate   = "tagCreate"
+	viewViews       = "views"
+	viewViewName    = "viewName"
+	viewTrash       = "trash"
+	viewRevert      = "revert"
+	viewAgenda      = "agenda"
 )
 
 var roundedFrameRunes = []rune{'─', '│', '╭', '╮', '╰', '╯'}
 
+// Options configures the TUI.
+type Options struct {
+	// PomodoroWork and PomodoroBreak are the lengths of a focus session and
+	// of the break after it.
+	PomodoroWork  time.Duration
+	PomodoroBreak time.Duration
+}
+
 type UI struct {
-	store *db.Store
-	gui   *gocui.Gui
+	store   *db.Store
+	gui     *gocui.Gui
+	options Options
 
 	filter     model.Filter
 	activeView *model.View
@@ -48,14 +65,23 @@ type UI struct {
 	eventuallyDepth       map[int64]int
 	eventuallyHasChildren map[int64]bool
 
-	tags    []tagCountEntry
-	doing   []model.Task
-	history []model.HistoryEntry
+	tags     []tagCountEntry
+	doing    []model.Task
+	history  []model.HistoryEntry
+	snippets map[int64]string
 
 	historyVisible bool
+	historyMode    string
+	historyTitles  map[int64]string
 	collapsed      map[int64]bool
 	moveActive     bool
 	moveTaskID     int64
+	blockActive    bool
+	blockTaskID    int64
+	dependencies   []model.Dependency
+	blocked        map[int64]bool
+	pomodoro       *pomodoroState
+	pomodoros      map[int64]int64
 
 	selectedPending    int
 	selectedDone       int
@@ -73,6 +99,29 @@ type UI struct {
 	tagCreateActive bool
 	tagCreateValue  string
 	status          string
+
+	views          []model.View
+	selectedView   int
+	viewsActive    bool
+	viewNameActive bool
+	viewNameID     int64
+	viewNameValue  string
+
+	trash         []model.Task
+	selectedTrash int
+	trashActive   bool
+
+	agenda         []model.Task
+	selectedAgenda int
+	agendaActive   bool
+
+	revertActive   bool
+	revertEntry    model.HistoryEntry
+	revertOptions  []revertOption
+	selectedRevert int
+
+	undoStack []undoEntry
+	redoStack []undoEntry
 }
 
 type formState struct {
@@ -87,7 +136,7 @@ type formEditor struct {
 	ui *UI
 }
 
-func Run(store *db.Store) error {
+func Run(store *db.Store, options Options) error {
 	gui, err := gocui.NewGui(gocui.NewGuiOpts{OutputMode: gocui.OutputNormal})
 	if err != nil {
 		return err
@@ -97,6 +146,7 @@ func Run(store *db.Store) error {
 	ui := &UI{
 		store:          store,
 		gui:            gui,
+		options:        options,
 		focus:          viewPending,
 		activeTags:     make(map[string]struct{}),
 		historyVisible: true,
@@ -109,10 +159,17 @@ func Run(store *db.Store) error {
 	if err := ui.bindKeys(gui); err != nil {
 		return err
 	}
+	if err := ui.restoreActiveView(); err != nil {
+		return err
+	}
 	if err := ui.loadTasks(); err != nil {
 		return err
 	}
 
+	done := make(chan struct{})
+	defer close(done)
+	go ui.runPomodoroClock(done)
+
 	if err := gui.MainLoop(); err != nil && err != gocui.ErrQuit {
 		return err
 	}
@@ -157,10 +214,16 @@ func (u *UI) bindKeys(gui *gocui.Gui) error {
 	if err := gui.SetKeybinding("", 'm', gocui.ModNone, u.toggleMoveMode); err != nil {
 		return err
 	}
-	if err := gui.SetKeybinding("", 'u', gocui.ModNone, u.unparentMoveTask); err != nil {
+	if err := gui.SetKeybinding("", 'b', gocui.ModNone, u.toggleBlockMode); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", 'u', gocui.ModNone, u.undoOrUnparent); err != nil {
 		return err
 	}
-	if err := gui.SetKeybinding("", gocui.KeyEsc, gocui.ModNone, u.cancelMoveMode); err != nil {
+	if err := gui.SetKeybinding("", gocui.KeyCtrlR, gocui.ModNone, u.redo); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", gocui.KeyEsc, gocui.ModNone, u.cancelPickMode); err != nil {
 		return err
 	}
 	if err := gui.SetKeybinding("", 'h', gocui.ModNone, u.refreshHistory); err != nil {
@@ -169,12 +232,33 @@ func (u *UI) bindKeys(gui *gocui.Gui) error {
 	if err := gui.SetKeybinding("", 'H', gocui.ModNone, u.toggleHistoryPane); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("", 'A', gocui.ModNone, u.cycleHistoryMode); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding("", '/', gocui.ModNone, u.startSearch); err != nil {
 		return err
 	}
 	if err := gui.SetKeybinding("", '?', gocui.ModNone, u.toggleHelp); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding("", 'P', gocui.ModNone, u.togglePomodoro); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", 'T', gocui.ModNone, u.openTrash); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", 'D', gocui.ModNone, u.openAgenda); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", 'V', gocui.ModNone, u.openViews); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", ']', gocui.ModNone, u.cycleView(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding("", '[', gocui.ModNone, u.cycleView(-1)); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding("", gocui.KeyTab, gocui.ModNone, u.switchFocus); err != nil {
 		return err
 	}
@@ -259,6 +343,9 @@ func (u *UI) bindKeys(gui *gocui.Gui) error {
 	if err := gui.SetKeybinding(viewTags, gocui.KeyEnter, gocui.ModNone, u.toggleTagFilter); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding(viewTags, 'o', gocui.ModNone, u.toggleTagMatch); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding(viewTags, 'a', gocui.ModNone, u.openTagCreate); err != nil {
 		return err
 	}
@@ -277,6 +364,9 @@ func (u *UI) bindKeys(gui *gocui.Gui) error {
 	if err := gui.SetKeybinding(viewHistory, 'k', gocui.ModNone, u.moveUp); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding(viewHistory, gocui.KeyEnter, gocui.ModNone, u.openRevert); err != nil {
+		return err
+	}
 	if err := gui.SetKeybinding(viewSearch, gocui.KeyEnter, gocui.ModNone, u.submitSearch); err != nil {
 		return err
 	}
@@ -319,6 +409,114 @@ func (u *UI) bindKeys(gui *gocui.Gui) error {
 	if err := gui.SetKeybinding(viewTagCreate, gocui.KeyEsc, gocui.ModNone, u.cancelTagCreate); err != nil {
 		return err
 	}
+	if err := gui.SetKeybinding(viewViews, gocui.KeyArrowDown, gocui.ModNone, u.moveViewSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 'j', gocui.ModNone, u.moveViewSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, gocui.KeyArrowUp, gocui.ModNone, u.moveViewSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 'k', gocui.ModNone, u.moveViewSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, gocui.KeyEnter, gocui.ModNone, u.applySelectedView); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 'n', gocui.ModNone, u.startViewCreate); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 's', gocui.ModNone, u.overwriteSelectedView); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 'R', gocui.ModNone, u.startViewRename); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 'd', gocui.ModNone, u.deleteSelectedView); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, gocui.KeyEsc, gocui.ModNone, u.closeViews); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViews, 'q', gocui.ModNone, u.closeViews); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, gocui.KeyArrowDown, gocui.ModNone, u.moveTrashSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, 'j', gocui.ModNone, u.moveTrashSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, gocui.KeyArrowUp, gocui.ModNone, u.moveTrashSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, 'k', gocui.ModNone, u.moveTrashSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, gocui.KeyEnter, gocui.ModNone, u.restoreSelectedTrash); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, 'd', gocui.ModNone, u.purgeSelectedTrash); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, 'D', gocui.ModNone, u.emptyTrash); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, gocui.KeyEsc, gocui.ModNone, u.closeTrash); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewTrash, 'q', gocui.ModNone, u.closeTrash); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, gocui.KeyArrowDown, gocui.ModNone, u.moveAgendaSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, 'j', gocui.ModNone, u.moveAgendaSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, gocui.KeyArrowUp, gocui.ModNone, u.moveAgendaSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, 'k', gocui.ModNone, u.moveAgendaSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, gocui.KeyEnter, gocui.ModNone, u.jumpToAgendaTask); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, gocui.KeyEsc, gocui.ModNone, u.closeAgenda); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewAgenda, 'q', gocui.ModNone, u.closeAgenda); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, gocui.KeyArrowDown, gocui.ModNone, u.moveRevertSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, 'j', gocui.ModNone, u.moveRevertSelection(1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, gocui.KeyArrowUp, gocui.ModNone, u.moveRevertSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, 'k', gocui.ModNone, u.moveRevertSelection(-1)); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, gocui.KeyEnter, gocui.ModNone, u.applyRevert); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, gocui.KeyEsc, gocui.ModNone, u.closeRevert); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewRevert, 'q', gocui.ModNone, u.closeRevert); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViewName, gocui.KeyEnter, gocui.ModNone, u.submitViewName); err != nil {
+		return err
+	}
+	if err := gui.SetKeybinding(viewViewName, gocui.KeyEsc, gocui.ModNone, u.closeViewName); err != nil {
+		return err
+	}
 	if err := gui.SetViewClickBinding(&gocui.ViewMouseBinding{ViewName: viewPending, Key: gocui.MouseLeft, Handler: func(opts gocui.ViewMouseBindingOpts) error {
 		return u.onListClick(gui, viewPending, opts)
 	}}); err != nil {
@@ -484,8 +682,9 @@ func (u *UI) layout(gui *gocui.Gui) error {
 		if err != nil && !goerrors.Is(err, gocui.ErrUnknownView) {
 			return err
 		}
-		if goerrors.Is(err, gocui.ErrUnknownView) {
-			historyView.Title = "[6] - History"
+		historyView.Title = "[6] - History"
+		if u.historyMode != historyModeTask {
+			historyView.Title = "[6] - Activity (" + historyModeTitle(u.historyMode) + ")"
 		}
 
 		applyViewStyle(historyView, u.focus == viewHistory, true)
@@ -521,11 +720,51 @@ func (u *UI) layout(gui *gocui.Gui) error {
 		_ = gui.DeleteView(viewTagCreate)
 	}
 
+	if u.viewsActive {
+		if err := u.showViews(gui); err != nil {
+			return err
+		}
+	} else {
+		_ = gui.DeleteView(viewViews)
+	}
+
+	if u.viewNameActive {
+		if err := u.showViewName(gui); err != nil {
+			return err
+		}
+	} else {
+		_ = gui.DeleteView(viewViewName)
+	}
+
+	if u.trashActive {
+		if err := u.showTrash(gui); err != nil {
+			return err
+		}
+	} else {
+		_ = gui.DeleteView(viewTrash)
+	}
+
+	if u.revertActive {
+		if err := u.showRevert(gui); err != nil {
+			return err
+		}
+	} else {
+		_ = gui.DeleteView(viewRevert)
+	}
+
+	if u.agendaActive {
+		if err := u.showAgenda(gui); err != nil {
+			return err
+		}
+	} else {
+		_ = gui.DeleteView(viewAgenda)
+	}
+
 	if gui.CurrentView() == nil {
 		_, _ = gui.SetCurrentView(u.focus)
 	}
 
-	gui.Cursor = u.searchActive || u.form != nil || u.tagCreateActive
+	gui.Cursor = u.searchActive || u.form != nil || u.tagCreateActive || u.viewNameActive
 
 	return nil
 }
@@ -608,11 +847,20 @@ func computeLayout(width, height int, historyVisible bool) layout {
 }
 
 func (u *UI) loadTasks() error {
-	tasks, err := u.store.ListTasks(context.Background(), u.filter)
+	results, err := u.store.SearchTasks(context.Background(), u.filter)
 	if err != nil {
 		return err
 	}
 
+	tasks := make([]model.Task, 0, len(results))
+	snippets := make(map[int64]string)
+	for _, result := range results {
+		tasks = append(tasks, result.Task)
+		if result.Snippet != "" {
+			snippets[result.Task.ID] = result.Snippet
+		}
+	}
+
 	allTags, err := u.store.ListTags(context.Background())
 	if err != nil {
 		return err
@@ -676,6 +924,7 @@ func (u *UI) loadTasks() error {
 
 	u.tags = entries
 	u.doing = doing
+	u.snippets = snippets
 
 	if u.selectedPending >= len(u.pending) {
 		u.selectedPending = max(len(u.pending)-1, 0)
@@ -693,10 +942,23 @@ func (u *UI) loadTasks() error {
 		u.formTagIndex = max(len(u.tags)-1, 0)
 	}
 
+	if err := u.loadDependencies(); err != nil {
+		return err
+	}
+	if err := u.loadAgenda(); err != nil {
+		return err
+	}
+	if u.pomodoros, err = u.store.PomodoroCounts(context.Background()); err != nil {
+		return err
+	}
 	return u.loadHistory()
 }
 
 func (u *UI) loadHistory() error {
+	if u.historyMode != historyModeTask {
+		return u.loadActivity()
+	}
+
 	selected := u.selectedTask()
 	if selected == nil {
 		u.history = nil
@@ -724,6 +986,9 @@ func (u *UI) renderHeader(view *gocui.View) {
 	viewLabel := "none"
 	if u.activeView != nil {
 		viewLabel = u.activeView.Name
+		if u.viewModified() {
+			viewLabel += "*"
+		}
 	}
 
 	statusLabel := u.filter.Status
@@ -734,6 +999,9 @@ func (u *UI) renderHeader(view *gocui.View) {
 	tagsLabel := "none"
 	if len(u.filter.Tags) > 0 {
 		tagsLabel = strings.Join(u.filter.Tags, ",")
+		if len(u.filter.Tags) > 1 {
+			tagsLabel += " (" + tagMatchLabel(u.filter.TagMatch) + ")"
+		}
 	}
 
 	dueLabel := "any"
@@ -750,6 +1018,12 @@ func (u *UI) renderHeader(view *gocui.View) {
 	}
 
 	fmt.Fprintf(view, "Search: %s | View: %s | Status: %s | Tags: %s | Due: %s", query, viewLabel, statusLabel, tagsLabel, dueLabel)
+	if overdue := u.overdueCount(u.now()); overdue > 0 {
+		fmt.Fprintf(view, " | \x1b[31mOverdue: %d\x1b[0m", overdue)
+	}
+	if label := u.pomodoroLabel(time.Now()); label != "" {
+		fmt.Fprintf(view, " | %s", label)
+	}
 }
 
 func (u *UI) renderFooter(view *gocui.View) {
@@ -757,8 +1031,8 @@ func (u *UI) renderFooter(view *gocui.View) {
 	view.SetOrigin(0, 0)
 	view.SetCursor(0, 0)
 
-	fmt.Fprintln(view, "a add | s subtask | e edit | d delete | m move | enter collapse/save | c current | x done | v eventually")
-	fmt.Fprintln(view, "/ search | space tag | tab field | h refresh history | H toggle history | r reload | g clear | tab cycle | 1-6 panes | q quit")
+	fmt.Fprintln(view, "a add | s subtask | e edit | d delete | m move | b block | enter collapse/save | c current | x done | v eventually")
+	fmt.Fprintln(view, "u undo | ctrl+r redo | P focus | D agenda | T trash | / search | V views | [/] switch view | space tag | tab field | h refresh history | H toggle history | A activity | r reload | g clear | tab cycle | 1-6 panes | q quit")
 	if u.status != "" {
 		fmt.Fprint(view, u.status)
 	}
@@ -766,6 +1040,7 @@ func (u *UI) renderFooter(view *gocui.View) {
 
 func (u *UI) renderTaskList(view *gocui.View, tasks []model.Task, selected int, focused bool, depthByID map[int64]int, hasChildrenByID map[int64]bool) {
 	view.Clear()
+	now := u.now()
 	for i, task := range tasks {
 		prefix := " "
 		if i == selected {
@@ -791,7 +1066,12 @@ func (u *UI) renderTaskList(view *gocui.View, tasks []model.Task, selected int,
 			}
 		}
 
-		fmt.Fprintf(view, "%s %s%s %s\n", prefix, indent, marker, formatTaskSummary(task))
+		summary := formatTaskSummary(task)
+		if u.blocked[task.ID] {
+			summary += " [blocked]"
+		}
+		summary += dueMarker(task, now)
+		fmt.Fprintf(view, "%s %s%s %s\n", prefix, indent, marker, summary)
 	}
 	if focused {
 		ensureSelectionVisible(view, selected, len(tasks))
@@ -901,6 +1181,9 @@ func (u *UI) scrollDown(gui *gocui.Gui, view *gocui.View) error {
 func (u *UI) renderHighlighted(view *gocui.View) {
 	view.Clear()
 	selected := u.selectedTask()
+	if u.focus == viewHistory && u.historyMode != historyModeTask {
+		selected = u.historyTask()
+	}
 	if selected == nil {
 		fmt.Fprint(view, "No task selected")
 		return
@@ -908,7 +1191,7 @@ func (u *UI) renderHighlighted(view *gocui.View) {
 
 	due := "n/a"
 	if selected.DueAt != nil {
-		due = selected.DueAt.Format("2006-01-02")
+		due = duedate.Format(*selected.DueAt, selected.DueAllDay, u.store.Location())
 	}
 
 	lines := []string{}
@@ -916,9 +1199,10 @@ func (u *UI) renderHighlighted(view *gocui.View) {
 		if entry := u.selectedHistoryEntry(); entry != nil {
 			lines = append(lines,
 				"History Detail",
-				fmt.Sprintf("When: %s", entry.CreatedAt.Format("2006-01-02 15:04:05")),
+				fmt.Sprintf("When: %s", entry.CreatedAt.In(u.store.Location()).Format("2006-01-02 15:04:05")),
 				fmt.Sprintf("Type: %s", entry.EventType),
-				fmt.Sprintf("Details: %s", entry.Details),
+				fmt.Sprintf("Details: %s", entry.Details(u.store.Location())),
+				fmt.Sprintf("By: %s", historyOrigin(*entry)),
 				"",
 				"Task",
 			)
@@ -933,9 +1217,24 @@ func (u *UI) renderHighlighted(view *gocui.View) {
 		fmt.Sprintf("Priority: %d", selected.Priority),
 		fmt.Sprintf("Due: %s", due),
 		fmt.Sprintf("Tags: %s", formatTags(selected.Tags)),
+	)
+	if rule, err := model.ParseRecurrence(selected.Recurrence); err == nil {
+		lines = append(lines, fmt.Sprintf("Repeats: %s", rule.Describe()))
+	}
+	if tracked := u.trackedTimeLabel(selected.ID); tracked != "" {
+		lines = append(lines, fmt.Sprintf("Time: %s", tracked))
+	}
+	if count := u.pomodoros[selected.ID]; count > 0 {
+		lines = append(lines, fmt.Sprintf("Pomodoros: %d", count))
+	}
+	if snippet, ok := u.snippets[selected.ID]; ok {
+		lines = append(lines, fmt.Sprintf("Match: %s", formatSnippet(snippet)))
+	}
+	lines = append(lines,
 		"",
 		selected.Description,
 	)
+	lines = append(lines, u.dependencyLines(selected.ID)...)
 
 	others := u.otherDoingTasks(selected.ID)
 	if len(others) > 0 {
@@ -943,13 +1242,14 @@ func (u *UI) renderHighlighted(view *gocui.View) {
 		for _, task := range others {
 			dueLabel := "n/a"
 			if task.DueAt != nil {
-				dueLabel = task.DueAt.Format("2006-01-02")
+				dueLabel = duedate.Format(*task.DueAt, task.DueAllDay, u.store.Location())
 			}
 			lines = append(lines,
 				fmt.Sprintf("- %s", task.Title),
 				fmt.Sprintf("  Status: %s", task.Status),
 				fmt.Sprintf("  Due: %s", dueLabel),
 				fmt.Sprintf("  Tags: %s", formatTags(task.Tags)),
+				fmt.Sprintf("  Pomodoros: %d", u.pomodoros[task.ID]),
 				fmt.Sprintf("  %s", strings.TrimSpace(task.Description)),
 			)
 		}
@@ -969,7 +1269,11 @@ func (u *UI) renderHistory(view *gocui.View, focused bool) {
 				prefix = "*"
 			}
 		}
-		fmt.Fprintf(view, "%s %s | %s | %s\n", prefix, entry.CreatedAt.Format("2006-01-02 15:04"), entry.EventType, entry.Details)
+		if u.historyMode != historyModeTask {
+			fmt.Fprintf(view, "%s %s | %s | %s\n", prefix, entry.CreatedAt.In(u.store.Location()).Format("2006-01-02 15:04"), u.historyTitles[entry.TaskID], entry.Details(u.store.Location()))
+			continue
+		}
+		fmt.Fprintf(view, "%s %s | %s | %s\n", prefix, entry.CreatedAt.In(u.store.Location()).Format("2006-01-02 15:04"), entry.EventType, entry.Details(u.store.Location()))
 	}
 	if focused {
 		ensureSelectionVisible(view, u.selectedHistory, len(u.history))
@@ -977,6 +1281,19 @@ func (u *UI) renderHistory(view *gocui.View, focused bool) {
 	}
 }
 
+// historyOrigin describes who made a history entry and from where.
+func historyOrigin(entry model.HistoryEntry) string {
+	switch {
+	case entry.Actor != "" && entry.Source != "":
+		return fmt.Sprintf("%s (%s)", entry.Actor, entry.Source)
+	case entry.Actor != "":
+		return entry.Actor
+	case entry.Source != "":
+		return entry.Source
+	}
+	return "unknown"
+}
+
 func (u *UI) selectedHistoryEntry() *model.HistoryEntry {
 	if u.selectedHistory >= 0 && u.selectedHistory < len(u.history) {
 		return &u.history[u.selectedHistory]
@@ -1178,10 +1495,14 @@ func (u *UI) clearFilters(gui *gocui.Gui, _ *gocui.View) error {
 	}
 	u.filter.Query = ""
 	u.filter.Tags = nil
+	u.filter.TagMatch = ""
 	u.filter.Status = ""
 	u.filter.DueAfter = nil
 	u.filter.DueBefore = nil
 	u.activeTags = make(map[string]struct{})
+	if u.activeView != nil {
+		return u.activateView(nil)
+	}
 	return u.reload(gui, nil)
 }
 
@@ -1253,7 +1574,7 @@ func (u *UI) closeTagCreate(gui *gocui.Gui) error {
 }
 
 func (u *UI) toggleMoveMode(gui *gocui.Gui, _ *gocui.View) error {
-	if u.inputActive() {
+	if u.inputActive() || u.blockActive {
 		return nil
 	}
 	if !u.moveActive {
@@ -1273,6 +1594,14 @@ func (u *UI) toggleMoveMode(gui *gocui.Gui, _ *gocui.View) error {
 	return u.completeMove(gui)
 }
 
+// cancelPickMode leaves move or block mode, whichever is active.
+func (u *UI) cancelPickMode(gui *gocui.Gui, view *gocui.View) error {
+	if u.blockActive {
+		return u.cancelBlockMode(gui, view)
+	}
+	return u.cancelMoveMode(gui, view)
+}
+
 func (u *UI) cancelMoveMode(_ *gocui.Gui, _ *gocui.View) error {
 	if u.inputActive() || !u.moveActive {
 		return nil
@@ -1326,20 +1655,26 @@ func (u *UI) moveTask(gui *gocui.Gui, parentID *int64, targetView string) error
 		u.status = err.Error()
 		return nil
 	}
-	input := taskInputFromTask(task)
+	input := db.InputFromTask(task)
 	if targetView != "" {
 		input.Status = statusForView(targetView)
 		input.ParentTaskID = nil
 	} else {
 		input.ParentTaskID = parentID
 	}
+	changes, err := u.captureTasks(task.ID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	if _, err := u.store.UpdateTask(context.Background(), task.ID, input); err != nil {
 		u.status = err.Error()
 		return nil
 	}
+	u.status = ""
+	u.recordUndo(undoEntry{label: taskLabel("move", task), changes: changes})
 	u.moveActive = false
 	u.moveTaskID = 0
-	u.status = ""
 	return u.loadTasks()
 }
 
@@ -1430,6 +1765,10 @@ func (u *UI) showSearch(gui *gocui.Gui) error {
 
 func (u *UI) submitSearch(gui *gocui.Gui, view *gocui.View) error {
 	value := strings.TrimSpace(view.Buffer())
+	if _, err := db.ParseQuery(value); err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	u.filter.Query = value
 	u.searchActive = false
 	u.status = ""
@@ -1449,7 +1788,7 @@ func (u *UI) addTask(gui *gocui.Gui, _ *gocui.View) error {
 	if u.inputActive() || u.moveActive {
 		return nil
 	}
-	fields := buildFormFields(nil)
+	fields := buildFormFields(nil, u.store.Location())
 	if u.focus == viewEventually {
 		fields[fieldStatus].Value = "eventually"
 	}
@@ -1467,9 +1806,9 @@ func (u *UI) addSubtask(gui *gocui.Gui, _ *gocui.View) error {
 		return nil
 	}
 
-	fields := buildFormFields(nil)
+	fields := buildFormFields(nil, u.store.Location())
 	fields[fieldStatus].Value = selected.Status
-	fields[fieldTags].Value = joinTags(selected.Tags)
+	fields[fieldTags].Value = taskform.JoinTags(selected.Tags)
 	parentID := selected.ID
 	u.form = &formState{fields: fields, parentTaskID: &parentID, cursors: initFormCursors(fields)}
 	u.formTagIndex = 0
@@ -1484,7 +1823,7 @@ func (u *UI) editTask(gui *gocui.Gui, _ *gocui.View) error {
 	if selected == nil {
 		return nil
 	}
-	fields := buildFormFields(selected)
+	fields := buildFormFields(selected, u.store.Location())
 	u.form = &formState{taskID: selected.ID, fields: fields, cursors: initFormCursors(fields)}
 	u.formTagIndex = 0
 	return nil
@@ -1535,27 +1874,40 @@ func (u *UI) submitFormNow(gui *gocui.Gui, view *gocui.View) error {
 		return nil
 	}
 
-	input, err := parseFormFields(u.form.fields)
+	input, err := parseFormFields(u.form.fields, u.store.Location())
 	if err != nil {
 		u.status = err.Error()
 		return nil
 	}
 	input.ParentTaskID = u.form.parentTaskID
 
+	u.status = ""
 	if u.form.taskID == 0 {
-		if _, err := u.store.CreateTask(context.Background(), input); err != nil {
+		created, err := u.store.CreateTask(context.Background(), input)
+		if err != nil {
 			u.status = err.Error()
 			return nil
 		}
+		u.recordUndo(undoEntry{label: taskLabel("create", created), changes: []taskChange{{taskID: created.ID, created: true}}})
 	} else {
-		if _, err := u.store.UpdateTask(context.Background(), u.form.taskID, input); err != nil {
+		changes, err := u.captureTasks(u.form.taskID)
+		if err != nil {
+			u.status = err.Error()
+			return nil
+		}
+		updated, err := u.store.UpdateTask(context.Background(), u.form.taskID, input)
+		if err != nil {
 			u.status = err.Error()
 			return nil
 		}
+		if changes, err = u.withOccurrences(changes, updated.ID); err != nil {
+			u.status = err.Error()
+			return nil
+		}
+		u.recordUndo(undoEntry{label: taskLabel("edit", updated), changes: changes})
 	}
 
 	u.form = nil
-	u.status = ""
 	_ = gui.DeleteView(viewForm)
 	_, _ = gui.SetCurrentView(u.focus)
 	return u.loadTasks()
@@ -1602,13 +1954,7 @@ func (u *UI) renderForm(view *gocui.View) {
 		if index == u.form.index {
 			prefix = "> "
 		}
-		value := sanitizeFormValue(field.Value)
-		if isTagsField(field.Label) {
-			candidate := u.currentTagOption()
-			if candidate != "" {
-				value = fmt.Sprintf("%s [pick: %s]", value, candidate)
-			}
-		}
+		value := sanitizeFormValue(field.Value) + u.formFieldHint(index)
 		fmt.Fprintf(view, "%s%s: %s\n", prefix, field.Label, value)
 	}
 	width, _ := view.InnerSize()
@@ -1678,6 +2024,22 @@ func (e *formEditor) Edit(view *gocui.View, key gocui.Key, ch rune, mod gocui.Mo
 	return true
 }
 
+// formFieldHint is shown after the value of a form field: the tag the tag
+// field would pick next, or the date the due field resolves to.
+func (u *UI) formFieldHint(index int) string {
+	switch index {
+	case fieldTags:
+		if candidate := u.currentTagOption(); candidate != "" {
+			return fmt.Sprintf(" [pick: %s]", candidate)
+		}
+	case fieldDue:
+		if preview := duePreview(u.form.fields[index].Value, u.store.Location()); preview != "" {
+			return " " + preview
+		}
+	}
+	return ""
+}
+
 func isStatusField(label string) bool {
 	return strings.HasPrefix(label, "Status")
 }
@@ -1780,13 +2142,11 @@ func (u *UI) deleteRuneAtCursor(field *formField, index int) {
 }
 
 func nextStatus(current string) string {
-	order := []string{"todo", "doing", "eventually", "done"}
-	return cycleStatus(order, current, 1)
+	return cycleStatus(model.Statuses, current, 1)
 }
 
 func prevStatus(current string) string {
-	order := []string{"todo", "doing", "eventually", "done"}
-	return cycleStatus(order, current, -1)
+	return cycleStatus(model.Statuses, current, -1)
 }
 
 func cycleStatus(order []string, current string, delta int) string {
@@ -1828,7 +2188,7 @@ func (u *UI) toggleTagInField(field *formField) {
 	}
 
 	selected := make(map[string]struct{})
-	for _, name := range parseTags(field.Value) {
+	for _, name := range taskform.ParseTags(field.Value) {
 		selected[name] = struct{}{}
 	}
 
@@ -1878,11 +2238,17 @@ func (u *UI) deleteTask(gui *gocui.Gui, _ *gocui.View) error {
 	if selected == nil {
 		return nil
 	}
+	changes, err := u.captureTasks(selected.ID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	if err := u.store.DeleteTask(context.Background(), selected.ID); err != nil {
 		u.status = err.Error()
 		return nil
 	}
 	u.status = ""
+	u.recordUndo(undoEntry{label: taskLabel("delete", *selected), changes: changes})
 	return u.loadTasks()
 }
 
@@ -1894,6 +2260,16 @@ func (u *UI) deleteTag(gui *gocui.Gui, _ *gocui.View) error {
 		return nil
 	}
 	entry := u.tags[u.selectedTags]
+	taskIDs, err := u.tasksWithTag(entry.Name)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	changes, err := u.captureTasks(taskIDs...)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	if err := u.store.DeleteTag(context.Background(), entry.ID); err != nil {
 		u.status = err.Error()
 		return nil
@@ -1901,6 +2277,7 @@ func (u *UI) deleteTag(gui *gocui.Gui, _ *gocui.View) error {
 	delete(u.activeTags, entry.Name)
 	u.filter.Tags = u.activeTagList()
 	u.status = ""
+	u.recordUndo(undoEntry{label: fmt.Sprintf("delete tag %q", entry.Name), changes: changes, deletedTag: entry.Name})
 	return u.loadTasks()
 }
 
@@ -1942,17 +2319,23 @@ func (u *UI) toggleDoing(gui *gocui.Gui, _ *gocui.View) error {
 	if selected == nil {
 		return nil
 	}
-	input := taskInputFromTask(*selected)
+	input := db.InputFromTask(*selected)
 	if selected.Status == "doing" {
 		input.Status = "todo"
 	} else {
 		input.Status = "doing"
 	}
+	changes, err := u.captureTasks(selected.ID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	if _, err := u.store.UpdateTask(context.Background(), selected.ID, input); err != nil {
 		u.status = err.Error()
 		return nil
 	}
 	u.status = ""
+	u.recordUndo(undoEntry{label: taskLabel("toggle current", *selected), changes: changes})
 	return u.loadTasks()
 }
 
@@ -1964,17 +2347,27 @@ func (u *UI) toggleDone(gui *gocui.Gui, _ *gocui.View) error {
 	if selected == nil {
 		return nil
 	}
-	input := taskInputFromTask(*selected)
+	input := db.InputFromTask(*selected)
 	if selected.Status == "done" {
 		input.Status = "todo"
 	} else {
 		input.Status = "done"
 	}
+	changes, err := u.captureTasks(selected.ID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	if _, err := u.store.UpdateTask(context.Background(), selected.ID, input); err != nil {
 		u.status = err.Error()
 		return nil
 	}
+	if changes, err = u.withOccurrences(changes, selected.ID); err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	u.status = ""
+	u.recordUndo(undoEntry{label: taskLabel("toggle done", *selected), changes: changes})
 	return u.loadTasks()
 }
 
@@ -1986,17 +2379,23 @@ func (u *UI) toggleEventually(gui *gocui.Gui, _ *gocui.View) error {
 	if selected == nil {
 		return nil
 	}
-	input := taskInputFromTask(*selected)
+	input := db.InputFromTask(*selected)
 	if selected.Status == "eventually" {
 		input.Status = "todo"
 	} else {
 		input.Status = "eventually"
 	}
+	changes, err := u.captureTasks(selected.ID)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
 	if _, err := u.store.UpdateTask(context.Background(), selected.ID, input); err != nil {
 		u.status = err.Error()
 		return nil
 	}
 	u.status = ""
+	u.recordUndo(undoEntry{label: taskLabel("toggle eventually", *selected), changes: changes})
 	return u.loadTasks()
 }
 
@@ -2017,6 +2416,25 @@ func (u *UI) toggleTagFilter(gui *gocui.Gui, _ *gocui.View) error {
 	return u.reload(gui, nil)
 }
 
+func (u *UI) toggleTagMatch(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.focus != viewTags {
+		return nil
+	}
+	if u.filter.TagMatch == model.TagMatchAll {
+		u.filter.TagMatch = model.TagMatchAny
+	} else {
+		u.filter.TagMatch = model.TagMatchAll
+	}
+	return u.reload(gui, nil)
+}
+
+func tagMatchLabel(mode string) string {
+	if mode == model.TagMatchAll {
+		return "all"
+	}
+	return "any"
+}
+
 func (u *UI) activeTagList() []string {
 	result := make([]string, 0, len(u.activeTags))
 	for name := range u.activeTags {
@@ -2032,7 +2450,7 @@ func (u *UI) isTagActive(name string) bool {
 }
 
 func (u *UI) inputActive() bool {
-	return u.searchActive || u.form != nil || u.helpActive || u.tagCreateActive
+	return u.searchActive || u.form != nil || u.helpActive || u.tagCreateActive || u.viewsActive || u.viewNameActive || u.trashActive || u.revertActive || u.agendaActive
 }
 
 func (u *UI) taskByID(taskID int64) (model.Task, error) {
@@ -2101,16 +2519,35 @@ func helpText() string {
 		"Actions:",
 		"  a add task | s add subtask | e edit task | d delete task/tag | m move",
 		"  c current | x toggle done | v eventually",
+		"  u undo | ctrl+r redo | T trash",
+		"  P start/stop a focus session (pomodoro) on the selected task",
+		"  enter restore | d delete forever | D empty trash (Trash)",
+		"  enter revert the task or one field to the selected entry (History pane)",
 		"  enter collapse/expand (lists) | enter save (form) | tab next field",
 		"",
 		"Move:",
 		"  m pick/drop task | 1/2/5 move to pane | u unparent | esc cancel",
 		"",
+		"Agenda:",
+		"  D open tasks by deadline: overdue, today, tomorrow, this week, later",
+		"  enter go to the task in its pane | esc/q close",
+		"",
+		"Dependencies:",
+		"  b pick task | b on its blocker adds or removes it | esc cancel",
+		"",
 		"Search/Filter:",
 		"  / search | g clear filters",
+		"  words match prefixes | \"exact phrase\" | AND/OR/NOT | -term excludes | ( ) group",
+		"  status:doing tag:work,home due<2026-11-01 due:none prio>=2 parent:42 parent:none",
+		"  is:ready (no unfinished blockers) | is:blocked",
+		"",
+		"Views:",
+		"  V open views | [/] previous/next view | g clear filters and view",
+		"  n save current filters | s overwrite | R rename | d delete (views picker)",
 		"",
 		"Tags:",
 		"  space toggle tag filter (Tags pane)",
+		"  o match any/all selected tags (Tags pane)",
 		"  a add tag (Tags pane)",
 		"  d delete tag (Tags pane)",
 		"  space/left/right cycle tags (form)",
@@ -2119,7 +2556,7 @@ func helpText() string {
 		"  space/left/right cycle status (form)",
 		"",
 		"Other:",
-		"  h refresh history | H toggle history pane | r reload | ? help | esc/q close help | q quit",
+		"  h refresh history | H toggle history pane | A cycle history, today's and this week's activity | r reload | ? help | esc/q close help | q quit",
 	}, "\n")
 }
 
@@ -2246,7 +2683,7 @@ func (u *UI) formCursorPosition(width int) (int, int) {
 		}
 		label := field.Label + ": "
 		value := sanitizeFormValue(field.Value)
-		lineRunes := []rune(prefix + label + value)
+		lineRunes := []rune(prefix + label + value + u.formFieldHint(i))
 		segments := wrapSegments(lineRunes, width)
 		lines := len(segments)
 		if i == u.form.index {
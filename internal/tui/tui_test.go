@@ -2,9 +2,13 @@ package tui
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
 	"github.com/Joseda-hg/lazytask/internal/model"
 )
 
@@ -63,6 +67,140 @@ func TestDeleteTagRemovesTagAndFilter(t *testing.T) {
 	}
 }
 
+func TestTagFilterComposesWithStatusAndMatchMode(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	inputs := []db.TaskInput{
+		{Title: "Both tags", Status: "doing", Tags: []string{"Home", "Work"}},
+		{Title: "Work only", Status: "done", Tags: []string{"Work"}},
+		{Title: "Home only", Status: "doing", Tags: []string{"Home"}},
+	}
+	for _, input := range inputs {
+		if _, err := store.CreateTask(context.Background(), input); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewTags
+	ui.filter.Status = "doing"
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	for index, entry := range ui.tags {
+		if entry.Name == "Work" {
+			ui.selectedTags = index
+		}
+	}
+	if err := ui.toggleTagFilter(nil, nil); err != nil {
+		t.Fatalf("toggle tag filter: %v", err)
+	}
+	if len(ui.pending) != 1 || ui.pending[0].Title != "Both tags" || len(ui.done) != 0 {
+		t.Fatalf("expected only the doing task tagged Work, got pending %v done %v", ui.pending, ui.done)
+	}
+
+	for index, entry := range ui.tags {
+		if entry.Name == "Home" {
+			ui.selectedTags = index
+		}
+	}
+	if err := ui.toggleTagFilter(nil, nil); err != nil {
+		t.Fatalf("toggle tag filter: %v", err)
+	}
+	if len(ui.pending) != 2 {
+		t.Fatalf("expected any-of match to show 2 tasks, got %d", len(ui.pending))
+	}
+
+	if err := ui.toggleTagMatch(nil, nil); err != nil {
+		t.Fatalf("toggle tag match: %v", err)
+	}
+	if ui.filter.TagMatch != model.TagMatchAll {
+		t.Fatalf("expected all-of mode, got %q", ui.filter.TagMatch)
+	}
+	if len(ui.pending) != 1 || ui.pending[0].Title != "Both tags" {
+		t.Fatalf("expected all-of match to show only 'Both tags', got %v", ui.pending)
+	}
+}
+
+func TestSavedViewsSwitchAndPersist(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	inputs := []db.TaskInput{
+		{Title: "Office task", Status: "doing", Tags: []string{"Work"}},
+		{Title: "Home task", Status: "todo", Tags: []string{"Home"}},
+	}
+	for _, input := range inputs {
+		if _, err := store.CreateTask(context.Background(), input); err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	ui := newTestUI(store)
+	ui.filter = model.Filter{Tags: []string{"Work"}}
+	ui.activeTags["Work"] = struct{}{}
+	if err := ui.openViews(nil, nil); err != nil {
+		t.Fatalf("open views: %v", err)
+	}
+	if err := ui.startViewCreate(nil, nil); err != nil {
+		t.Fatalf("start view create: %v", err)
+	}
+	ui.viewNameValue = "Work"
+	if err := ui.submitViewName(nil, nil); err != nil {
+		t.Fatalf("submit view name: %v", err)
+	}
+	if ui.activeView == nil || ui.activeView.Name != "Work" {
+		t.Fatalf("expected new view to become active, got %v", ui.activeView)
+	}
+
+	if err := ui.cycleView(1)(nil, nil); err != nil {
+		t.Fatalf("cycle view: %v", err)
+	}
+	if ui.activeView == nil {
+		t.Fatalf("expected cycling to be ignored while the picker is open")
+	}
+	if err := ui.closeViews(nil, nil); err != nil {
+		t.Fatalf("close views: %v", err)
+	}
+	if err := ui.cycleView(1)(nil, nil); err != nil {
+		t.Fatalf("cycle view: %v", err)
+	}
+	if ui.activeView != nil || len(ui.pending) != 2 {
+		t.Fatalf("expected cycling past the last view to clear it, got %v with %d tasks", ui.activeView, len(ui.pending))
+	}
+
+	restored := newTestUI(store)
+	if err := restored.restoreActiveView(); err != nil {
+		t.Fatalf("restore active view: %v", err)
+	}
+	if restored.activeView != nil {
+		t.Fatalf("expected cleared view to stay cleared, got %v", restored.activeView)
+	}
+
+	if err := ui.cycleView(1)(nil, nil); err != nil {
+		t.Fatalf("cycle view: %v", err)
+	}
+	if len(ui.pending) != 1 || ui.pending[0].Title != "Office task" || !ui.isTagActive("Work") {
+		t.Fatalf("expected Work view to filter tasks, got %v", ui.pending)
+	}
+
+	restored = newTestUI(store)
+	if err := restored.restoreActiveView(); err != nil {
+		t.Fatalf("restore active view: %v", err)
+	}
+	if restored.activeView == nil || restored.activeView.Name != "Work" || !restored.isTagActive("Work") {
+		t.Fatalf("expected Work view to be restored, got %v", restored.activeView)
+	}
+	if restored.viewModified() {
+		t.Fatalf("expected restored view to match its filter")
+	}
+	restored.filter.Status = "todo"
+	if !restored.viewModified() {
+		t.Fatalf("expected changed filter to mark the view as modified")
+	}
+}
+
 func TestToggleTaskStates(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -90,6 +228,9 @@ func TestToggleTaskStates(t *testing.T) {
 		if status != "doing" {
 			t.Fatalf("expected status 'doing', got %q", status)
 		}
+		if label := ui.trackedTimeLabel(ui.pending[0].ID); label != "0m (running)" {
+			t.Fatalf("expected a running timer, got %q", label)
+		}
 
 		if err := ui.toggleDoing(nil, nil); err != nil {
 			t.Fatalf("toggle doing again: %v", err)
@@ -98,6 +239,9 @@ func TestToggleTaskStates(t *testing.T) {
 		if status != "todo" {
 			t.Fatalf("expected status 'todo', got %q", status)
 		}
+		if label := ui.trackedTimeLabel(ui.pending[0].ID); label != "0m" {
+			t.Fatalf("expected the timer to stop, got %q", label)
+		}
 	})
 
 	t.Run("toggle done", func(t *testing.T) {
@@ -161,6 +305,632 @@ func TestToggleTaskStates(t *testing.T) {
 	})
 }
 
+func TestUndoRedoTaskOperations(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	parent, err := store.CreateTask(ctx, db.TaskInput{Title: "Parent", Status: "todo", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create parent: %v", err)
+	}
+	child, err := store.CreateTask(ctx, db.TaskInput{Title: "Child", Status: "todo", ParentTaskID: &parent.ID})
+	if err != nil {
+		t.Fatalf("create child: %v", err)
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewPending
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	selectTask := func(id int64) {
+		t.Helper()
+		for index, task := range ui.pending {
+			if task.ID == id {
+				ui.selectedPending = index
+				return
+			}
+		}
+		t.Fatalf("task %d not in pending list", id)
+	}
+
+	selectTask(child.ID)
+	if err := ui.deleteTask(nil, nil); err != nil {
+		t.Fatalf("delete child: %v", err)
+	}
+	selectTask(parent.ID)
+	if err := ui.toggleDone(nil, nil); err != nil {
+		t.Fatalf("toggle done: %v", err)
+	}
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo toggle: %v", err)
+	}
+	if status := taskStatusByID(t, store, parent.ID); status != "todo" {
+		t.Fatalf("expected status reverted to todo, got %q", status)
+	}
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo delete: %v", err)
+	}
+	restored, err := store.GetTaskWithTags(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("expected child restored: %v", err)
+	}
+	if restored.ParentTaskID == nil || *restored.ParentTaskID != parent.ID {
+		t.Fatalf("expected child parent %d, got %v", parent.ID, restored.ParentTaskID)
+	}
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo with empty stack: %v", err)
+	}
+	if ui.status != "Nothing to undo" {
+		t.Fatalf("expected empty undo stack, got status %q", ui.status)
+	}
+
+	if err := ui.redo(nil, nil); err != nil {
+		t.Fatalf("redo delete: %v", err)
+	}
+	if err := ui.redo(nil, nil); err != nil {
+		t.Fatalf("redo toggle: %v", err)
+	}
+	if _, err := store.GetTaskWithTags(ctx, child.ID); err == nil {
+		t.Fatalf("expected child deleted again after redo")
+	}
+	if status := taskStatusByID(t, store, parent.ID); status != "done" {
+		t.Fatalf("expected parent done after redo, got %q", status)
+	}
+
+	// Undoing a tag deletion puts the tag back on its tasks; a new action
+	// clears what could be redone.
+	ui.focus = viewTags
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	ui.selectedTags = 0
+	if err := ui.deleteTag(nil, nil); err != nil {
+		t.Fatalf("delete tag: %v", err)
+	}
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo delete tag: %v", err)
+	}
+	tagged, err := store.GetTaskWithTags(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("get parent: %v", err)
+	}
+	if len(tagged.Tags) != 1 || tagged.Tags[0].Name != "work" {
+		t.Fatalf("expected tag restored, got %+v", tagged.Tags)
+	}
+	if err := ui.redo(nil, nil); err != nil {
+		t.Fatalf("redo delete tag: %v", err)
+	}
+	tags, err := store.ListTags(ctx)
+	if err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("expected tag deleted again, got %+v", tags)
+	}
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo delete tag again: %v", err)
+	}
+	ui.focus = viewDone
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	ui.selectedDone = 0
+	if err := ui.toggleDone(nil, nil); err != nil {
+		t.Fatalf("toggle done: %v", err)
+	}
+	if len(ui.redoStack) != 0 {
+		t.Fatalf("expected a new action to clear the redo stack")
+	}
+}
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, title := range []string{"Keep me", "Drop me"} {
+		if _, err := store.CreateTask(ctx, db.TaskInput{Title: title, Status: "todo"}); err != nil {
+			t.Fatalf("create %q: %v", title, err)
+		}
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewPending
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	for len(ui.pending) > 0 {
+		ui.selectedPending = 0
+		if err := ui.deleteTask(nil, nil); err != nil {
+			t.Fatalf("delete task: %v", err)
+		}
+	}
+
+	if err := ui.openTrash(nil, nil); err != nil {
+		t.Fatalf("open trash: %v", err)
+	}
+	if !ui.trashActive || len(ui.trash) != 2 {
+		t.Fatalf("expected trash with 2 tasks, got %d", len(ui.trash))
+	}
+	for index, task := range ui.trash {
+		if task.Title == "Keep me" {
+			ui.selectedTrash = index
+		}
+	}
+	if err := ui.restoreSelectedTrash(nil, nil); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if len(ui.pending) != 1 || ui.pending[0].Title != "Keep me" {
+		t.Fatalf("expected restored task back in pending, got %+v", ui.pending)
+	}
+	if len(ui.trash) != 1 {
+		t.Fatalf("expected 1 task left in the trash, got %d", len(ui.trash))
+	}
+
+	if err := ui.purgeSelectedTrash(nil, nil); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if len(ui.trash) != 0 {
+		t.Fatalf("expected empty trash, got %d", len(ui.trash))
+	}
+	if err := ui.closeTrash(nil, nil); err != nil {
+		t.Fatalf("close trash: %v", err)
+	}
+
+	// Undoing the restore moves the task back to the trash.
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo restore: %v", err)
+	}
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(trash) != 1 || trash[0].Title != "Keep me" {
+		t.Fatalf("expected restore undone, got %+v", trash)
+	}
+}
+
+func TestRevertFieldFromHistory(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Draft", Description: "careful notes", Status: "todo"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if _, err := store.UpdateTask(ctx, task.ID, db.TaskInput{Title: "Final", Description: "oops", Status: "todo"}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewPending
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	ui.focus = viewHistory
+	// History is newest first, so the creation entry is last.
+	ui.selectedHistory = len(ui.history) - 1
+	if err := ui.openRevert(nil, nil); err != nil {
+		t.Fatalf("open revert: %v", err)
+	}
+	if !ui.revertActive || len(ui.revertOptions) != 3 {
+		t.Fatalf("expected all-fields, title and description options, got %+v", ui.revertOptions)
+	}
+	for index, option := range ui.revertOptions {
+		if option.field == model.FieldDescription {
+			ui.selectedRevert = index
+		}
+	}
+	if err := ui.applyRevert(nil, nil); err != nil {
+		t.Fatalf("apply revert: %v", err)
+	}
+	if ui.revertActive {
+		t.Fatalf("expected revert picker to close")
+	}
+
+	reverted, err := store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if reverted.Description != "careful notes" || reverted.Title != "Final" {
+		t.Fatalf("expected only the description reverted, got %q / %q", reverted.Title, reverted.Description)
+	}
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo revert: %v", err)
+	}
+	undone, err := store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if undone.Description != "oops" {
+		t.Fatalf("expected undo to restore 'oops', got %q", undone.Description)
+	}
+}
+
+func TestActivityModeListsAllTasks(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, title := range []string{"Standup notes", "Review PR"} {
+		if _, err := store.CreateTask(ctx, db.TaskInput{Title: title, Status: "todo"}); err != nil {
+			t.Fatalf("create %q: %v", title, err)
+		}
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewPending
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	if len(ui.history) != 1 {
+		t.Fatalf("expected the selected task's history, got %d entries", len(ui.history))
+	}
+
+	if err := ui.cycleHistoryMode(nil, nil); err != nil {
+		t.Fatalf("cycle history mode: %v", err)
+	}
+	if ui.historyMode != historyModeToday || ui.focus != viewHistory {
+		t.Fatalf("expected today's activity focused, got mode %q focus %q", ui.historyMode, ui.focus)
+	}
+	if len(ui.history) != 2 || ui.historyTitles[ui.history[0].TaskID] != "Review PR" {
+		t.Fatalf("expected both tasks newest first, got %+v", ui.history)
+	}
+	if task := ui.historyTask(); task == nil || task.Title != "Review PR" {
+		t.Fatalf("expected the highlighted task to follow the activity entry, got %+v", task)
+	}
+
+	for _, want := range []string{historyModeWeek, historyModeTask} {
+		if err := ui.cycleHistoryMode(nil, nil); err != nil {
+			t.Fatalf("cycle history mode: %v", err)
+		}
+		if ui.historyMode != want {
+			t.Fatalf("expected mode %q, got %q", want, ui.historyMode)
+		}
+	}
+	if len(ui.history) != 1 {
+		t.Fatalf("expected the task history again, got %d entries", len(ui.history))
+	}
+}
+
+func TestToggleDoneOnRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Water plants", Status: "todo", Recurrence: "FREQ=DAILY"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if _, err := store.CreateTask(ctx, db.TaskInput{Title: "Fill can", Status: "todo", ParentTaskID: &task.ID}); err != nil {
+		t.Fatalf("create subtask: %v", err)
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewPending
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	ui.selectedPending = 0
+	if err := ui.toggleDone(nil, nil); err != nil {
+		t.Fatalf("toggle done: %v", err)
+	}
+	var next *model.Task
+	for index := range ui.pending {
+		if ui.pending[index].Title == "Water plants" {
+			next = &ui.pending[index]
+		}
+	}
+	if len(ui.pending) != 3 || next == nil || next.ID == task.ID || next.Recurrence != "FREQ=DAILY" {
+		t.Fatalf("expected the next occurrence and a copy of its subtask pending, got %+v", ui.pending)
+	}
+	if !strings.Contains(formatTaskSummary(*next), "↻") {
+		t.Fatalf("expected a recurrence marker, got %q", formatTaskSummary(*next))
+	}
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if len(ui.pending) != 2 || ui.pending[0].ID != task.ID || ui.pending[0].Recurrence != "FREQ=DAILY" {
+		t.Fatalf("expected undo to remove the occurrence and reopen the task, got %+v", ui.pending)
+	}
+	if taskStatusByID(t, store, task.ID) != "todo" {
+		t.Fatalf("expected the original task to be todo again")
+	}
+}
+
+func TestBlockModeAddsAndRemovesBlockers(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	design, err := store.CreateTask(ctx, db.TaskInput{Title: "Design", Status: "todo"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	build, err := store.CreateTask(ctx, db.TaskInput{Title: "Build", Status: "todo"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	ui := newTestUI(store)
+	ui.focus = viewPending
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	indexOf := func(id int64) int {
+		for index, task := range ui.pending {
+			if task.ID == id {
+				return index
+			}
+		}
+		t.Fatalf("task %d not pending", id)
+		return 0
+	}
+
+	ui.selectedPending = indexOf(build.ID)
+	if err := ui.toggleBlockMode(nil, nil); err != nil {
+		t.Fatalf("pick task: %v", err)
+	}
+	ui.selectedPending = indexOf(design.ID)
+	if err := ui.toggleBlockMode(nil, nil); err != nil {
+		t.Fatalf("pick blocker: %v", err)
+	}
+	if ui.blockActive || !ui.blocked[build.ID] || ui.blocked[design.ID] {
+		t.Fatalf("expected Build to be blocked by Design, got %v (%s)", ui.blocked, ui.status)
+	}
+	lines := strings.Join(ui.dependencyLines(build.ID), "\n")
+	if !strings.Contains(lines, "Blocked by:") || !strings.Contains(lines, "Design (todo)") {
+		t.Fatalf("expected Build to list its blocker, got %q", lines)
+	}
+	if lines := strings.Join(ui.dependencyLines(design.ID), "\n"); !strings.Contains(lines, "Blocks:") || !strings.Contains(lines, "Build (todo)") {
+		t.Fatalf("expected Design to list the task it blocks, got %q", lines)
+	}
+
+	ui.selectedPending = indexOf(design.ID)
+	if err := ui.toggleBlockMode(nil, nil); err != nil {
+		t.Fatalf("pick task: %v", err)
+	}
+	ui.selectedPending = indexOf(build.ID)
+	if err := ui.toggleBlockMode(nil, nil); err != nil {
+		t.Fatalf("pick blocker: %v", err)
+	}
+	if !ui.blockActive || !strings.Contains(ui.status, "already waits") {
+		t.Fatalf("expected the cycle to be rejected, got %q", ui.status)
+	}
+	if err := ui.cancelPickMode(nil, nil); err != nil || ui.blockActive {
+		t.Fatalf("expected esc to leave block mode: %v", err)
+	}
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo: %v", err)
+	}
+	if ui.blocked[build.ID] || len(ui.dependencies) != 0 {
+		t.Fatalf("expected undo to remove the blocker, got %+v", ui.dependencies)
+	}
+	if err := ui.redo(nil, nil); err != nil {
+		t.Fatalf("redo: %v", err)
+	}
+	if !ui.blocked[build.ID] {
+		t.Fatalf("expected redo to add the blocker again")
+	}
+}
+
+func TestPomodoroLogsSessionsAgainstTask(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	task, err := store.CreateTask(context.Background(), db.TaskInput{Title: "Write report", Status: "todo"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	ui := newTestUI(store)
+	ui.options = Options{PomodoroWork: 20 * time.Minute, PomodoroBreak: 4 * time.Minute}
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+
+	start := time.Now()
+	ui.startPomodoro(task, start)
+	if label := ui.pomodoroLabel(start.Add(90 * time.Second)); label != "Focus 18:30 Write report" {
+		t.Fatalf("unexpected countdown %q", label)
+	}
+	if err := ui.tickPomodoro(start.Add(19 * time.Minute)); err != nil || ui.pomodoros[task.ID] != 0 {
+		t.Fatalf("expected nothing to be logged before the session ends (%v)", err)
+	}
+
+	if err := ui.tickPomodoro(start.Add(20 * time.Minute)); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if ui.pomodoros[task.ID] != 1 {
+		t.Fatalf("expected one completed session, got %d", ui.pomodoros[task.ID])
+	}
+	if label := ui.pomodoroLabel(start.Add(21 * time.Minute)); label != "Break 03:00" {
+		t.Fatalf("unexpected break countdown %q", label)
+	}
+
+	if err := ui.tickPomodoro(start.Add(24 * time.Minute)); err != nil {
+		t.Fatalf("tick: %v", err)
+	}
+	if ui.pomodoro != nil || ui.pomodoroLabel(start.Add(24*time.Minute)) != "" {
+		t.Fatalf("expected the session to end after the break")
+	}
+
+	ui.startPomodoro(task, start)
+	if err := ui.togglePomodoro(nil, nil); err != nil || ui.pomodoro != nil {
+		t.Fatalf("expected P to stop the session (%v)", err)
+	}
+	if err := ui.loadTasks(); err != nil || ui.pomodoros[task.ID] != 1 {
+		t.Fatalf("expected a stopped session not to be logged, got %d (%v)", ui.pomodoros[task.ID], err)
+	}
+}
+
+func TestUndoCreatePurgesTask(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	ui := newTestUI(store)
+	created, err := store.CreateTask(ctx, db.TaskInput{Title: "Typo", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	// As submitting the form does.
+	ui.recordUndo(undoEntry{label: taskLabel("create", created), changes: []taskChange{{taskID: created.ID, created: true}}})
+
+	if err := ui.undo(nil, nil); err != nil {
+		t.Fatalf("undo create: %v", err)
+	}
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(trash) != 0 {
+		t.Fatalf("expected undoing a create to leave nothing in the trash, got %+v", trash)
+	}
+	if _, err := store.SnapshotTask(ctx, created.ID); err != sql.ErrNoRows {
+		t.Fatalf("expected the task to be purged, got %v", err)
+	}
+
+	if err := ui.redo(nil, nil); err != nil {
+		t.Fatalf("redo create: %v", err)
+	}
+	redone, err := store.GetTaskWithTags(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("expected redo to bring the task back: %v", err)
+	}
+	if redone.Title != "Typo" || len(redone.Tags) != 1 {
+		t.Fatalf("expected the task as created, got %+v", redone)
+	}
+}
+
+func TestDueFieldAcceptsNaturalDates(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	fields := buildFormFields(nil, store.Location())
+	fields[fieldTitle].Value = "Renew passport"
+	fields[fieldDue].Value = "tomorrow"
+	ui := newTestUI(store)
+	ui.form = &formState{fields: fields}
+
+	tomorrow := time.Now().In(store.Location()).AddDate(0, 0, 1)
+	if hint := ui.formFieldHint(fieldDue); hint != " → "+tomorrow.Format("Mon 2006-01-02") {
+		t.Fatalf("unexpected due preview %q", hint)
+	}
+	input, err := parseFormFields(fields, store.Location())
+	if err != nil {
+		t.Fatalf("parse form: %v", err)
+	}
+	if input.DueAt == nil || input.DueAt.Format("2006-01-02") != tomorrow.Format("2006-01-02") {
+		t.Fatalf("expected due %s, got %v", tomorrow.Format("2006-01-02"), input.DueAt)
+	}
+
+	fields[fieldDue].Value = "someday"
+	if hint := ui.formFieldHint(fieldDue); hint != " → ?" {
+		t.Fatalf("expected an invalid preview, got %q", hint)
+	}
+	if _, err := parseFormFields(fields, store.Location()); err == nil {
+		t.Fatalf("expected an invalid due date to be rejected")
+	}
+}
+
+func TestAgendaGroupsDeadlinesAndJumpsToTask(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	now := time.Now()
+	dueOn := func(value string) *time.Time {
+		due, err := duedate.Parse(value, now)
+		if err != nil {
+			t.Fatalf("parse %q: %v", value, err)
+		}
+		instant := due.Instant()
+		return &instant
+	}
+	hourAgo := now.Add(-time.Hour).UTC().Truncate(time.Minute)
+	errands, err := store.CreateTask(ctx, db.TaskInput{Title: "Errands", Status: "todo"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	inputs := []db.TaskInput{
+		{Title: "File taxes", Status: "todo", DueAt: dueOn("yesterday"), DueAllDay: true},
+		{Title: "Call bank", Status: "doing", DueAt: &hourAgo},
+		{Title: "Water plants", Status: "todo", DueAt: dueOn("today"), DueAllDay: true},
+		{Title: "Renew passport", Status: "todo", DueAt: dueOn("+30d"), DueAllDay: true, ParentTaskID: &errands.ID},
+		{Title: "Old chore", Status: "done", DueAt: dueOn("yesterday"), DueAllDay: true},
+	}
+	var passport model.Task
+	for _, input := range inputs {
+		task, err := store.CreateTask(ctx, input)
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		if task.Title == "Renew passport" {
+			passport = task
+		}
+	}
+
+	ui := newTestUI(store)
+	ui.collapsed = map[int64]bool{errands.ID: true}
+	ui.filter.Query = "taxes"
+	if err := ui.loadTasks(); err != nil {
+		t.Fatalf("load tasks: %v", err)
+	}
+	if count := ui.overdueCount(now); count != 2 {
+		t.Fatalf("expected 2 overdue tasks, got %d", count)
+	}
+
+	if err := ui.openAgenda(nil, nil); err != nil || !ui.agendaActive {
+		t.Fatalf("expected D to open the agenda (%v)", err)
+	}
+	lines, _ := ui.agendaLines(now)
+	var headings []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, ">") {
+			headings = append(headings, line)
+		}
+	}
+	if strings.Join(headings, ",") != "Overdue,Today,Later" || len(lines) != 7 {
+		t.Fatalf("expected open tasks grouped by deadline, got %q", lines)
+	}
+	if marker := dueMarker(ui.agenda[0], now); !strings.Contains(marker, "\x1b[31m[due ") {
+		t.Fatalf("expected an overdue marker in red, got %q", marker)
+	}
+
+	ui.selectedAgenda = len(ui.agenda) - 1
+	if err := ui.jumpToAgendaTask(nil, nil); err != nil {
+		t.Fatalf("jump: %v", err)
+	}
+	if ui.agendaActive || ui.focus != viewPending {
+		t.Fatalf("expected enter to close the agenda and focus Pending, got %q", ui.focus)
+	}
+	if selected := ui.selectedTask(); selected == nil || selected.ID != passport.ID {
+		t.Fatalf("expected the task to be selected, got %+v", selected)
+	}
+	if ui.collapsed[errands.ID] || ui.filter.Query != "" {
+		t.Fatalf("expected the parent to expand and the filter to clear")
+	}
+}
+
+func taskStatusByID(t *testing.T, store *db.Store, id int64) string {
+	t.Helper()
+	task, err := store.GetTaskWithTags(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	return task.Status
+}
+
 func taskStatus(t *testing.T, store *db.Store) string {
 	t.Helper()
 	tasks, err := store.ListTasks(context.Background(), model.Filter{})
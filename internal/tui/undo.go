@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// maxUndo bounds how many operations can be undone.
+const maxUndo = 100
+
+// taskChange records one task before and after an operation. A nil before
+// means the operation created the task or took it out of the trash; a nil
+// after means it deleted it.
+type taskChange struct {
+	taskID int64
+	before *db.TaskSnapshot
+	after  *db.TaskSnapshot
+	// created tasks are purged on undo rather than moved to the trash.
+	created bool
+}
+
+type undoEntry struct {
+	label   string
+	changes []taskChange
+	// deletedTag is removed again on redo, after its tasks are updated.
+	deletedTag string
+	// blocker is a dependency change, reversed on undo and reapplied on
+	// redo.
+	blocker *dependencyChange
+}
+
+// captureTasks snapshots the given tasks ahead of an operation.
+func (u *UI) captureTasks(taskIDs ...int64) ([]taskChange, error) {
+	changes := make([]taskChange, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		before, err := u.snapshotTask(taskID)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, taskChange{taskID: taskID, before: before})
+	}
+	return changes, nil
+}
+
+func (u *UI) snapshotTask(taskID int64) (*db.TaskSnapshot, error) {
+	snap, err := u.store.SnapshotTask(context.Background(), taskID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// withOccurrences adds the next occurrence that completing a recurring task
+// just created, and its subtasks, to changes so that undo removes them again.
+func (u *UI) withOccurrences(changes []taskChange, taskID int64) ([]taskChange, error) {
+	nextID, ok, err := u.store.NextOccurrenceID(context.Background(), taskID)
+	if err != nil || !ok {
+		return changes, err
+	}
+
+	pending := []int64{nextID}
+	for len(pending) > 0 {
+		id := pending[0]
+		pending = pending[1:]
+		snap, err := u.store.SnapshotTask(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, taskChange{taskID: id, created: true})
+		pending = append(pending, snap.ChildIDs...)
+	}
+	return changes, nil
+}
+
+// recordUndo completes changes with the state after the operation and pushes
+// them onto the undo stack, dropping anything that could have been redone.
+func (u *UI) recordUndo(entry undoEntry) {
+	for index := range entry.changes {
+		after, err := u.snapshotTask(entry.changes[index].taskID)
+		if err != nil {
+			u.status = err.Error()
+			return
+		}
+		entry.changes[index].after = after
+	}
+	u.undoStack = append(u.undoStack, entry)
+	if len(u.undoStack) > maxUndo {
+		u.undoStack = slices.Delete(u.undoStack, 0, len(u.undoStack)-maxUndo)
+	}
+	u.redoStack = nil
+}
+
+// undoOrUnparent shares the u key with move mode, where it unparents the task.
+func (u *UI) undoOrUnparent(gui *gocui.Gui, view *gocui.View) error {
+	if u.moveActive {
+		return u.unparentMoveTask(gui, view)
+	}
+	return u.undo(gui, view)
+}
+
+func (u *UI) undo(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.moveActive {
+		return nil
+	}
+	if len(u.undoStack) == 0 {
+		u.status = "Nothing to undo"
+		return nil
+	}
+	entry := u.undoStack[len(u.undoStack)-1]
+	if err := u.applyUndo(entry, false); err != nil {
+		u.status = err.Error()
+		return u.loadTasks()
+	}
+	u.undoStack = u.undoStack[:len(u.undoStack)-1]
+	u.redoStack = append(u.redoStack, entry)
+	u.status = "Undid: " + entry.label
+	return u.loadTasks()
+}
+
+func (u *UI) redo(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() || u.moveActive {
+		return nil
+	}
+	if len(u.redoStack) == 0 {
+		u.status = "Nothing to redo"
+		return nil
+	}
+	entry := u.redoStack[len(u.redoStack)-1]
+	if err := u.applyUndo(entry, true); err != nil {
+		u.status = err.Error()
+		return u.loadTasks()
+	}
+	u.redoStack = u.redoStack[:len(u.redoStack)-1]
+	u.undoStack = append(u.undoStack, entry)
+	u.status = "Redid: " + entry.label
+	return u.loadTasks()
+}
+
+// applyUndo puts every task of entry back into its before state, or forward
+// into its after state when redo is set.
+func (u *UI) applyUndo(entry undoEntry, redo bool) error {
+	if entry.blocker != nil {
+		change := *entry.blocker
+		change.added = change.added == redo
+		return u.applyDependency(change)
+	}
+
+	ctx := context.Background()
+	return u.store.WithTx(ctx, func(tx *db.Store) error {
+		for offset := range entry.changes {
+			change := entry.changes[len(entry.changes)-1-offset]
+			target := change.before
+			if redo {
+				change = entry.changes[offset]
+				target = change.after
+			}
+			if target == nil {
+				err := tx.DeleteTask(ctx, change.taskID)
+				if err == nil && change.created && !redo {
+					err = tx.PurgeTask(ctx, change.taskID)
+				}
+				if err != nil && err != sql.ErrNoRows {
+					return err
+				}
+				continue
+			}
+			if _, err := tx.RestoreTask(ctx, *target); err != nil {
+				return err
+			}
+		}
+
+		if !redo || entry.deletedTag == "" {
+			return nil
+		}
+		tags, err := tx.ListTags(ctx)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if tag.Name == entry.deletedTag {
+				return tx.DeleteTag(ctx, tag.ID)
+			}
+		}
+		return nil
+	})
+}
+
+// tasksWithTag returns the IDs of every task carrying the tag, regardless of
+// the active filters.
+func (u *UI) tasksWithTag(name string) ([]int64, error) {
+	tasks, err := u.store.ListTasks(context.Background(), model.Filter{Tags: []string{name}})
+	if err != nil {
+		return nil, err
+	}
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+	return taskIDs, nil
+}
+
+func taskLabel(action string, task model.Task) string {
+	return fmt.Sprintf("%s %q", action, task.Title)
+}
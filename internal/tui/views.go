@@ -0,0 +1,412 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	goerrors "github.com/go-errors/errors"
+	"github.com/jesseduffield/gocui"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// restoreActiveView applies the view that was active when the UI last exited.
+func (u *UI) restoreActiveView() error {
+	view, err := u.store.ActiveView(context.Background())
+	if err != nil {
+		return err
+	}
+	if view != nil {
+		u.applyFilter(*view)
+	}
+	return nil
+}
+
+func (u *UI) applyFilter(view model.View) {
+	u.activeView = &view
+	u.filter = view.Filter
+	u.filter.Tags = slices.Clone(view.Filter.Tags)
+	u.activeTags = make(map[string]struct{}, len(u.filter.Tags))
+	for _, name := range u.filter.Tags {
+		u.activeTags[name] = struct{}{}
+	}
+}
+
+// activateView switches to view, or back to no view and no filters when view
+// is nil, and remembers the choice for the next session.
+func (u *UI) activateView(view *model.View) error {
+	var viewID int64
+	if view != nil {
+		viewID = view.ID
+		u.applyFilter(*view)
+	} else {
+		u.activeView = nil
+		u.filter = model.Filter{}
+		u.activeTags = make(map[string]struct{})
+	}
+	if err := u.store.SetActiveView(context.Background(), viewID); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.status = ""
+	return u.loadTasks()
+}
+
+// viewModified reports whether the current filter differs from the active view.
+func (u *UI) viewModified() bool {
+	if u.activeView == nil {
+		return false
+	}
+	return !filtersEqual(u.filter, u.activeView.Filter)
+}
+
+func filtersEqual(a, b model.Filter) bool {
+	if a.Query != b.Query || a.Status != b.Status || tagMatchLabel(a.TagMatch) != tagMatchLabel(b.TagMatch) {
+		return false
+	}
+	if !timesEqual(a.DueBefore, b.DueBefore) || !timesEqual(a.DueAfter, b.DueAfter) {
+		return false
+	}
+	aTags := slices.Sorted(slices.Values(a.Tags))
+	bTags := slices.Sorted(slices.Values(b.Tags))
+	return slices.Equal(aTags, bTags)
+}
+
+func (u *UI) loadViews() error {
+	views, err := u.store.ListViews(context.Background())
+	if err != nil {
+		return err
+	}
+	u.views = views
+	if u.selectedView >= len(u.views) {
+		u.selectedView = max(len(u.views)-1, 0)
+	}
+	return nil
+}
+
+func (u *UI) openViews(gui *gocui.Gui, _ *gocui.View) error {
+	if u.inputActive() {
+		return nil
+	}
+	if err := u.loadViews(); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.selectedView = 0
+	if u.activeView != nil {
+		for index, view := range u.views {
+			if view.ID == u.activeView.ID {
+				u.selectedView = index
+			}
+		}
+	}
+	u.viewsActive = true
+	return nil
+}
+
+func (u *UI) closeViews(gui *gocui.Gui, _ *gocui.View) error {
+	u.viewsActive = false
+	if gui != nil {
+		_ = gui.DeleteView(viewViews)
+		_, _ = gui.SetCurrentView(u.focus)
+	}
+	return nil
+}
+
+func (u *UI) moveViewSelection(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(gui *gocui.Gui, _ *gocui.View) error {
+		if len(u.views) == 0 {
+			return nil
+		}
+		u.selectedView = min(max(u.selectedView+delta, 0), len(u.views)-1)
+		return nil
+	}
+}
+
+func (u *UI) selectedSavedView() *model.View {
+	if u.selectedView < 0 || u.selectedView >= len(u.views) {
+		return nil
+	}
+	return &u.views[u.selectedView]
+}
+
+func (u *UI) applySelectedView(gui *gocui.Gui, view *gocui.View) error {
+	selected := u.selectedSavedView()
+	if selected == nil {
+		return nil
+	}
+	chosen := *selected
+	if err := u.closeViews(gui, view); err != nil {
+		return err
+	}
+	return u.activateView(&chosen)
+}
+
+// overwriteSelectedView stores the current filter in the selected view.
+func (u *UI) overwriteSelectedView(gui *gocui.Gui, _ *gocui.View) error {
+	selected := u.selectedSavedView()
+	if selected == nil {
+		return nil
+	}
+	updated := *selected
+	updated.Filter = u.filter
+	saved, err := u.store.SaveView(context.Background(), updated)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	u.status = fmt.Sprintf("Saved view %q", saved.Name)
+	if u.activeView != nil && u.activeView.ID == saved.ID {
+		u.activeView = &saved
+	}
+	return u.loadViews()
+}
+
+func (u *UI) deleteSelectedView(gui *gocui.Gui, _ *gocui.View) error {
+	selected := u.selectedSavedView()
+	if selected == nil {
+		return nil
+	}
+	if err := u.store.DeleteView(context.Background(), selected.ID); err != nil {
+		u.status = err.Error()
+		return nil
+	}
+	if u.activeView != nil && u.activeView.ID == selected.ID {
+		u.activeView = nil
+		if err := u.store.SetActiveView(context.Background(), 0); err != nil {
+			u.status = err.Error()
+			return nil
+		}
+	}
+	u.status = ""
+	return u.loadViews()
+}
+
+func (u *UI) startViewCreate(gui *gocui.Gui, _ *gocui.View) error {
+	u.viewNameActive = true
+	u.viewNameID = 0
+	u.viewNameValue = ""
+	return nil
+}
+
+func (u *UI) startViewRename(gui *gocui.Gui, _ *gocui.View) error {
+	selected := u.selectedSavedView()
+	if selected == nil {
+		return nil
+	}
+	u.viewNameActive = true
+	u.viewNameID = selected.ID
+	u.viewNameValue = selected.Name
+	return nil
+}
+
+// submitViewName saves the current filter as a new view or renames the
+// selected one, depending on how the prompt was opened.
+func (u *UI) submitViewName(gui *gocui.Gui, view *gocui.View) error {
+	if !u.viewNameActive {
+		return nil
+	}
+	name := strings.TrimSpace(u.viewNameValue)
+	if view != nil {
+		name = strings.TrimSpace(view.Buffer())
+	}
+
+	toSave := model.View{Name: name, Filter: u.filter}
+	if u.viewNameID != 0 {
+		existing, err := u.store.GetView(context.Background(), u.viewNameID)
+		if err != nil {
+			u.status = err.Error()
+			return nil
+		}
+		toSave = existing
+		toSave.Name = name
+	}
+	saved, err := u.store.SaveView(context.Background(), toSave)
+	if err != nil {
+		u.status = err.Error()
+		return nil
+	}
+
+	creating := u.viewNameID == 0
+	if err := u.closeViewName(gui, view); err != nil {
+		return err
+	}
+	if err := u.loadViews(); err != nil {
+		return err
+	}
+	for index, entry := range u.views {
+		if entry.ID == saved.ID {
+			u.selectedView = index
+		}
+	}
+	if creating {
+		u.activeView = &saved
+		if err := u.store.SetActiveView(context.Background(), saved.ID); err != nil {
+			u.status = err.Error()
+			return nil
+		}
+	} else if u.activeView != nil && u.activeView.ID == saved.ID {
+		u.activeView.Name = saved.Name
+	}
+	u.status = fmt.Sprintf("Saved view %q", saved.Name)
+	return nil
+}
+
+func (u *UI) closeViewName(gui *gocui.Gui, _ *gocui.View) error {
+	u.viewNameActive = false
+	u.viewNameID = 0
+	u.viewNameValue = ""
+	if gui != nil {
+		_ = gui.DeleteView(viewViewName)
+		if u.viewsActive {
+			_, _ = gui.SetCurrentView(viewViews)
+		} else {
+			_, _ = gui.SetCurrentView(u.focus)
+		}
+	}
+	return nil
+}
+
+// cycleView switches to the next (or previous) saved view without opening
+// the picker, passing through "no view" between the last and the first.
+func (u *UI) cycleView(delta int) func(*gocui.Gui, *gocui.View) error {
+	return func(gui *gocui.Gui, _ *gocui.View) error {
+		if u.inputActive() {
+			return nil
+		}
+		if err := u.loadViews(); err != nil {
+			u.status = err.Error()
+			return nil
+		}
+		if len(u.views) == 0 {
+			u.status = "No saved views; press V to create one"
+			return nil
+		}
+
+		// Position 0 is "no view", positions 1..n are the saved views.
+		current := 0
+		if u.activeView != nil {
+			for index, view := range u.views {
+				if view.ID == u.activeView.ID {
+					current = index + 1
+				}
+			}
+		}
+		next := (current + delta + len(u.views) + 1) % (len(u.views) + 1)
+		if next == 0 {
+			return u.activateView(nil)
+		}
+		chosen := u.views[next-1]
+		return u.activateView(&chosen)
+	}
+}
+
+func (u *UI) showViews(gui *gocui.Gui) error {
+	maxX, maxY := gui.Size()
+	width := max(50, maxX/2)
+	height := max(min(len(u.views)+3, maxY-4), 5)
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width
+	y1 := y0 + height
+
+	view, err := gui.SetView(viewViews, x0, y0, x1, y1, 0)
+	if err != nil && !goerrors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if goerrors.Is(err, gocui.ErrUnknownView) {
+		view.Title = "Views"
+		view.Footer = "enter apply | n new | s save | R rename | d delete | esc close"
+	}
+	view.FrameRunes = roundedFrameRunes
+	view.Clear()
+	if len(u.views) == 0 {
+		fmt.Fprintln(view, "  No saved views. Press n to save the current filters.")
+	}
+	for index, entry := range u.views {
+		prefix := " "
+		if index == u.selectedView {
+			prefix = ">"
+		}
+		marker := " "
+		if u.activeView != nil && u.activeView.ID == entry.ID {
+			marker = "*"
+		}
+		fmt.Fprintf(view, "%s %s %s  %s\n", prefix, marker, entry.Name, describeFilter(entry.Filter))
+	}
+	ensureSelectionVisible(view, u.selectedView, len(u.views))
+	setCursorToSelection(view, u.selectedView, len(u.views))
+	if !u.viewNameActive {
+		_, _ = gui.SetCurrentView(viewViews)
+	}
+	return nil
+}
+
+func (u *UI) showViewName(gui *gocui.Gui) error {
+	maxX, maxY := gui.Size()
+	width := max(40, maxX/3)
+	height := 3
+	x0 := (maxX - width) / 2
+	y0 := (maxY - height) / 2
+	x1 := x0 + width
+	y1 := y0 + height
+
+	view, err := gui.SetView(viewViewName, x0, y0, x1, y1, 0)
+	if err != nil && !goerrors.Is(err, gocui.ErrUnknownView) {
+		return err
+	}
+	if goerrors.Is(err, gocui.ErrUnknownView) {
+		view.Title = "Save View As"
+		if u.viewNameID != 0 {
+			view.Title = "Rename View"
+		}
+		view.Wrap = true
+		view.Clear()
+		fmt.Fprint(view, u.viewNameValue)
+		view.SetCursor(len([]rune(u.viewNameValue)), 0)
+	}
+	view.FrameRunes = roundedFrameRunes
+	view.Editable = true
+	view.Editor = gocui.DefaultEditor
+	_, _ = gui.SetViewOnTop(viewViewName)
+	_, _ = gui.SetCurrentView(viewViewName)
+	return nil
+}
+
+// describeFilter summarizes the non-empty parts of a filter for the picker.
+func describeFilter(filter model.Filter) string {
+	var parts []string
+	if filter.Query != "" {
+		parts = append(parts, fmt.Sprintf("search:%q", filter.Query))
+	}
+	if filter.Status != "" {
+		parts = append(parts, "status:"+filter.Status)
+	}
+	if len(filter.Tags) > 0 {
+		tags := "tags:" + strings.Join(filter.Tags, ",")
+		if len(filter.Tags) > 1 {
+			tags += "(" + tagMatchLabel(filter.TagMatch) + ")"
+		}
+		parts = append(parts, tags)
+	}
+	if filter.DueAfter != nil {
+		parts = append(parts, "due>="+filter.DueAfter.Format("2006-01-02"))
+	}
+	if filter.DueBefore != nil {
+		parts = append(parts, "due<="+filter.DueBefore.Format("2006-01-02"))
+	}
+	if len(parts) == 0 {
+		return "(all tasks)"
+	}
+	return strings.Join(parts, " ")
+}
+
+func timesEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
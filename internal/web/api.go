@@ -0,0 +1,355 @@
+package web
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
+	"github.com/Joseda-hg/lazytask/internal/model"
+	"github.com/Joseda-hg/lazytask/internal/taskform"
+)
+
+// taskBody is the JSON body of POST /api/v1/tasks and PUT /api/v1/tasks/{id}.
+// DueAt takes what the CLI's --due does, such as "2026-11-01",
+// "tomorrow 14:00" or an RFC 3339 time; times without a zone are read in the
+// store's location.
+type taskBody struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Status       string   `json:"status"`
+	Priority     int64    `json:"priority"`
+	DueAt        string   `json:"due_at"`
+	ParentTaskID *int64   `json:"parent_task_id"`
+	Recurrence   string   `json:"recurrence"`
+	Tags         []string `json:"tags"`
+}
+
+func (b taskBody) input(loc *time.Location) (db.TaskInput, error) {
+	due, err := taskform.ParseDue(b.DueAt, loc)
+	if err != nil {
+		return db.TaskInput{}, err
+	}
+	input := db.TaskInput{
+		Title:        strings.TrimSpace(b.Title),
+		Description:  strings.TrimSpace(b.Description),
+		Status:       b.Status,
+		Priority:     b.Priority,
+		ParentTaskID: b.ParentTaskID,
+		Recurrence:   b.Recurrence,
+		Tags:         b.Tags,
+	}
+	input.SetDue(due)
+	return input, nil
+}
+
+// apiCreateTaskHandler creates a task and answers 201 with the task and its
+// URL in Location.
+func (s *Server) apiCreateTaskHandler(w http.ResponseWriter, r *http.Request) {
+	var body taskBody
+	if err := decodeBody(r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	input, err := body.input(s.store.Location())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task, err := s.store.CreateTask(r.Context(), input)
+	if err != nil {
+		writeJSONError(w, apiErrorStatus(err), err)
+		return
+	}
+
+	payload, err := apiPayload(r, task, s.store.Location())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Location", apiPath(r, "/tasks/%d", task.ID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// apiPutTaskHandler replaces every field of a task; fields missing from the
+// body are cleared.
+func (s *Server) apiPutTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var body taskBody
+	if err := decodeBody(r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	input, err := body.input(s.store.Location())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.updateTask(w, r, id, func(db.TaskInput) (db.TaskInput, error) { return input, nil })
+}
+
+// apiPatchTaskHandler changes the fields present in the body, which takes
+// the fields of PUT; null clears due_at and parent_task_id.
+func (s *Server) apiPatchTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := decodeBody(r, &fields); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.updateTask(w, r, id, func(input db.TaskInput) (db.TaskInput, error) {
+		return patchInput(input, fields, s.store.Location())
+	})
+}
+
+// patchInput applies the fields of a PATCH body to input, reading due times
+// in loc.
+func patchInput(input db.TaskInput, fields map[string]json.RawMessage, loc *time.Location) (db.TaskInput, error) {
+	for name, value := range fields {
+		var err error
+		switch name {
+		case "title":
+			err = json.Unmarshal(value, &input.Title)
+			input.Title = strings.TrimSpace(input.Title)
+		case "description":
+			err = json.Unmarshal(value, &input.Description)
+			input.Description = strings.TrimSpace(input.Description)
+		case "status":
+			err = json.Unmarshal(value, &input.Status)
+		case "priority":
+			err = json.Unmarshal(value, &input.Priority)
+		case "due_at":
+			var due *string
+			if err = json.Unmarshal(value, &due); err == nil {
+				var parsed *duedate.Due
+				if due != nil {
+					parsed, err = taskform.ParseDue(*due, loc)
+				}
+				input.SetDue(parsed)
+			}
+		case "parent_task_id":
+			input.ParentTaskID = nil
+			err = json.Unmarshal(value, &input.ParentTaskID)
+		case "recurrence":
+			err = json.Unmarshal(value, &input.Recurrence)
+		case "tags":
+			input.Tags = nil
+			err = json.Unmarshal(value, &input.Tags)
+		default:
+			return db.TaskInput{}, fmt.Errorf("unknown field %q", name)
+		}
+		if err != nil {
+			return db.TaskInput{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return input, nil
+}
+
+// apiDeleteTaskHandler moves a task to the trash.
+func (s *Server) apiDeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.DeleteTask(r.Context(), id); err != nil {
+		writeTaskError(w, id, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiStatusHandler moves a task to another status. It takes
+// {"status": "doing"}; completing a recurring task creates its next
+// occurrence, as elsewhere.
+func (s *Server) apiStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if strings.TrimSpace(body.Status) == "" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("status is required"))
+		return
+	}
+	s.updateTask(w, r, id, func(input db.TaskInput) (db.TaskInput, error) {
+		input.Status = body.Status
+		return input, nil
+	})
+}
+
+// apiMoveHandler reparents a task. It takes {"parent_task_id": N}, or null
+// to make it a top-level task.
+func (s *Server) apiMoveHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		ParentTaskID *int64 `json:"parent_task_id"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.updateTask(w, r, id, func(input db.TaskInput) (db.TaskInput, error) {
+		input.ParentTaskID = body.ParentTaskID
+		return input, nil
+	})
+}
+
+// apiTagsHandler adds tags to a task (POST) or replaces them (PUT). It takes
+// {"tags": ["work", "urgent"]} and answers with the task.
+func (s *Server) apiTagsHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Tags []string `json:"tags"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.updateTask(w, r, id, func(input db.TaskInput) (db.TaskInput, error) {
+		if r.Method == http.MethodPut {
+			input.Tags = body.Tags
+		} else {
+			input.Tags = append(input.Tags, body.Tags...)
+		}
+		return input, nil
+	})
+}
+
+// apiRemoveTagHandler removes a tag from a task, answering 404 when the task
+// does not have it.
+func (s *Server) apiRemoveTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("tag")
+	s.updateTask(w, r, id, func(input db.TaskInput) (db.TaskInput, error) {
+		index := slices.IndexFunc(input.Tags, func(tag string) bool { return strings.EqualFold(tag, name) })
+		if index < 0 {
+			return db.TaskInput{}, notFoundError(fmt.Sprintf("task %d has no tag %q", id, name))
+		}
+		input.Tags = slices.Delete(input.Tags, index, index+1)
+		return input, nil
+	})
+}
+
+// updateTask loads a task, lets change edit it and saves it, answering with
+// the updated task. The whole edit is one transaction, so concurrent
+// requests cannot overwrite each other's changes.
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request, id int64, change func(db.TaskInput) (db.TaskInput, error)) {
+	ctx := r.Context()
+	var updated model.Task
+	err := s.store.WithTx(ctx, func(tx *db.Store) error {
+		task, err := tx.GetTaskWithTags(ctx, id)
+		if err != nil {
+			return err
+		}
+		input, err := change(db.InputFromTask(task))
+		var notFound notFoundError
+		switch {
+		case errors.As(err, &notFound):
+			return err
+		case err != nil:
+			return &db.ValidationError{Msg: err.Error()}
+		}
+		updated, err = tx.UpdateTask(ctx, id, input)
+		return err
+	})
+	if err != nil {
+		writeTaskError(w, id, err)
+		return
+	}
+	s.writeAPI(w, r, updated)
+}
+
+// pathID reads the {id} of the request path, answering 404 when it is not
+// a task ID.
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("invalid task ID %q", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeBody decodes a JSON request body into v, rejecting unknown fields.
+func decodeBody(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+// notFoundError reports something a request names that does not exist.
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+// apiErrorStatus maps store errors to status codes: 404 for tasks that do
+// not exist, 400 for input the store rejects and 500 otherwise.
+func apiErrorStatus(err error) int {
+	var (
+		notFound      notFoundError
+		validationErr *db.ValidationError
+		queryErr      *db.QueryError
+		revertErr     *db.RevertError
+		dependencyErr *db.DependencyError
+	)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.As(err, &notFound):
+		return http.StatusNotFound
+	case errors.As(err, &validationErr), errors.As(err, &queryErr), errors.As(err, &revertErr), errors.As(err, &dependencyErr):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writeTaskError answers with the status for a store error about task id.
+func writeTaskError(w http.ResponseWriter, id int64, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		err = notFoundError(fmt.Sprintf("task %d not found", id))
+	}
+	writeJSONError(w, apiErrorStatus(err), err)
+}
+
+// writeJSONError answers an API request with {"error": "..."}.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
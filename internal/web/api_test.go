@@ -0,0 +1,742 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestAPIV1ResponsesMatchOpenAPI(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+	spec := loadOpenAPI(t)
+
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	release, err := store.CreateTask(ctx, db.TaskInput{Title: "Plan release", Status: "doing", Priority: 2, DueAt: &due, Recurrence: "FREQ=WEEKLY", Tags: []string{"work", "ops"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	notes, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes", ParentTaskID: &release.ID})
+	if err != nil {
+		t.Fatalf("create subtask: %v", err)
+	}
+	if err := store.AddBlocker(ctx, release.ID, notes.ID); err != nil {
+		t.Fatalf("add blocker: %v", err)
+	}
+	if _, err := store.UpdateTask(ctx, notes.ID, db.TaskInput{Title: "Write release notes", ParentTaskID: &release.ID}); err != nil {
+		t.Fatalf("update task: %v", err)
+	}
+	trashed, err := store.CreateTask(ctx, db.TaskInput{Title: "Old idea"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := store.DeleteTask(ctx, trashed.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	history, err := store.ListHistory(ctx, notes.ID)
+	if err != nil || len(history) == 0 {
+		t.Fatalf("list history: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	_, readSecret, err := store.CreateAPIToken(ctx, "read", model.ScopeRead)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		secret string
+		status int
+	}{
+		{"GET", "/tasks", "", secret, http.StatusOK},
+		{"GET", "/tasks?tags=work&tag_match=all&due_before=2027-01-01", "", secret, http.StatusOK},
+		{"GET", "/tasks", "", "", http.StatusUnauthorized},
+		{"POST", "/tasks", `{"title": "Rotate keys", "due_at": "2026-12-01 09:00", "tags": ["ops"]}`, secret, http.StatusCreated},
+		{"POST", "/tasks", `{"title": ""}`, secret, http.StatusBadRequest},
+		{"POST", "/tasks", `{"title": "Read only"}`, readSecret, http.StatusForbidden},
+		{"GET", fmt.Sprintf("/tasks/%d", release.ID), "", secret, http.StatusOK},
+		{"GET", fmt.Sprintf("/tasks/%d", notes.ID), "", readSecret, http.StatusOK},
+		{"GET", "/tasks/999", "", secret, http.StatusNotFound},
+		{"PUT", fmt.Sprintf("/tasks/%d", notes.ID), `{"title": "Write notes", "parent_task_id": null}`, secret, http.StatusOK},
+		{"PATCH", fmt.Sprintf("/tasks/%d", notes.ID), `{"priority": 3, "due_at": "2026-11-02"}`, secret, http.StatusOK},
+		{"PATCH", fmt.Sprintf("/tasks/%d", notes.ID), `{"colour": "red"}`, secret, http.StatusBadRequest},
+		{"POST", fmt.Sprintf("/tasks/%d/status", notes.ID), `{"status": "doing"}`, secret, http.StatusOK},
+		{"POST", fmt.Sprintf("/tasks/%d/move", notes.ID), fmt.Sprintf(`{"parent_task_id": %d}`, release.ID), secret, http.StatusOK},
+		{"POST", fmt.Sprintf("/tasks/%d/move", release.ID), fmt.Sprintf(`{"parent_task_id": %d}`, notes.ID), secret, http.StatusBadRequest},
+		{"POST", fmt.Sprintf("/tasks/%d/tags", notes.ID), `{"tags": ["docs"]}`, secret, http.StatusOK},
+		{"PUT", fmt.Sprintf("/tasks/%d/tags", notes.ID), `{"tags": ["docs", "work"]}`, secret, http.StatusOK},
+		{"DELETE", fmt.Sprintf("/tasks/%d/tags/work", notes.ID), "", secret, http.StatusOK},
+		{"DELETE", fmt.Sprintf("/tasks/%d/tags/missing", notes.ID), "", secret, http.StatusNotFound},
+		{"POST", fmt.Sprintf("/tasks/%d/revert", notes.ID), fmt.Sprintf(`{"history_id": %d, "field": "title"}`, history[0].ID), secret, http.StatusOK},
+		{"GET", "/dependencies", "", secret, http.StatusOK},
+		{"GET", "/trash", "", secret, http.StatusOK},
+		{"GET", "/activity?since=week&limit=2", "", secret, http.StatusOK},
+		{"GET", "/activity?since=someday", "", secret, http.StatusBadRequest},
+		{"GET", "/events", "", secret, http.StatusOK},
+		{"DELETE", fmt.Sprintf("/tasks/%d", notes.ID), "", secret, http.StatusNoContent},
+	}
+
+	handler := NewServer(store, Options{}).Handler()
+	exercised := map[string]bool{}
+	for _, tt := range tests {
+		name := tt.method + " " + tt.path
+		request := httptest.NewRequest(tt.method, apiV1Prefix+tt.path, strings.NewReader(tt.body))
+		if tt.secret != "" {
+			request.Header.Set("Authorization", "Bearer "+tt.secret)
+		}
+		recorder := httptest.NewRecorder()
+		if strings.HasPrefix(tt.path, "/events") {
+			serveStream(handler, recorder, request)
+		} else {
+			handler.ServeHTTP(recorder, request)
+		}
+
+		if recorder.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", name, tt.status, recorder.Code, recorder.Body)
+			continue
+		}
+		template, operation := spec.operation(tt.method, strings.Split(tt.path, "?")[0])
+		if operation == nil {
+			t.Errorf("%s: not in openapi.json", name)
+			continue
+		}
+		exercised[tt.method+" "+template] = true
+		if err := spec.validateResponse(operation, recorder); err != nil {
+			t.Errorf("%s: %v\n%s", name, err, recorder.Body)
+		}
+	}
+
+	for _, operation := range spec.operations() {
+		if !exercised[operation] {
+			t.Errorf("%s is in openapi.json but not tested", operation)
+		}
+	}
+}
+
+func TestOpenAPIIsServedWithoutAToken(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	recorder := httptest.NewRecorder()
+	NewServer(store, Options{}).Handler().ServeHTTP(recorder, httptest.NewRequest("GET", openAPIPath, nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected the document as JSON, got %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		t.Fatalf("expected an OpenAPI 3 document, got version %q", version)
+	}
+
+	spec := loadOpenAPI(t)
+	var enum []string
+	for _, value := range spec.resolve("#/components/schemas/Task")["properties"].(map[string]any)["status"].(map[string]any)["enum"].([]any) {
+		enum = append(enum, value.(string))
+	}
+	if !slices.Equal(enum, model.Statuses) {
+		t.Fatalf("expected the status enum to be %v, got %v", model.Statuses, enum)
+	}
+}
+
+func TestUnversionedAPIKeepsModelFields(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes", Tags: []string{"docs"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	request := httptest.NewRequest("GET", fmt.Sprintf("/api/tasks/%d", task.ID), nil)
+	request.Header.Set("Authorization", "Bearer "+secret)
+	recorder := httptest.NewRecorder()
+	NewServer(store, Options{}).Handler().ServeHTTP(recorder, request)
+
+	var payload struct {
+		Task    map[string]any   `json:"task"`
+		History []map[string]any `json:"history"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	for _, field := range []string{"ID", "Title", "ParentTaskID", "Tags"} {
+		if _, ok := payload.Task[field]; !ok {
+			t.Fatalf("expected /api to keep field %q, got %v", field, payload.Task)
+		}
+	}
+	if len(payload.History) != 1 {
+		t.Fatalf("expected one history entry, got %v", payload.History)
+	}
+	for _, field := range []string{"ID", "TaskID", "EventType", "Details", "CreatedAt"} {
+		if _, ok := payload.History[0][field]; !ok {
+			t.Fatalf("expected /api history to keep field %q, got %v", field, payload.History[0])
+		}
+	}
+	if details, _ := payload.History[0]["Details"].(string); !strings.HasPrefix(details, "created:") {
+		t.Fatalf("expected the rendered details, got %q", details)
+	}
+}
+
+func TestV1AnswersServerErrorForUnmappedPayloads(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	recorder := httptest.NewRecorder()
+	NewServer(store, Options{}).writeAPI(recorder, httptest.NewRequest("GET", apiV1Prefix+"/tasks", nil), struct{ Name string }{"unmapped"})
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(recorder.Body.String(), `"error"`) {
+		t.Fatalf("expected a JSON 500, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestRevertRejectsUnknownFields(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	handler := NewServer(store, Options{}).Handler()
+	recorder := serveAPI(handler, "POST", fmt.Sprintf("/api/v1/tasks/%d/revert", task.ID), `{"histroy_id": 1}`, secret)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "histroy_id") {
+		t.Fatalf("expected 400 naming the unknown field, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestCreateTaskAnswersWithItsLocation(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	_, secret, err := store.CreateAPIToken(context.Background(), "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+
+	for _, prefix := range []string{"/api", apiV1Prefix} {
+		recorder := serveAPI(handler, "POST", prefix+"/tasks", `{"title": "Rotate keys", "tags": ["ops"]}`, secret)
+		if recorder.Code != http.StatusCreated {
+			t.Fatalf("%s: expected 201, got %d: %s", prefix, recorder.Code, recorder.Body)
+		}
+		location := recorder.Header().Get("Location")
+		if !strings.HasPrefix(location, prefix+"/tasks/") {
+			t.Fatalf("%s: expected a Location under %s/tasks, got %q", prefix, prefix, location)
+		}
+		recorder = serveAPI(handler, "GET", location, "", secret)
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "Rotate keys") {
+			t.Fatalf("%s: expected Location to serve the new task, got %d: %s", prefix, recorder.Code, recorder.Body)
+		}
+	}
+}
+
+func TestAPIAnswersNotFoundForMissingAndTrashedTasks(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	trashed, err := store.CreateTask(ctx, db.TaskInput{Title: "Old idea"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := store.DeleteTask(ctx, trashed.ID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+
+	for _, id := range []string{"999", fmt.Sprint(trashed.ID), "abc"} {
+		requests := []struct{ method, path, body string }{
+			{"GET", "/tasks/" + id, ""},
+			{"PUT", "/tasks/" + id, `{"title": "Back"}`},
+			{"PATCH", "/tasks/" + id, `{"title": "Back"}`},
+			{"DELETE", "/tasks/" + id, ""},
+			{"POST", "/tasks/" + id + "/status", `{"status": "done"}`},
+			{"POST", "/tasks/" + id + "/move", `{"parent_task_id": null}`},
+			{"POST", "/tasks/" + id + "/tags", `{"tags": ["work"]}`},
+		}
+		for _, req := range requests {
+			recorder := serveAPI(handler, req.method, apiV1Prefix+req.path, req.body, secret)
+			if recorder.Code != http.StatusNotFound {
+				t.Errorf("%s %s: expected 404, got %d: %s", req.method, req.path, recorder.Code, recorder.Body)
+				continue
+			}
+			var payload struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil || payload.Error == "" {
+				t.Errorf("%s %s: expected a JSON error, got %s", req.method, req.path, recorder.Body)
+			}
+		}
+	}
+}
+
+func TestAPIRejectsInvalidBodies(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+	path := fmt.Sprintf("/tasks/%d", task.ID)
+
+	tests := []struct{ method, path, body string }{
+		{"POST", "/tasks", `{"title": "Rotate keys", "colour": "red"}`},
+		{"POST", "/tasks", `{"title": "   "}`},
+		{"POST", "/tasks", `{"title": "Rotate keys", "status": "someday"}`},
+		{"POST", "/tasks", `{"title": "Rotate keys", "due_at": "whenever"}`},
+		{"POST", "/tasks", `{"title": "Rotate keys", "parent_task_id": 999}`},
+		{"POST", "/tasks", `not json`},
+		{"PUT", path, `{"title": "Write notes", "colour": "red"}`},
+		{"PUT", path, `{"title": ""}`},
+		{"PATCH", path, `{"colour": "red"}`},
+		{"PATCH", path, `{"priority": "high"}`},
+		{"PATCH", path, `{"due_at": "whenever"}`},
+		{"POST", path + "/status", `{}`},
+		{"POST", path + "/status", `{"status": "someday"}`},
+		{"POST", path + "/move", `{"parent": 1}`},
+		{"POST", path + "/tags", `{"tag": "work"}`},
+	}
+	for _, tt := range tests {
+		recorder := serveAPI(handler, tt.method, apiV1Prefix+tt.path, tt.body, secret)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: expected 400, got %d: %s", tt.method, tt.path, tt.body, recorder.Code, recorder.Body)
+		}
+	}
+
+	got, err := store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.Title != "Write notes" || got.Status != task.Status || got.Priority != task.Priority || got.DueAt != nil {
+		t.Fatalf("expected rejected requests to leave the task alone, got %+v", got)
+	}
+	tasks, err := store.ListTasks(ctx, model.Filter{})
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("expected rejected creates to add no task, got %d (%v)", len(tasks), err)
+	}
+}
+
+func TestPatchClearsNullFieldsAndKeepsAbsentOnes(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	parent, err := store.CreateTask(ctx, db.TaskInput{Title: "Release"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes", Description: "For the blog", Status: "doing", Priority: 2, DueAt: &due, DueAllDay: true, ParentTaskID: &parent.ID, Recurrence: "FREQ=WEEKLY", Tags: []string{"docs"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+
+	recorder := serveAPI(handler, "PATCH", fmt.Sprintf("%s/tasks/%d", apiV1Prefix, task.ID), `{"due_at": null, "parent_task_id": null, "priority": 3}`, secret)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	got, err := store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.DueAt != nil || got.DueAllDay || got.ParentTaskID != nil {
+		t.Fatalf("expected null to clear due_at and parent_task_id, got due %v parent %v", got.DueAt, got.ParentTaskID)
+	}
+	if got.Priority != 3 {
+		t.Fatalf("expected priority 3, got %d", got.Priority)
+	}
+	if got.Title != "Write notes" || got.Description != "For the blog" || got.Status != "doing" || got.Recurrence != "FREQ=WEEKLY" || len(got.Tags) != 1 || got.Tags[0].Name != "docs" {
+		t.Fatalf("expected absent fields to be kept, got %+v", got)
+	}
+}
+
+func TestRemoveTagIgnoresCase(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes", Tags: []string{"work", "docs"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	recorder := serveAPI(NewServer(store, Options{}).Handler(), "DELETE", fmt.Sprintf("%s/tasks/%d/tags/Work", apiV1Prefix, task.ID), "", secret)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	got, err := store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if len(got.Tags) != 1 || got.Tags[0].Name != "docs" {
+		t.Fatalf("expected only docs to be left, got %+v", got.Tags)
+	}
+}
+
+func TestConcurrentEditsKeepEachOther(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+
+	const edits = 10
+	var wg sync.WaitGroup
+	for i := 0; i < edits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder := serveAPI(handler, "POST", fmt.Sprintf("%s/tasks/%d/tags", apiV1Prefix, task.ID), fmt.Sprintf(`{"tags": ["tag%d"]}`, i), secret)
+			if recorder.Code != http.StatusOK {
+				t.Errorf("add tag%d: expected 200, got %d: %s", i, recorder.Code, recorder.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if len(got.Tags) != edits {
+		t.Fatalf("expected every concurrent edit to be kept, got %d tags: %+v", len(got.Tags), got.Tags)
+	}
+}
+
+func TestMoveRejectsParentCycles(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	release, err := store.CreateTask(ctx, db.TaskInput{Title: "Release"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	notes, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes", ParentTaskID: &release.ID})
+	if err != nil {
+		t.Fatalf("create subtask: %v", err)
+	}
+	changelog, err := store.CreateTask(ctx, db.TaskInput{Title: "Changelog", ParentTaskID: &notes.ID})
+	if err != nil {
+		t.Fatalf("create subtask: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+
+	for _, parentID := range []int64{release.ID, changelog.ID} {
+		path := fmt.Sprintf("%s/tasks/%d/move", apiV1Prefix, release.ID)
+		recorder := serveAPI(handler, "POST", path, fmt.Sprintf(`{"parent_task_id": %d}`, parentID), secret)
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("moving under %d: expected 400, got %d: %s", parentID, recorder.Code, recorder.Body)
+		}
+	}
+	got, err := store.GetTaskWithTags(ctx, release.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if got.ParentTaskID != nil {
+		t.Fatalf("expected the rejected move to leave the task top-level, got parent %d", *got.ParentTaskID)
+	}
+}
+
+// serveAPI makes a request with a bearer token, or none when secret is
+// empty.
+func serveAPI(handler http.Handler, method, path, body, secret string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if secret != "" {
+		request.Header.Set("Authorization", "Bearer "+secret)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// serveStream serves a request to an event stream, ending it once the
+// stream has sent its first message.
+func serveStream(handler http.Handler, recorder *httptest.ResponseRecorder, request *http.Request) {
+	ctx, cancel := context.WithCancel(request.Context())
+	defer cancel()
+	handler.ServeHTTP(cancelOnFlush{recorder, cancel}, request.WithContext(ctx))
+}
+
+// cancelOnFlush is a recorder that ends the request when it is flushed.
+type cancelOnFlush struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (w cancelOnFlush) Flush() {
+	w.ResponseRecorder.Flush()
+	w.cancel()
+}
+
+// openAPI is openapi.json, decoded to check responses against. It knows the
+// parts of OpenAPI 3.0 the document uses, and is stricter than the document:
+// responses may only have the properties their schema lists, so a field
+// cannot be added to the API without being documented.
+type openAPI map[string]any
+
+func loadOpenAPI(t *testing.T) openAPI {
+	t.Helper()
+	var spec openAPI
+	if err := json.Unmarshal(openAPIDocument, &spec); err != nil {
+		t.Fatalf("decode openapi.json: %v", err)
+	}
+	return spec
+}
+
+// operations lists the operations of the document as "METHOD /path".
+func (spec openAPI) operations() []string {
+	var operations []string
+	for template, item := range spec["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			if method != "parameters" {
+				operations = append(operations, strings.ToUpper(method)+" "+template)
+			}
+		}
+	}
+	sort.Strings(operations)
+	return operations
+}
+
+// operation finds the operation serving a request, returning its path
+// template.
+func (spec openAPI) operation(method, path string) (string, map[string]any) {
+	segments := strings.Split(path, "/")
+	for template, item := range spec["paths"].(map[string]any) {
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		matches := true
+		for i, part := range parts {
+			if part != segments[i] && !strings.HasPrefix(part, "{") {
+				matches = false
+				break
+			}
+		}
+		if operation, ok := item.(map[string]any)[strings.ToLower(method)].(map[string]any); matches && ok {
+			return template, operation
+		}
+	}
+	return "", nil
+}
+
+// resolve follows a local reference such as "#/components/schemas/Task".
+func (spec openAPI) resolve(ref string) map[string]any {
+	var node any = map[string]any(spec)
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = object[key]
+	}
+	object, _ := node.(map[string]any)
+	return object
+}
+
+func (spec openAPI) deref(node map[string]any) map[string]any {
+	for node != nil {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			break
+		}
+		node = spec.resolve(ref)
+	}
+	return node
+}
+
+func (spec openAPI) validateResponse(operation map[string]any, recorder *httptest.ResponseRecorder) error {
+	responses := operation["responses"].(map[string]any)
+	response, ok := responses[fmt.Sprint(recorder.Code)].(map[string]any)
+	if !ok {
+		if response, ok = responses["default"].(map[string]any); !ok {
+			return fmt.Errorf("status %d is not documented", recorder.Code)
+		}
+	}
+	response = spec.deref(response)
+
+	content, _ := response["content"].(map[string]any)
+	if len(content) == 0 {
+		if recorder.Body.Len() != 0 {
+			return fmt.Errorf("expected no body for status %d", recorder.Code)
+		}
+		return nil
+	}
+	contentType := recorder.Header().Get("Content-Type")
+	media, ok := content[contentType].(map[string]any)
+	if !ok {
+		return fmt.Errorf("content type %q is not documented for status %d", contentType, recorder.Code)
+	}
+	if contentType != "application/json" {
+		return nil
+	}
+	var body any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		return fmt.Errorf("decode body: %w", err)
+	}
+	return spec.validate(media["schema"].(map[string]any), body, "body")
+}
+
+// validate checks value against schema, naming the offending part of the
+// value by path.
+func (spec openAPI) validate(schema map[string]any, value any, path string) error {
+	schema = spec.deref(schema)
+	if parts, ok := schema["allOf"].([]any); ok {
+		schema = spec.merge(parts)
+	}
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: unexpected null", path)
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+	}
+
+	switch schema["type"] {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %T", path, value)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Errorf("%s: expected an integer, got %v", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", path, value)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", path, value)
+		}
+		for i, item := range items {
+			if err := spec.validate(schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", path, value)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required field %q", path, name)
+			}
+		}
+		for name, field := range object {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: field %q is not documented", path, name)
+			}
+			if err := spec.validate(property, field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s: schema has no type", path)
+	}
+	return nil
+}
+
+// merge combines the object schemas of an allOf into one.
+func (spec openAPI) merge(parts []any) map[string]any {
+	properties := map[string]any{}
+	var required []any
+	for _, part := range parts {
+		part := spec.deref(part.(map[string]any))
+		for name, property := range part["properties"].(map[string]any) {
+			properties[name] = property
+		}
+		if names, ok := part["required"].([]any); ok {
+			required = append(required, names...)
+		}
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}
+}
+
+func newTestStore(t *testing.T) (*db.Store, func()) {
+	t.Helper()
+	conn, err := db.Open(":memory:")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	return db.NewStore(conn), func() {
+		_ = conn.Close()
+	}
+}
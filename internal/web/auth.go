@@ -0,0 +1,79 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// authenticate guards every request. The API always takes an API token,
+// sent as "Authorization: Bearer <secret>". The HTML pages ask the browser
+// for a login whose password is an API token: every page when
+// Options.BasicAuth is set, otherwise only the forms that make changes. The
+// /events stream the pages subscribe to is read like a page. Read tokens can only make GET and HEAD requests. The
+// OpenAPI document is public, so clients can be generated without one.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api := strings.HasPrefix(r.URL.Path, "/api/")
+		if r.URL.Path == openAPIPath || !api && !s.options.BasicAuth && isRead(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := s.store.AuthenticateToken(r.Context(), requestSecret(r))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			unauthorized(w, api)
+			return
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !token.CanWrite() && !isRead(r) {
+			err := fmt.Errorf("token %q is %s only", token.Name, model.ScopeRead)
+			if api {
+				writeJSONError(w, http.StatusForbidden, err)
+			} else {
+				writeError(w, http.StatusForbidden, err)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isRead reports whether r only reads, which is all a read token may do.
+func isRead(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// requestSecret returns the token a request carries, either as a bearer
+// token or as the password of a basic-auth login.
+func requestSecret(r *http.Request) string {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	scheme, secret, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(secret)
+}
+
+// unauthorized asks for credentials: a bearer token for the API, a
+// basic-auth login the browser prompts for otherwise.
+func unauthorized(w http.ResponseWriter, api bool) {
+	err := fmt.Errorf("a valid API token is required; create one with `lazytask token create`")
+	if api {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="lazytask"`)
+		writeJSONError(w, http.StatusUnauthorized, err)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="lazytask", charset="UTF-8"`)
+	writeError(w, http.StatusUnauthorized, err)
+}
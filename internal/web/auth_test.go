@@ -0,0 +1,124 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestAuthenticateGuardsChangesAndEvents(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, writeSecret, err := store.CreateAPIToken(ctx, "write", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	_, readSecret, err := store.CreateAPIToken(ctx, "read", model.ScopeRead)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	const (
+		none = iota
+		read
+		write
+	)
+	tests := []struct {
+		method    string
+		path      string
+		body      string
+		basicAuth bool
+		token     int
+		status    int
+	}{
+		// Without web_basic_auth, pages and the event stream they subscribe
+		// to can be read without a login, but changes need a token.
+		{"GET", "/", "", false, none, http.StatusOK},
+		{"GET", fmt.Sprintf("/tasks/%d", task.ID), "", false, none, http.StatusOK},
+		{"GET", "/tasks/new", "", false, none, http.StatusOK},
+		{"GET", "/activity", "", false, none, http.StatusOK},
+		{"GET", "/events", "", false, none, http.StatusOK},
+		{"POST", "/tasks", "title=New", false, none, http.StatusUnauthorized},
+		{"POST", "/tasks", "title=New", false, read, http.StatusForbidden},
+		{"POST", "/tasks", "title=New", false, write, http.StatusSeeOther},
+		{"POST", fmt.Sprintf("/tasks/%d", task.ID), "title=Renamed", false, none, http.StatusUnauthorized},
+		{"POST", fmt.Sprintf("/tasks/%d", task.ID), "title=Renamed", false, read, http.StatusForbidden},
+		{"POST", fmt.Sprintf("/tasks/%d", task.ID), "title=Renamed", false, write, http.StatusSeeOther},
+		{"POST", fmt.Sprintf("/tasks/%d/status", task.ID), "status=done", false, none, http.StatusUnauthorized},
+		{"POST", fmt.Sprintf("/tasks/%d/status", task.ID), "status=done", false, read, http.StatusForbidden},
+		{"POST", fmt.Sprintf("/tasks/%d/status", task.ID), "status=done", false, write, http.StatusSeeOther},
+		{"POST", "/activity", "", false, none, http.StatusUnauthorized},
+		{"POST", fmt.Sprintf("/tasks/%d/delete", task.ID), "", false, none, http.StatusUnauthorized},
+		{"POST", fmt.Sprintf("/tasks/%d/delete", task.ID), "", false, read, http.StatusForbidden},
+		{"POST", fmt.Sprintf("/tasks/%d/delete", task.ID), "", false, write, http.StatusSeeOther},
+		// With it, every page needs one.
+		{"GET", "/", "", true, none, http.StatusUnauthorized},
+		{"GET", "/", "", true, read, http.StatusOK},
+		{"GET", "/events", "", true, none, http.StatusUnauthorized},
+		{"GET", "/events", "", true, read, http.StatusOK},
+		{"POST", "/tasks", "title=New", true, read, http.StatusForbidden},
+		{"POST", "/tasks", "title=New", true, write, http.StatusSeeOther},
+		// The API always does.
+		{"GET", apiV1Prefix + "/tasks", "", false, none, http.StatusUnauthorized},
+		{"GET", apiV1Prefix + "/tasks", "", false, read, http.StatusOK},
+		{"POST", apiV1Prefix + "/tasks", `{"title": "New"}`, false, read, http.StatusForbidden},
+		{"POST", apiV1Prefix + "/tasks", `{"title": "New"}`, false, write, http.StatusCreated},
+		{"GET", apiV1Prefix + "/events", "", false, none, http.StatusUnauthorized},
+		// The OpenAPI document never does.
+		{"GET", openAPIPath, "", false, none, http.StatusOK},
+		{"GET", openAPIPath, "", true, none, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		handler := NewServer(store, Options{BasicAuth: tt.basicAuth}).Handler()
+		name := fmt.Sprintf("%s %s (basic auth %t, token %d)", tt.method, tt.path, tt.basicAuth, tt.token)
+
+		request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.method == "POST" && !strings.HasPrefix(tt.path, "/api/") {
+			// Forms carry a CSRF token, so the answer is about the login.
+			const csrf = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+			form, _ := url.ParseQuery(tt.body)
+			form.Set(csrfField, csrf)
+			request = httptest.NewRequest(tt.method, tt.path, strings.NewReader(form.Encode()))
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.AddCookie(&http.Cookie{Name: csrfCookie, Value: csrf})
+		}
+		switch {
+		case tt.token == read && strings.HasPrefix(tt.path, "/api/"):
+			request.Header.Set("Authorization", "Bearer "+readSecret)
+		case tt.token == write && strings.HasPrefix(tt.path, "/api/"):
+			request.Header.Set("Authorization", "Bearer "+writeSecret)
+		case tt.token == read:
+			request.SetBasicAuth("ana", readSecret)
+		case tt.token == write:
+			request.SetBasicAuth("ana", writeSecret)
+		}
+		recorder := httptest.NewRecorder()
+		if strings.HasSuffix(tt.path, "/events") {
+			serveStream(handler, recorder, request)
+		} else {
+			handler.ServeHTTP(recorder, request)
+		}
+
+		if recorder.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", name, tt.status, recorder.Code, recorder.Body)
+			continue
+		}
+		if tt.status == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected a WWW-Authenticate challenge", name)
+		}
+	}
+}
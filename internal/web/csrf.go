@@ -0,0 +1,50 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+// The HTML forms are protected with a double-submit token: a random value
+// kept in a cookie that every form echoes back in a hidden field. Another
+// site can make a browser send the cookie, but cannot read it to fill in the
+// field.
+const (
+	csrfCookie = "lazytask_csrf"
+	csrfField  = "csrf_token"
+)
+
+// csrfToken returns the token for the forms of a page, setting a new one in
+// the cookie when the request has none. Call it before writing the body.
+func csrfToken(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(csrfCookie); err == nil && len(cookie.Value) == 64 {
+		return cookie.Value
+	}
+	token := make([]byte, 32)
+	_, _ = rand.Read(token)
+	value := hex.EncodeToString(token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return value
+}
+
+// checkCSRF wraps a form handler, refusing submissions whose token does not
+// match the cookie.
+func checkCSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(csrfCookie)
+		if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.PostFormValue(csrfField))) != 1 {
+			writeError(w, http.StatusForbidden, fmt.Errorf("invalid form token; reload the page and try again"))
+			return
+		}
+		next(w, r)
+	}
+}
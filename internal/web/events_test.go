@@ -0,0 +1,79 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestEventsStreamChanges(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeRead)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	server := httptest.NewServer(NewServer(store, Options{}).Handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, "GET", server.URL+apiV1Prefix+"/events", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+secret)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %q", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(response.Body)
+	// The stream is subscribed once the server has said so.
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("expected the stream to open with a comment, got %q (%v)", lines.Text(), lines.Err())
+	}
+
+	task, err := store.WithOrigin(db.Origin{Actor: "ana", Source: db.SourceCLI}).CreateTask(ctx, db.TaskInput{Title: "Rotate keys"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	var name, data string
+	for lines.Scan() {
+		line := lines.Text()
+		if line == "" && name != "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, "event: "); ok {
+			name = value
+		}
+		if value, ok := strings.CutPrefix(line, "data: "); ok {
+			data = value
+		}
+	}
+	if name != db.EventTask {
+		t.Fatalf("expected a %q event, got %q (%v)", db.EventTask, name, lines.Err())
+	}
+	var event db.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("decode event %q: %v", data, err)
+	}
+	if event.Kind != db.EventTask || event.Action != model.EventCreated || event.TaskID != task.ID || event.Actor != "ana" || event.Source != db.SourceCLI {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,257 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+	"github.com/Joseda-hg/lazytask/internal/taskform"
+)
+
+// formPage is the data of form.tmpl, which creates and edits tasks.
+type formPage struct {
+	Heading  string
+	Action   string
+	Cancel   string
+	CSRF     string
+	Error    string
+	Fields   taskform.Fields
+	ParentID int64
+	Parents  []parentOption
+	Statuses []string
+}
+
+// parentOption is a task that can be picked as the parent, labelled with
+// its depth in the tree.
+type parentOption struct {
+	ID    int64
+	Label string
+}
+
+// newTaskFormHandler shows an empty task form; ?parent=N makes it a subtask
+// of N.
+func (s *Server) newTaskFormHandler(w http.ResponseWriter, r *http.Request) {
+	page := formPage{Heading: "New task", Action: "/tasks", Cancel: "/", Fields: taskform.FromTask(nil, s.store.Location())}
+	if parentID, err := strconv.ParseInt(r.URL.Query().Get("parent"), 10, 64); err == nil {
+		page.ParentID = parentID
+		page.Heading = fmt.Sprintf("New subtask of #%d", parentID)
+		page.Cancel = fmt.Sprintf("/tasks/%d", parentID)
+	}
+	s.renderForm(w, r, http.StatusOK, page, 0)
+}
+
+// editTaskFormHandler shows the form of an existing task.
+func (s *Server) editTaskFormHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := htmlPathID(w, r)
+	if !ok {
+		return
+	}
+	task, err := s.store.GetTaskWithTags(context.Background(), id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	page := editPage(task)
+	page.Fields = taskform.FromTask(&task, s.store.Location())
+	if task.ParentTaskID != nil {
+		page.ParentID = *task.ParentTaskID
+	}
+	s.renderForm(w, r, http.StatusOK, page, id)
+}
+
+// createTaskFormHandler creates a task from the form and shows it, or shows
+// the form again with the error.
+func (s *Server) createTaskFormHandler(w http.ResponseWriter, r *http.Request) {
+	page := formPage{Heading: "New task", Action: "/tasks", Cancel: "/"}
+	input, err := s.readForm(r, &page)
+	if err == nil {
+		var task model.Task
+		if task, err = s.store.CreateTask(context.Background(), input); err == nil {
+			http.Redirect(w, r, fmt.Sprintf("/tasks/%d", task.ID), http.StatusSeeOther)
+			return
+		}
+	}
+	s.renderFormError(w, r, page, 0, err)
+}
+
+// updateTaskFormHandler saves the form of an existing task and shows it, or
+// shows the form again with the error.
+func (s *Server) updateTaskFormHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := htmlPathID(w, r)
+	if !ok {
+		return
+	}
+	task, err := s.store.GetTaskWithTags(context.Background(), id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	page := editPage(task)
+	input, err := s.readForm(r, &page)
+	if err == nil {
+		if _, err = s.store.UpdateTask(context.Background(), id, input); err == nil {
+			http.Redirect(w, r, fmt.Sprintf("/tasks/%d", id), http.StatusSeeOther)
+			return
+		}
+	}
+	s.renderFormError(w, r, page, id, err)
+}
+
+// taskStatusFormHandler moves a task to the status of the button pressed,
+// then goes back to the page named by next.
+func (s *Server) taskStatusFormHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := htmlPathID(w, r)
+	if !ok {
+		return
+	}
+	task, err := s.store.GetTaskWithTags(context.Background(), id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	input := db.InputFromTask(task)
+	input.Status = r.PostFormValue("status")
+	if _, err := s.store.UpdateTask(context.Background(), id, input); err != nil {
+		writeError(w, apiErrorStatus(err), err)
+		return
+	}
+	redirectBack(w, r, fmt.Sprintf("/tasks/%d", id))
+}
+
+// deleteTaskFormHandler moves a task to the trash.
+func (s *Server) deleteTaskFormHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := htmlPathID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.DeleteTask(context.Background(), id); err != nil {
+		writeError(w, apiErrorStatus(err), err)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func editPage(task model.Task) formPage {
+	return formPage{
+		Heading: fmt.Sprintf("Edit #%d", task.ID),
+		Action:  fmt.Sprintf("/tasks/%d", task.ID),
+		Cancel:  fmt.Sprintf("/tasks/%d", task.ID),
+	}
+}
+
+// readForm parses a submitted task form into page, so it can be shown again
+// as typed, and into the input the store takes. It parses the fields the way
+// the TUI's form does.
+func (s *Server) readForm(r *http.Request, page *formPage) (db.TaskInput, error) {
+	page.Fields = taskform.Fields{
+		Title:       r.PostFormValue("title"),
+		Description: r.PostFormValue("description"),
+		Status:      r.PostFormValue("status"),
+		Tags:        r.PostFormValue("tags"),
+		Due:         r.PostFormValue("due"),
+		Priority:    r.PostFormValue("priority"),
+		Recurrence:  r.PostFormValue("recurrence"),
+	}
+	page.ParentID = 0
+	if value := strings.TrimSpace(r.PostFormValue("parent_task_id")); value != "" {
+		parentID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return db.TaskInput{}, &db.ValidationError{Msg: fmt.Sprintf("invalid parent task %q", value)}
+		}
+		page.ParentID = parentID
+	}
+
+	input, err := page.Fields.Input(s.store.Location())
+	if err != nil {
+		return db.TaskInput{}, &db.ValidationError{Msg: err.Error()}
+	}
+	if page.ParentID != 0 {
+		input.ParentTaskID = &page.ParentID
+	}
+	return input, nil
+}
+
+// renderFormError shows the form again with err, or fails the request when
+// err is not about the input.
+func (s *Server) renderFormError(w http.ResponseWriter, r *http.Request, page formPage, taskID int64, err error) {
+	status := apiErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		writeError(w, status, err)
+		return
+	}
+	page.Error = err.Error()
+	s.renderForm(w, r, status, page, taskID)
+}
+
+// renderForm renders page; taskID is the task being edited, which is left
+// out of the parents together with its subtasks.
+func (s *Server) renderForm(w http.ResponseWriter, r *http.Request, status int, page formPage, taskID int64) {
+	parents, err := s.parentOptions(taskID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// Keep a parent that is not listed, such as one in the trash, rather
+	// than silently moving the task to the top level on save.
+	if page.ParentID != 0 && !slices.ContainsFunc(parents, func(option parentOption) bool { return option.ID == page.ParentID }) {
+		parents = append(parents, parentOption{ID: page.ParentID, Label: fmt.Sprintf("#%d (not listed)", page.ParentID)})
+	}
+	page.Parents = parents
+	page.Statuses = model.Statuses
+	page.CSRF = csrfToken(w, r)
+
+	w.WriteHeader(status)
+	if err := formTemplate.Execute(w, page); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// parentOptions lists the tasks a task can be moved under, in tree order.
+func (s *Server) parentOptions(taskID int64) ([]parentOption, error) {
+	tasks, err := s.store.ListTasks(context.Background(), model.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	options := make([]parentOption, 0, len(tasks))
+	skipDeeperThan := -1
+	for _, row := range buildTaskRows(tasks) {
+		if skipDeeperThan >= 0 && row.Depth > skipDeeperThan {
+			continue
+		}
+		skipDeeperThan = -1
+		if row.Task.ID == taskID {
+			skipDeeperThan = row.Depth
+			continue
+		}
+		label := fmt.Sprintf("%s#%d %s", strings.Repeat("— ", row.Depth), row.Task.ID, row.Task.Title)
+		options = append(options, parentOption{ID: row.Task.ID, Label: label})
+	}
+	return options, nil
+}
+
+// htmlPathID reads the {id} of the request path, answering 404 when it is
+// not a task ID.
+func htmlPathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("invalid task ID %q", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+// redirectBack sends the browser to the page in the form's next field, or to
+// fallback when it is missing or not a page of this server.
+func redirectBack(w http.ResponseWriter, r *http.Request, fallback string) {
+	next := r.PostFormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = fallback
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
@@ -0,0 +1,183 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+func TestFormsRejectMissingOrMismatchedCSRFTokens(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	task, err := store.CreateTask(ctx, db.TaskInput{Title: "Write notes"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+	token := formToken(t, handler)
+	other := strings.Repeat("0", len(token))
+
+	paths := []string{
+		"/tasks",
+		fmt.Sprintf("/tasks/%d", task.ID),
+		fmt.Sprintf("/tasks/%d/status", task.ID),
+		fmt.Sprintf("/tasks/%d/delete", task.ID),
+	}
+	tests := []struct {
+		name   string
+		cookie string
+		field  string
+	}{
+		{"no token", "", ""},
+		{"no cookie", "", token},
+		{"no field", token, ""},
+		{"mismatched field", token, other},
+	}
+	for _, path := range paths {
+		for _, tt := range tests {
+			form := url.Values{"title": {"Changed"}, "status": {"done"}}
+			if tt.field != "" {
+				form.Set(csrfField, tt.field)
+			}
+			recorder := postForm(handler, path, form, tt.cookie, secret)
+			if recorder.Code != http.StatusForbidden {
+				t.Errorf("POST %s with %s: expected 403, got %d", path, tt.name, recorder.Code)
+			}
+		}
+	}
+
+	tasks, err := store.ListTasks(ctx, model.Filter{})
+	if err != nil {
+		t.Fatalf("list tasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Write notes" || tasks[0].Status != task.Status {
+		t.Fatalf("expected refused submissions to change nothing, got %+v", tasks)
+	}
+}
+
+func TestFormsRedirectAndPersistChanges(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	_, secret, err := store.CreateAPIToken(ctx, "test", model.ScopeReadWrite)
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	handler := NewServer(store, Options{}).Handler()
+	token := formToken(t, handler)
+
+	recorder := postForm(handler, "/tasks", url.Values{csrfField: {token}, "title": {""}}, token, secret)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "<form") {
+		t.Fatalf("expected an invalid task to show the form again, got %d", recorder.Code)
+	}
+
+	form := url.Values{
+		csrfField:  {token},
+		"title":    {"Rotate keys"},
+		"status":   {"todo"},
+		"priority": {"2"},
+		"due":      {"2026-11-01"},
+		"tags":     {"ops, work"},
+	}
+	recorder = postForm(handler, "/tasks", form, token, secret)
+	if recorder.Code != http.StatusSeeOther {
+		t.Fatalf("create: expected 303, got %d: %s", recorder.Code, recorder.Body)
+	}
+	tasks, err := store.ListTasks(ctx, model.Filter{})
+	if err != nil || len(tasks) != 1 {
+		t.Fatalf("expected the task to be created, got %d (%v)", len(tasks), err)
+	}
+	task := tasks[0]
+	if location := recorder.Header().Get("Location"); location != fmt.Sprintf("/tasks/%d", task.ID) {
+		t.Fatalf("create: expected a redirect to the task, got %q", location)
+	}
+	if task.Title != "Rotate keys" || task.Priority != 2 || task.DueAt == nil || !task.DueAllDay || len(task.Tags) != 2 {
+		t.Fatalf("create: expected the form fields to be saved, got %+v", task)
+	}
+
+	form.Set("title", "Rotate API keys")
+	form.Set("due", "")
+	recorder = postForm(handler, fmt.Sprintf("/tasks/%d", task.ID), form, token, secret)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != fmt.Sprintf("/tasks/%d", task.ID) {
+		t.Fatalf("edit: expected a redirect to the task, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	task, err = store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Title != "Rotate API keys" || task.DueAt != nil {
+		t.Fatalf("edit: expected the changes to be saved, got %+v", task)
+	}
+
+	recorder = postForm(handler, fmt.Sprintf("/tasks/%d/status", task.ID), url.Values{csrfField: {token}, "status": {"done"}, "next": {"/?status=todo"}}, token, secret)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/?status=todo" {
+		t.Fatalf("status: expected a redirect to next, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	task, err = store.GetTaskWithTags(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Status != "done" {
+		t.Fatalf("status: expected done, got %q", task.Status)
+	}
+
+	recorder = postForm(handler, fmt.Sprintf("/tasks/%d/delete", task.ID), url.Values{csrfField: {token}}, token, secret)
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/" {
+		t.Fatalf("delete: expected a redirect to the list, got %d %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != task.ID {
+		t.Fatalf("delete: expected the task in the trash, got %+v", trash)
+	}
+}
+
+// formToken loads the new task form and returns the CSRF token its cookie
+// carries.
+func formToken(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/tasks/new", nil))
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == csrfCookie {
+			if !strings.Contains(recorder.Body.String(), cookie.Value) {
+				t.Fatalf("expected the form to carry the token of its cookie")
+			}
+			return cookie.Value
+		}
+	}
+	t.Fatalf("expected the form to set a %s cookie", csrfCookie)
+	return ""
+}
+
+// postForm submits form to path, sending cookie as the CSRF cookie and
+// secret as the login password unless they are empty.
+func postForm(handler http.Handler, path string, form url.Values, cookie, secret string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != "" {
+		request.AddCookie(&http.Cookie{Name: csrfCookie, Value: cookie})
+	}
+	if secret != "" {
+		request.SetBasicAuth("ana", secret)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
@@ -0,0 +1,215 @@
+package web
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Joseda-hg/lazytask/internal/model"
+)
+
+// apiV1Prefix is where version 1 of the API is served. Its responses are the
+// api* types below, which openapi.json describes; fields may be added to
+// them, but not renamed, retyped or removed. The unversioned /api paths are
+// kept for older clients: they answer with the model types, and with the
+// legacy* types below where a model type has since changed.
+const apiV1Prefix = "/api/v1"
+
+// openAPIPath serves openapi.json, the OpenAPI 3 description of /api/v1.
+const openAPIPath = "/api/openapi.json"
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIDocument)
+}
+
+// apiTask is a task in /api/v1. Tags are listed by name, as requests take
+// them.
+type apiTask struct {
+	ID           int64      `json:"id"`
+	ParentTaskID *int64     `json:"parent_task_id"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Status       string     `json:"status"`
+	Priority     int64      `json:"priority"`
+	DueAt        *time.Time `json:"due_at"`
+	DueAllDay    bool       `json:"due_all_day"`
+	Recurrence   string     `json:"recurrence"`
+	Tags         []string   `json:"tags"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+}
+
+type apiHistoryEntry struct {
+	ID        int64              `json:"id"`
+	TaskID    int64              `json:"task_id"`
+	EventType string             `json:"event_type"`
+	Changes   []apiHistoryChange `json:"changes"`
+	Actor     string             `json:"actor"`
+	Source    string             `json:"source"`
+	Text      string             `json:"text"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type apiHistoryChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type apiActivityEntry struct {
+	apiHistoryEntry
+	TaskTitle string `json:"task_title"`
+}
+
+type apiDependency struct {
+	TaskID    int64     `json:"task_id"`
+	BlockerID int64     `json:"blocker_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type apiTaskDetail struct {
+	Task      apiTask           `json:"task"`
+	BlockedBy []apiTask         `json:"blocked_by"`
+	Blocks    []apiTask         `json:"blocks"`
+	History   []apiHistoryEntry `json:"history"`
+}
+
+// taskDetail is the answer to GET /api/tasks/{id}.
+type taskDetail struct {
+	Task      model.Task           `json:"task"`
+	BlockedBy []model.Task         `json:"blocked_by"`
+	Blocks    []model.Task         `json:"blocks"`
+	History   []model.HistoryEntry `json:"history"`
+}
+
+// legacyTaskDetail is taskDetail as the unversioned /api answers it, with
+// history entries carrying the Details text they had before history was
+// structured.
+type legacyTaskDetail struct {
+	Task      model.Task           `json:"task"`
+	BlockedBy []model.Task         `json:"blocked_by"`
+	Blocks    []model.Task         `json:"blocks"`
+	History   []legacyHistoryEntry `json:"history"`
+}
+
+type legacyHistoryEntry struct {
+	model.HistoryEntry
+	Details string
+}
+
+// isV1 reports whether r is a request to /api/v1.
+func isV1(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiV1Prefix+"/")
+}
+
+// apiPath returns the URL of an API resource, under the version of the API
+// r was made to.
+func apiPath(r *http.Request, format string, args ...any) string {
+	prefix := "/api"
+	if isV1(r) {
+		prefix = apiV1Prefix
+	}
+	return prefix + fmt.Sprintf(format, args...)
+}
+
+// apiPayload returns what to answer r with for payload: its /api/v1 type for
+// requests to /api/v1, payload itself otherwise. History is rendered in loc
+// for the unversioned API. It fails for payloads /api/v1 has no type for.
+func apiPayload(r *http.Request, payload any, loc *time.Location) (any, error) {
+	if !isV1(r) {
+		if detail, ok := payload.(taskDetail); ok {
+			history := make([]legacyHistoryEntry, 0, len(detail.History))
+			for _, entry := range detail.History {
+				history = append(history, legacyHistoryEntry{HistoryEntry: entry, Details: entry.Details(loc)})
+			}
+			return legacyTaskDetail{Task: detail.Task, BlockedBy: detail.BlockedBy, Blocks: detail.Blocks, History: history}, nil
+		}
+		return payload, nil
+	}
+	switch payload := payload.(type) {
+	case model.Task:
+		return newAPITask(payload), nil
+	case []model.Task:
+		return newAPITasks(payload), nil
+	case taskDetail:
+		history := make([]apiHistoryEntry, 0, len(payload.History))
+		for _, entry := range payload.History {
+			history = append(history, newAPIHistoryEntry(entry))
+		}
+		return apiTaskDetail{
+			Task:      newAPITask(payload.Task),
+			BlockedBy: newAPITasks(payload.BlockedBy),
+			Blocks:    newAPITasks(payload.Blocks),
+			History:   history,
+		}, nil
+	case []model.Dependency:
+		dependencies := make([]apiDependency, 0, len(payload))
+		for _, dependency := range payload {
+			dependencies = append(dependencies, apiDependency{TaskID: dependency.TaskID, BlockerID: dependency.BlockerID, CreatedAt: dependency.CreatedAt})
+		}
+		return dependencies, nil
+	case []model.ActivityEntry:
+		activity := make([]apiActivityEntry, 0, len(payload))
+		for _, entry := range payload {
+			activity = append(activity, apiActivityEntry{apiHistoryEntry: newAPIHistoryEntry(entry.HistoryEntry), TaskTitle: entry.TaskTitle})
+		}
+		return activity, nil
+	}
+	// Every handler answering /api/v1 must have a type above; the contract
+	// tests catch the ones that do not.
+	return nil, fmt.Errorf("no /api/v1 representation for %T", payload)
+}
+
+func newAPITask(task model.Task) apiTask {
+	tags := make([]string, 0, len(task.Tags))
+	for _, tag := range task.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return apiTask{
+		ID:           task.ID,
+		ParentTaskID: task.ParentTaskID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		Priority:     task.Priority,
+		DueAt:        task.DueAt,
+		DueAllDay:    task.DueAllDay,
+		Recurrence:   task.Recurrence,
+		Tags:         tags,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+		DeletedAt:    task.DeletedAt,
+	}
+}
+
+func newAPITasks(tasks []model.Task) []apiTask {
+	result := make([]apiTask, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, newAPITask(task))
+	}
+	return result
+}
+
+func newAPIHistoryEntry(entry model.HistoryEntry) apiHistoryEntry {
+	changes := make([]apiHistoryChange, 0, len(entry.Changes))
+	for _, change := range entry.Changes {
+		changes = append(changes, apiHistoryChange{Field: change.Field, Old: change.Old, New: change.New})
+	}
+	return apiHistoryEntry{
+		ID:        entry.ID,
+		TaskID:    entry.TaskID,
+		EventType: entry.EventType,
+		Changes:   changes,
+		Actor:     entry.Actor,
+		Source:    entry.Source,
+		Text:      entry.Text,
+		CreatedAt: entry.CreatedAt,
+	}
+}
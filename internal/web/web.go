@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/Joseda-hg/lazytask/internal/db"
+	"github.com/Joseda-hg/lazytask/internal/duedate"
 	"github.com/Joseda-hg/lazytask/internal/model"
 )
 
@@ -20,46 +22,115 @@ import (
 var templateFS embed.FS
 
 var (
-	indexTemplate = template.Must(template.ParseFS(templateFS, "templates/index.tmpl"))
-	taskTemplate  = template.Must(template.ParseFS(templateFS, "templates/task.tmpl"))
+	indexTemplate    = parseTemplate("index.tmpl")
+	taskTemplate     = parseTemplate("task.tmpl")
+	activityTemplate = parseTemplate("activity.tmpl")
+	formTemplate     = parseTemplate("form.tmpl")
 )
 
+// activityPageSize is the number of entries on a page of /activity and the
+// default limit of /api/v1/activity.
+const activityPageSize = 50
+
 type Server struct {
-	store *db.Store
+	store   *db.Store
+	options Options
+}
+
+// Options configures the web server.
+type Options struct {
+	// BasicAuth makes the HTML pages ask for a login whose password is an
+	// API token, as the API does.
+	BasicAuth bool
 }
 
 type taskRow struct {
 	Task     model.Task
+	Depth    int
 	IndentPx int
+	Snippet  template.HTML
 }
 
-func NewServer(store *db.Store) *Server {
-	return &Server{store: store}
+func NewServer(store *db.Store, options Options) *Server {
+	return &Server{store: store, options: options}
 }
 
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.indexHandler)
-	mux.HandleFunc("/tasks/", s.taskHandler)
-	mux.HandleFunc("/api/tasks", s.apiTasksHandler)
-	mux.HandleFunc("/api/tasks/", s.apiTaskHandler)
-	return mux
+	mux.HandleFunc("GET /tasks/new", s.newTaskFormHandler)
+	mux.HandleFunc("POST /tasks", checkCSRF(s.createTaskFormHandler))
+	mux.HandleFunc("GET /tasks/{id}", s.taskHandler)
+	mux.HandleFunc("POST /tasks/{id}", checkCSRF(s.updateTaskFormHandler))
+	mux.HandleFunc("GET /tasks/{id}/edit", s.editTaskFormHandler)
+	mux.HandleFunc("POST /tasks/{id}/status", checkCSRF(s.taskStatusFormHandler))
+	mux.HandleFunc("POST /tasks/{id}/delete", checkCSRF(s.deleteTaskFormHandler))
+	mux.HandleFunc("/activity", s.activityHandler)
+	mux.HandleFunc("GET /events", s.eventsHandler)
+	mux.HandleFunc("GET "+openAPIPath, openAPIHandler)
+	s.apiRoutes(mux, apiV1Prefix)
+	s.apiRoutes(mux, "/api")
+	return s.authenticate(mux)
+}
+
+// apiRoutes registers the API under prefix. The handlers answer in the
+// version of the API the request path names; see apiPayload.
+func (s *Server) apiRoutes(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc("GET "+prefix+"/tasks", s.apiTasksHandler)
+	mux.HandleFunc("POST "+prefix+"/tasks", s.apiCreateTaskHandler)
+	mux.HandleFunc("GET "+prefix+"/tasks/{id}", s.apiTaskHandler)
+	mux.HandleFunc("PUT "+prefix+"/tasks/{id}", s.apiPutTaskHandler)
+	mux.HandleFunc("PATCH "+prefix+"/tasks/{id}", s.apiPatchTaskHandler)
+	mux.HandleFunc("DELETE "+prefix+"/tasks/{id}", s.apiDeleteTaskHandler)
+	mux.HandleFunc("POST "+prefix+"/tasks/{id}/status", s.apiStatusHandler)
+	mux.HandleFunc("POST "+prefix+"/tasks/{id}/move", s.apiMoveHandler)
+	mux.HandleFunc("POST "+prefix+"/tasks/{id}/tags", s.apiTagsHandler)
+	mux.HandleFunc("PUT "+prefix+"/tasks/{id}/tags", s.apiTagsHandler)
+	mux.HandleFunc("DELETE "+prefix+"/tasks/{id}/tags/{tag}", s.apiRemoveTagHandler)
+	mux.HandleFunc("POST "+prefix+"/tasks/{id}/revert", s.apiRevertHandler)
+	mux.HandleFunc("GET "+prefix+"/dependencies", s.apiDependenciesHandler)
+	mux.HandleFunc("GET "+prefix+"/trash", s.apiTrashHandler)
+	mux.HandleFunc("GET "+prefix+"/activity", s.apiActivityHandler)
+	mux.HandleFunc("GET "+prefix+"/events", s.eventsHandler)
 }
 
 func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
-	filter := filterFromRequest(r)
-	tasks, err := s.store.ListTasks(context.Background(), filter)
-	if err != nil {
+	csrf := csrfToken(w, r)
+	filter := filterFromRequest(r, s.store.Location())
+	results, err := s.store.SearchTasks(context.Background(), filter)
+	var queryErr *db.QueryError
+	if errors.As(err, &queryErr) {
+		w.WriteHeader(http.StatusBadRequest)
+		results = nil
+	} else if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	tasks := make([]model.Task, 0, len(results))
+	snippets := make(map[int64]string, len(results))
+	for _, result := range results {
+		tasks = append(tasks, result.Task)
+		snippets[result.Task.ID] = result.Snippet
+	}
+
 	rows := buildTaskRows(tasks)
+	for i := range rows {
+		rows[i].Snippet = highlightSnippet(snippets[rows[i].Task.ID])
+	}
 
 	data := struct {
-		Total int
-		Rows  []taskRow
-	}{Total: len(tasks), Rows: rows}
+		Total    int
+		Query    string
+		Error    string
+		Rows     []taskRow
+		CSRF     string
+		Self     string
+		Location *time.Location
+	}{Total: len(tasks), Query: filter.Query, Rows: rows, CSRF: csrf, Self: r.URL.RequestURI(), Location: s.store.Location()}
+	if queryErr != nil {
+		data.Error = queryErr.Error()
+	}
 
 	if err := indexTemplate.Execute(w, data); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
@@ -113,15 +184,43 @@ func buildTaskRows(tasks []model.Task) []taskRow {
 
 	rows := make([]taskRow, 0, len(visible))
 	for _, task := range visible {
-		rows = append(rows, taskRow{Task: task, IndentPx: depthByID[task.ID] * 20})
+		rows = append(rows, taskRow{Task: task, Depth: depthByID[task.ID], IndentPx: depthByID[task.ID] * 20})
 	}
 	return rows
 }
 
+// templateFuncs are available to every template; due renders the due date
+// of a task in a location, which pages pass as .Location.
+var templateFuncs = template.FuncMap{"due": func(task model.Task, loc *time.Location) string {
+	return duedate.Format(*task.DueAt, task.DueAllDay, loc)
+}}
+
+// parseTemplate parses a page together with the partials pages share.
+func parseTemplate(name string) *template.Template {
+	return template.Must(template.New(name).Funcs(templateFuncs).ParseFS(templateFS, "templates/"+name, "templates/live.tmpl"))
+}
+
+// highlightSnippet escapes a search snippet and wraps its matched terms in <mark>.
+func highlightSnippet(snippet string) template.HTML {
+	if snippet == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, part := range strings.SplitAfter(snippet, db.SnippetMatchEnd) {
+		before, match, found := strings.Cut(strings.TrimSuffix(part, db.SnippetMatchEnd), db.SnippetMatchStart)
+		b.WriteString(template.HTMLEscapeString(before))
+		if found {
+			b.WriteString("<mark>")
+			b.WriteString(template.HTMLEscapeString(match))
+			b.WriteString("</mark>")
+		}
+	}
+	return template.HTML(b.String())
+}
+
 func (s *Server) taskHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(r.URL.Path, "/tasks/")
-	if err != nil {
-		writeError(w, http.StatusNotFound, err)
+	id, ok := htmlPathID(w, r)
+	if !ok {
 		return
 	}
 
@@ -137,10 +236,29 @@ func (s *Server) taskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	blockedBy, blocks, err := s.dependencies(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	data := struct {
-		Task    model.Task
-		History []model.HistoryEntry
-	}{Task: task, History: history}
+		Task      model.Task
+		Repeats   string
+		BlockedBy []model.Task
+		Blocks    []model.Task
+		History   []model.HistoryEntry
+		Statuses  []string
+		CSRF      string
+		Related   []int64
+		Location  *time.Location
+	}{Task: task, BlockedBy: blockedBy, Blocks: blocks, History: history, Statuses: model.Statuses, CSRF: csrfToken(w, r), Related: []int64{task.ID}, Location: s.store.Location()}
+	for _, linked := range append(blockedBy, blocks...) {
+		data.Related = append(data.Related, linked.ID)
+	}
+	if rule, err := model.ParseRecurrence(task.Recurrence); err == nil {
+		data.Repeats = rule.Describe()
+	}
 
 	if err := taskTemplate.Execute(w, data); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
@@ -148,59 +266,214 @@ func (s *Server) taskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) activityHandler(w http.ResponseWriter, r *http.Request) {
+	sinceValue := strings.TrimSpace(r.URL.Query().Get("since"))
+	if sinceValue == "" {
+		sinceValue = "today"
+	}
+	page := 1
+	if value, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && value > 1 {
+		page = value
+	}
+
+	data := struct {
+		Since    string
+		Error    string
+		Activity []model.ActivityEntry
+		Page     int
+		PrevPage int
+		NextPage int
+		HasMore  bool
+		Location *time.Location
+	}{Since: sinceValue, Page: page, PrevPage: page - 1, NextPage: page + 1, Location: s.store.Location()}
+
+	since, err := model.ParseSince(sinceValue, time.Now().In(s.store.Location()))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		data.Error = err.Error()
+	} else {
+		// Fetch one extra entry to know whether there is an older page.
+		activity, err := s.store.ListActivity(context.Background(), since, activityPageSize+1, (page-1)*activityPageSize)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		data.HasMore = len(activity) > activityPageSize
+		data.Activity = activity[:min(len(activity), activityPageSize)]
+	}
+
+	if err := activityTemplate.Execute(w, data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
 func (s *Server) apiTasksHandler(w http.ResponseWriter, r *http.Request) {
-	filter := filterFromRequest(r)
+	filter := filterFromRequest(r, s.store.Location())
 	tasks, err := s.store.ListTasks(context.Background(), filter)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err)
+		writeJSONError(w, apiErrorStatus(err), err)
 		return
 	}
 
-	writeJSON(w, tasks)
+	s.writeAPI(w, r, tasks)
 }
 
 func (s *Server) apiTaskHandler(w http.ResponseWriter, r *http.Request) {
-	id, err := parseID(r.URL.Path, "/api/tasks/")
-	if err != nil {
-		writeError(w, http.StatusNotFound, err)
+	id, ok := pathID(w, r)
+	if !ok {
 		return
 	}
 
 	task, err := s.store.GetTaskWithTags(context.Background(), id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, err)
+		writeTaskError(w, id, err)
 		return
 	}
 
 	history, err := s.store.ListHistory(context.Background(), id)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err)
+		writeJSONError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	payload := struct {
-		Task    model.Task           `json:"task"`
-		History []model.HistoryEntry `json:"history"`
-	}{Task: task, History: history}
+	blockedBy, blocks, err := s.dependencies(id)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
 
-	writeJSON(w, payload)
+	s.writeAPI(w, r, taskDetail{Task: task, BlockedBy: blockedBy, Blocks: blocks, History: history})
+}
+
+// dependencies returns the tasks blocking a task and the tasks it blocks,
+// as empty rather than nil slices.
+func (s *Server) dependencies(taskID int64) ([]model.Task, []model.Task, error) {
+	blockedBy, err := s.store.ListBlockers(context.Background(), taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	blocks, err := s.store.ListDependents(context.Background(), taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if blockedBy == nil {
+		blockedBy = []model.Task{}
+	}
+	if blocks == nil {
+		blocks = []model.Task{}
+	}
+	return blockedBy, blocks, nil
+}
+
+// apiDependenciesHandler lists every edge between tasks outside the trash.
+func (s *Server) apiDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	dependencies, err := s.store.ListDependencies(context.Background())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.writeAPI(w, r, dependencies)
+}
+
+// apiRevertHandler reverts a task, or a single field of it, to the state
+// right after a history entry. It takes {"history_id": N, "field": "..."}.
+func (s *Server) apiRevertHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		HistoryID int64  `json:"history_id"`
+		Field     string `json:"field"`
+	}
+	if err := decodeBody(r, &body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if body.HistoryID == 0 {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("history_id is required"))
+		return
+	}
+
+	var fields []string
+	if body.Field != "" {
+		fields = append(fields, body.Field)
+	}
+	task, err := s.store.RevertTask(context.Background(), id, body.HistoryID, fields...)
+	if err != nil {
+		writeJSONError(w, apiErrorStatus(err), err)
+		return
+	}
+
+	s.writeAPI(w, r, task)
 }
 
-func filterFromRequest(r *http.Request) model.Filter {
+func (s *Server) apiTrashHandler(w http.ResponseWriter, r *http.Request) {
+	tasks, err := s.store.ListTrash(context.Background())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if tasks == nil {
+		tasks = []model.Task{}
+	}
+
+	s.writeAPI(w, r, tasks)
+}
+
+// apiActivityHandler lists history across all tasks, newest first. It takes
+// since (default today), limit and offset query parameters.
+func (s *Server) apiActivityHandler(w http.ResponseWriter, r *http.Request) {
+	since, err := model.ParseSince(r.URL.Query().Get("since"), time.Now().In(s.store.Location()))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit := activityPageSize
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+	}
+	offset := 0
+	if value := r.URL.Query().Get("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid offset %q", value))
+			return
+		}
+	}
+
+	activity, err := s.store.ListActivity(context.Background(), since, limit, offset)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeAPI(w, r, activity)
+}
+
+// filterFromRequest reads the filters of a task list from the query string,
+// with due dates relative to today in loc.
+func filterFromRequest(r *http.Request, loc *time.Location) model.Filter {
 	query := strings.TrimSpace(r.URL.Query().Get("q"))
 	status := strings.TrimSpace(r.URL.Query().Get("status"))
 
 	var dueBefore *time.Time
 	if value := strings.TrimSpace(r.URL.Query().Get("due_before")); value != "" {
-		if parsed, err := time.Parse("2006-01-02", value); err == nil {
-			dueBefore = &parsed
+		if due, err := duedate.Parse(value, time.Now().In(loc)); err == nil {
+			day := due.Day()
+			dueBefore = &day
 		}
 	}
 
 	var dueAfter *time.Time
 	if value := strings.TrimSpace(r.URL.Query().Get("due_after")); value != "" {
-		if parsed, err := time.Parse("2006-01-02", value); err == nil {
-			dueAfter = &parsed
+		if due, err := duedate.Parse(value, time.Now().In(loc)); err == nil {
+			day := due.Day()
+			dueAfter = &day
 		}
 	}
 
@@ -214,19 +487,24 @@ func filterFromRequest(r *http.Request) model.Filter {
 		}
 	}
 
-	return model.Filter{Query: query, Status: status, Tags: tags, DueBefore: dueBefore, DueAfter: dueAfter}
+	var tagMatch string
+	switch value := strings.TrimSpace(r.URL.Query().Get("tag_match")); value {
+	case model.TagMatchAny, model.TagMatchAll:
+		tagMatch = value
+	}
+
+	return model.Filter{Query: query, Status: status, Tags: tags, TagMatch: tagMatch, DueBefore: dueBefore, DueAfter: dueAfter}
 }
 
-func parseID(path, prefix string) (int64, error) {
-	if !strings.HasPrefix(path, prefix) {
-		return 0, fmt.Errorf("invalid path")
-	}
-	value := strings.TrimPrefix(path, prefix)
-	value = strings.Trim(value, "/")
-	if value == "" {
-		return 0, fmt.Errorf("missing id")
+// writeAPI answers an API request with payload, in the version of the API
+// the request was made to.
+func (s *Server) writeAPI(w http.ResponseWriter, r *http.Request, payload any) {
+	payload, err := apiPayload(r, payload, s.store.Location())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
 	}
-	return strconv.ParseInt(value, 10, 64)
+	writeJSON(w, payload)
 }
 
 func writeJSON(w http.ResponseWriter, payload any) {